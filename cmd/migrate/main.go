@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	_ "github.com/lib/pq"
+
+	"habit-tracker/logger"
+	"habit-tracker/migrate"
+	"habit-tracker/migrations"
+	"habit-tracker/postgres"
+)
+
+func main() {
+	log := logger.New("migrate")
+
+	database := flag.String("database", "", "postgres connection url, instead of the environment")
+	configFile := flag.String("config", "", "file of KEY=VALUE settings, instead of the environment")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: migrate [-database url | -config file] up|status|validate\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		cfg postgres.Config
+		err error
+	)
+
+	switch {
+	case *database != "":
+		cfg, err = postgres.ParseURL(*database)
+	case *configFile != "":
+		cfg, err = postgres.LoadConfigFile(*configFile)
+	default:
+		cfg, err = postgres.LoadConfig()
+	}
+	if err != nil {
+		log.Fatalf("[migrate][config][err:%s]", err.Error())
+	}
+
+	db, err := sql.Open("postgres", cfg.DSN())
+	if err != nil {
+		log.Fatalf("[migrate][open][err:%s]", err.Error())
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("[migrate][load][err:%s]", err.Error())
+	}
+
+	ctx := context.Background()
+
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			log.Infof("[migrate][up][version:%d][script:%s]", migration.Version, migration.Script)
+		}
+		if err != nil {
+			log.Fatalf("[migrate][up][err:%s]", err.Error())
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("[migrate][status][err:%s]", err.Error())
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tDESCRIPTION\tSTATE\tINSTALLED AT")
+		for _, status := range statuses {
+			installedAt := "-"
+			if status.InstalledAt != nil {
+				installedAt = status.InstalledAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Description, status.State, installedAt)
+		}
+		w.Flush()
+	case "validate":
+		err = migrator.Validate(ctx)
+		if err != nil {
+			log.Fatalf("[migrate][validate][err:%s]", err.Error())
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
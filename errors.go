@@ -0,0 +1,87 @@
+package habit_tracker
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	// ErrConflict reports a write clashing with another one, e.g. a duplicated
+	// unique value or a failed serializable transaction.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation reports values the storage rejected, e.g. a too long name.
+	ErrValidation = errors.New("validation failed")
+	// ErrUnavailable reports that the storage couldn't be reached or canceled
+	// the operation.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error reports a failure affecting specific rows of an entity. errors.Is
+// matches it against its Kind, e.g. errors.Is(err, ErrNotFound), and against
+// Err, the error of the storage that caused it, if any.
+type Error struct {
+	Kind   error
+	Entity string
+	IDs    []uint64
+	Err    error
+}
+
+func NewNotFoundError(entity string, ids ...uint64) *Error {
+	return &Error{
+		Kind:   ErrNotFound,
+		Entity: entity,
+		IDs:    ids,
+	}
+}
+
+// NewForeignKeyError reports that the rows of entity with the given ids,
+// referenced by the rows being written, don't exist.
+func NewForeignKeyError(entity string, ids ...uint64) *Error {
+	return &Error{
+		Kind:   ErrForeignKeyViolation,
+		Entity: entity,
+		IDs:    ids,
+	}
+}
+
+func NewConflictError(entity string, ids ...uint64) *Error {
+	return &Error{
+		Kind:   ErrConflict,
+		Entity: entity,
+		IDs:    ids,
+	}
+}
+
+func NewValidationError(entity string, ids ...uint64) *Error {
+	return &Error{
+		Kind:   ErrValidation,
+		Entity: entity,
+		IDs:    ids,
+	}
+}
+
+func NewUnavailableError(entity string, ids ...uint64) *Error {
+	return &Error{
+		Kind:   ErrUnavailable,
+		Entity: entity,
+		IDs:    ids,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("[%s:%v][err:%s][cause:%s]", e.Entity, e.IDs, e.Kind, e.Err)
+	}
+
+	return fmt.Sprintf("[%s:%v][err:%s]", e.Entity, e.IDs, e.Kind)
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+
+	return []error{e.Kind}
+}
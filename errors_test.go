@@ -0,0 +1,53 @@
+package habit_tracker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError_Unwrap(t *testing.T) {
+	type test struct {
+		name    string
+		err     *Error
+		wantIs  []error
+		wantNot []error
+		want    string
+	}
+
+	tests := []test{
+		{
+			name:    "WithoutCause",
+			err:     NewNotFoundError("habits", 1, 2),
+			wantIs:  []error{ErrNotFound},
+			wantNot: []error{ErrConflict, assert.AnError},
+			want:    "[habits:[1 2]][err:not found]",
+		},
+		{
+			name: "WithCause",
+			err: &Error{
+				Kind:   ErrUnavailable,
+				Entity: "tags",
+				Err:    assert.AnError,
+			},
+			wantIs:  []error{ErrUnavailable, assert.AnError},
+			wantNot: []error{ErrNotFound},
+			want:    "[tags:[]][err:unavailable][cause:" + assert.AnError.Error() + "]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				for _, target := range tt.wantIs {
+					assert.True(t, errors.Is(tt.err, target))
+				}
+				for _, target := range tt.wantNot {
+					assert.False(t, errors.Is(tt.err, target))
+				}
+				assert.Equal(t, tt.want, tt.err.Error())
+			},
+		)
+	}
+}
@@ -6,17 +6,41 @@ import (
 )
 
 type Event struct {
-	ID      uint64
-	HabitID uint64
-	Subject string
-	StartAt time.Time
-	EndAt   time.Time
+	ID        uint64     `sql:"id"`
+	HabitID   uint64     `sql:"habit_id"`
+	Subject   string     `sql:"subject"`
+	StartAt   time.Time  `sql:"start_at"`
+	EndAt     time.Time  `sql:"end_at"`
+	CreatedAt time.Time  `sql:"created_at"`
+	UpdatedAt time.Time  `sql:"updated_at"`
+	DeletedAt *time.Time `sql:"deleted_at"`
+	Version   uint64     `sql:"version"`
+}
+
+// EventFilter matches events overlapping the [From, To) window.
+type EventFilter struct {
+	HabitID        uint64
+	From           time.Time
+	To             time.Time
+	IncludeDeleted bool
+	Page
 }
 
 type Events []Event
 
 //go:generate mockery --name EventRepository --filename event_repository.go --outpkg mocks --structname EventRepository --disable-version-string
 type EventRepository interface {
+	// InsertEvents fills the ID, CreatedAt, UpdatedAt and Version generated for each element.
 	InsertEvents(ctx context.Context, events Events, now time.Time) error
+	// LoadEvents bulk loads the events received until events is closed, all or
+	// none of them, and returns how many were loaded.
+	LoadEvents(ctx context.Context, events <-chan Event, now time.Time) (int64, error)
+	// UpdateEvents checks and bumps the Version of the events like UpdateGoals.
 	UpdateEvents(ctx context.Context, events Events, now time.Time) error
+	GetEventByID(ctx context.Context, id uint64) (Event, error)
+	GetEventsByIDs(ctx context.Context, ids []uint64) (Events, error)
+	ListEvents(ctx context.Context, filter EventFilter) (Events, error)
+	DeleteEvents(ctx context.Context, ids []uint64, now time.Time) error
+	RestoreEvents(ctx context.Context, ids []uint64, now time.Time) error
+	PurgeEvents(ctx context.Context, ids []uint64) error
 }
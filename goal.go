@@ -2,18 +2,212 @@ package habit_tracker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"time"
 )
 
+type GoalTargetType string
+
+const (
+	GoalTargetCount      GoalTargetType = "count"
+	GoalTargetPercentage GoalTargetType = "percentage"
+)
+
+// Goal is reached when its linked habits have TargetValue successful records,
+// or TargetValue percent of their records successful, between WindowStart and
+// WindowEnd. Nil window bounds are open.
 type Goal struct {
-	ID          uint64
-	Description string
+	ID          uint64         `sql:"id"`
+	Description string         `sql:"description"`
+	TargetType  GoalTargetType `sql:"target_type"`
+	TargetValue float64        `sql:"target_value"`
+	WindowStart *time.Time     `sql:"window_start"`
+	WindowEnd   *time.Time     `sql:"window_end"`
+	Deadline    *time.Time     `sql:"deadline"`
+	CreatedAt   time.Time      `sql:"created_at"`
+	UpdatedAt   time.Time      `sql:"updated_at"`
+	DeletedAt   *time.Time     `sql:"deleted_at"`
+	Version     uint64         `sql:"version"`
+}
+
+type HabitGoal struct {
+	HabitID uint64 `sql:"habit_id"`
+	GoalID  uint64 `sql:"goal_id"`
+}
+
+type GoalFilter struct {
+	IncludeDeleted bool
+	Page
+}
+
+type GoalProgress struct {
+	GoalID     uint64
+	Successful uint64
+	Total      uint64
+	Value      float64
+	Achieved   bool
+	Overdue    bool
 }
 
 type Goals []Goal
+type HabitGoals []HabitGoal
+
+// Validate returns a validation error listing the goals with an unknown
+// TargetType or a TargetValue that isn't a positive number, or nil. An empty
+// TargetType stands for GoalTargetCount.
+func (g Goals) Validate() error {
+	var (
+		ids  []uint64
+		errs []error
+	)
+	for i, goal := range g {
+		var err error
+		switch {
+		case goal.TargetType != "" && goal.TargetType != GoalTargetCount && goal.TargetType != GoalTargetPercentage:
+			err = fmt.Errorf("unknown target type %q", goal.TargetType)
+		case !(goal.TargetValue > 0) || math.IsInf(goal.TargetValue, 0):
+			err = fmt.Errorf("target value %v isn't a positive number", goal.TargetValue)
+		default:
+			continue
+		}
+
+		if goal.ID != 0 {
+			ids = append(ids, goal.ID)
+		}
+
+		errs = append(errs, fmt.Errorf("[goal:%d][err:%w]", i, err))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &Error{
+		Kind:   ErrValidation,
+		Entity: "goals",
+		IDs:    ids,
+		Err:    errors.Join(errs...),
+	}
+}
 
 //go:generate mockery --name GoalRepository --filename goal_repository.go --outpkg mocks --structname GoalRepository --disable-version-string
 type GoalRepository interface {
+	// InsertGoals fills the ID, CreatedAt, UpdatedAt and Version generated for
+	// each element. Like UpdateGoals, it fails with a validation error if a
+	// target is invalid, see Goals.Validate.
 	InsertGoals(ctx context.Context, habits Goals, now time.Time) error
+	// UpdateGoals only applies if every goal is still at its Version, which is
+	// then bumped, and fails with a conflict error listing the stale ones
+	// otherwise, or else with a not found error listing the unknown and
+	// deleted ones.
 	UpdateGoals(ctx context.Context, habits Goals, now time.Time) error
+	GetGoalByID(ctx context.Context, id uint64) (Goal, error)
+	GetGoalsByIDs(ctx context.Context, ids []uint64) (Goals, error)
+	ListGoals(ctx context.Context, filter GoalFilter) (Goals, error)
+	DeleteGoals(ctx context.Context, ids []uint64, now time.Time) error
+	RestoreGoals(ctx context.Context, ids []uint64, now time.Time) error
+	// PurgeGoals removes the goals for good, unlinking them from their habits.
+	PurgeGoals(ctx context.Context, ids []uint64) error
+	// LinkHabits links habits to goals, ignoring links that already exist.
+	LinkHabits(ctx context.Context, habitGoals HabitGoals) error
+	UnlinkHabits(ctx context.Context, habitGoals HabitGoals) error
+	// ListHabitIDsByGoalIDs returns the IDs of the habits linked to each goal,
+	// keyed by goal ID.
+	ListHabitIDsByGoalIDs(ctx context.Context, goalIDs []uint64) (map[uint64][]uint64, error)
+}
+
+// Progress computes how far the goal is from its target given the records of
+// its linked habits. Records outside the goal window are ignored, and so are
+// skipped ones, which don't lower a percentage.
+func (g Goal) Progress(records HabitRecords, now time.Time) GoalProgress {
+	progress := GoalProgress{
+		GoalID: g.ID,
+	}
+
+	for _, record := range records {
+		if g.WindowStart != nil && record.RecordDate.Before(*g.WindowStart) {
+			continue
+		}
+
+		if g.WindowEnd != nil && !record.RecordDate.Before(*g.WindowEnd) {
+			continue
+		}
+
+		if record.Result == ResultSkipped {
+			continue
+		}
+
+		progress.Total++
+		if record.Succeeded() {
+			progress.Successful++
+		}
+	}
+
+	switch g.TargetType {
+	case GoalTargetPercentage:
+		if progress.Total > 0 {
+			progress.Value = float64(progress.Successful) / float64(progress.Total) * 100
+		}
+	default:
+		progress.Value = float64(progress.Successful)
+	}
+
+	progress.Achieved = progress.Value >= g.TargetValue
+	progress.Overdue = !progress.Achieved && g.Deadline != nil && now.After(*g.Deadline)
+
+	return progress
+}
+
+// recordFilter selects the records of the habit within the goal window. The
+// bounds are in UTC so that equal windows give equal filters.
+func (g Goal) recordFilter(habitID uint64) HabitRecordFilter {
+	filter := HabitRecordFilter{HabitID: habitID}
+	if g.WindowStart != nil {
+		filter.From = g.WindowStart.UTC()
+	}
+
+	if g.WindowEnd != nil {
+		filter.To = g.WindowEnd.UTC()
+	}
+
+	return filter
+}
+
+// GoalsProgress computes the progress of the goals with the given IDs from the
+// records of the habits linked to each of them, only listing the records
+// within the window of each goal.
+func GoalsProgress(ctx context.Context, goalRepository GoalRepository, habitRepository HabitRepository,
+	goalIDs []uint64, now time.Time) (map[uint64]GoalProgress, error) {
+	goals, err := goalRepository.GetGoalsByIDs(ctx, goalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	habitIDs, err := goalRepository.ListHabitIDsByGoalIDs(ctx, goalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[HabitRecordFilter]HabitRecords)
+	progress := make(map[uint64]GoalProgress, len(goals))
+	for _, goal := range goals {
+		var goalRecords HabitRecords
+		for _, habitID := range habitIDs[goal.ID] {
+			filter := goal.recordFilter(habitID)
+			if _, ok := records[filter]; !ok {
+				records[filter], err = habitRepository.ListHabitRecords(ctx, filter)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			goalRecords = append(goalRecords, records[filter]...)
+		}
+
+		progress[goal.ID] = goal.Progress(goalRecords, now)
+	}
+
+	return progress, nil
 }
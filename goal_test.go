@@ -0,0 +1,217 @@
+package habit_tracker_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+	"habit-tracker/mocks"
+)
+
+func TestGoal_Progress(t *testing.T) {
+	windowStart := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	windowEnd := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+	deadline := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+	records := habit_tracker.HabitRecords{
+		{RecordDate: time.Date(2023, 6, 30, 12, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone},
+		{RecordDate: time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone},
+		{RecordDate: time.Date(2023, 7, 2, 12, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone},
+		{RecordDate: time.Date(2023, 7, 3, 12, 0, 0, 0, time.UTC), Result: habit_tracker.ResultMissed},
+		{RecordDate: time.Date(2023, 7, 4, 12, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone},
+		{RecordDate: time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone},
+	}
+
+	type args struct {
+		goal    habit_tracker.Goal
+		records habit_tracker.HabitRecords
+		now     time.Time
+	}
+	tests := []struct {
+		name string
+		args args
+		want habit_tracker.GoalProgress
+	}{
+		{
+			name: "CountAchieved",
+			args: args{
+				goal: habit_tracker.Goal{
+					ID:          1,
+					TargetType:  habit_tracker.GoalTargetCount,
+					TargetValue: 3,
+					WindowStart: &windowStart,
+					WindowEnd:   &windowEnd,
+				},
+				records: records,
+				now:     time.Date(2023, 7, 5, 0, 0, 0, 0, time.UTC),
+			},
+			want: habit_tracker.GoalProgress{
+				GoalID:     1,
+				Successful: 3,
+				Total:      4,
+				Value:      3,
+				Achieved:   true,
+			},
+		},
+		{
+			name: "PercentageOverdue",
+			args: args{
+				goal: habit_tracker.Goal{
+					ID:          2,
+					TargetType:  habit_tracker.GoalTargetPercentage,
+					TargetValue: 80,
+					WindowStart: &windowStart,
+					WindowEnd:   &windowEnd,
+					Deadline:    &deadline,
+				},
+				records: records,
+				now:     time.Date(2023, 8, 2, 0, 0, 0, 0, time.UTC),
+			},
+			want: habit_tracker.GoalProgress{
+				GoalID:     2,
+				Successful: 3,
+				Total:      4,
+				Value:      75,
+				Overdue:    true,
+			},
+		},
+		{
+			name: "PercentageSkipped",
+			args: args{
+				goal: habit_tracker.Goal{
+					ID:          4,
+					TargetType:  habit_tracker.GoalTargetPercentage,
+					TargetValue: 50,
+				},
+				records: habit_tracker.HabitRecords{
+					{RecordDate: time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone},
+					{RecordDate: time.Date(2023, 7, 2, 12, 0, 0, 0, time.UTC), Result: habit_tracker.ResultSkipped},
+					{RecordDate: time.Date(2023, 7, 3, 12, 0, 0, 0, time.UTC), Result: habit_tracker.ResultSkipped},
+					{RecordDate: time.Date(2023, 7, 4, 12, 0, 0, 0, time.UTC), Result: habit_tracker.ResultMissed},
+				},
+				now: time.Date(2023, 7, 5, 0, 0, 0, 0, time.UTC),
+			},
+			want: habit_tracker.GoalProgress{
+				GoalID:     4,
+				Successful: 1,
+				Total:      2,
+				Value:      50,
+				Achieved:   true,
+			},
+		},
+		{
+			name: "OpenWindow",
+			args: args{
+				goal: habit_tracker.Goal{
+					ID:          3,
+					TargetValue: 10,
+				},
+				records: records,
+				now:     time.Date(2023, 8, 2, 0, 0, 0, 0, time.UTC),
+			},
+			want: habit_tracker.GoalProgress{
+				GoalID:     3,
+				Successful: 5,
+				Total:      6,
+				Value:      5,
+			},
+		},
+		{
+			name: "PercentageWithoutRecords",
+			args: args{
+				goal: habit_tracker.Goal{
+					ID:          4,
+					TargetType:  habit_tracker.GoalTargetPercentage,
+					TargetValue: 50,
+				},
+				now: time.Date(2023, 8, 2, 0, 0, 0, 0, time.UTC),
+			},
+			want: habit_tracker.GoalProgress{
+				GoalID: 4,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				assert.Equal(t, tt.want, tt.args.goal.Progress(tt.args.records, tt.args.now))
+			},
+		)
+	}
+}
+
+func TestGoals_Validate(t *testing.T) {
+	goals := habit_tracker.Goals{
+		{ID: 1, TargetValue: 3},
+		{ID: 2, TargetType: habit_tracker.GoalTargetPercentage, TargetValue: 80},
+		{ID: 3, TargetType: "foo", TargetValue: 3},
+		{ID: 4},
+		{ID: 5, TargetValue: -1},
+		{ID: 6, TargetValue: math.NaN()},
+		{ID: 7, TargetValue: math.Inf(1)},
+	}
+
+	err := goals.Validate()
+
+	var classified *habit_tracker.Error
+	assert.ErrorIs(t, err, habit_tracker.ErrValidation)
+	assert.ErrorAs(t, err, &classified)
+	assert.Equal(t, "goals", classified.Entity)
+	assert.Equal(t, []uint64{3, 4, 5, 6, 7}, classified.IDs)
+	assert.NoError(t, goals[:2].Validate())
+}
+
+func TestGoalsProgress(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 7, 5, 0, 0, 0, 0, time.UTC)
+	windowStart := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	windowEnd := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	goalRepository := mocks.NewGoalRepository(t)
+	goalRepository.On("GetGoalsByIDs", ctx, []uint64{1, 2, 3}).Return(
+		habit_tracker.Goals{
+			{ID: 1, TargetValue: 2, WindowStart: &windowStart, WindowEnd: &windowEnd},
+			{ID: 2, TargetValue: 1, WindowStart: &windowStart, WindowEnd: &windowEnd},
+			{ID: 3, TargetValue: 1, WindowStart: &windowStart},
+		}, nil,
+	)
+	goalRepository.On("ListHabitIDsByGoalIDs", ctx, []uint64{1, 2, 3}).Return(
+		map[uint64][]uint64{1: {10, 11}, 2: {11}, 3: {11}}, nil,
+	)
+
+	habitRepository := mocks.NewHabitRepository(t)
+	habitRepository.On(
+		"ListHabitRecords", ctx, habit_tracker.HabitRecordFilter{HabitID: 10, From: windowStart, To: windowEnd},
+	).Return(
+		habit_tracker.HabitRecords{{HabitID: 10, RecordDate: now, Result: habit_tracker.ResultDone}}, nil,
+	).Once()
+	habitRepository.On(
+		"ListHabitRecords", ctx, habit_tracker.HabitRecordFilter{HabitID: 11, From: windowStart, To: windowEnd},
+	).Return(
+		habit_tracker.HabitRecords{{HabitID: 11, RecordDate: now, Result: habit_tracker.ResultMissed}}, nil,
+	).Once()
+	habitRepository.On("ListHabitRecords", ctx, habit_tracker.HabitRecordFilter{HabitID: 11, From: windowStart}).Return(
+		habit_tracker.HabitRecords{
+			{HabitID: 11, RecordDate: now, Result: habit_tracker.ResultMissed},
+			{HabitID: 11, RecordDate: windowEnd, Result: habit_tracker.ResultDone},
+		}, nil,
+	).Once()
+
+	got, err := habit_tracker.GoalsProgress(ctx, goalRepository, habitRepository, []uint64{1, 2, 3}, now)
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		map[uint64]habit_tracker.GoalProgress{
+			1: {GoalID: 1, Successful: 1, Total: 2, Value: 1},
+			2: {GoalID: 2, Total: 1},
+			3: {GoalID: 3, Successful: 1, Total: 2, Value: 1, Achieved: true},
+		},
+		got,
+	)
+	habitRepository.AssertNumberOfCalls(t, "ListHabitRecords", 3)
+}
@@ -2,39 +2,276 @@ package habit_tracker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
+// Habit is measurable when it has a TargetComparison: the Values of its
+// records are then summed over each TargetPeriod and compared to TargetValue,
+// e.g. at least 30 pages a day. Pauses, like Tags, aren't read along with the
+// habit: see HabitRepository.ListHabitPauses.
 type Habit struct {
-	ID          uint64 `sql:"id"`
-	CategoryID  uint64 `sql:"category_id"`
-	Name        string `sql:"name"`
-	Description string `sql:"description"`
+	ID               uint64           `sql:"id"`
+	CategoryID       uint64           `sql:"category_id"`
+	Name             string           `sql:"name"`
+	Description      string           `sql:"description"`
+	Schedule         Schedule         `sql:"schedule"`
+	TargetValue      float64          `sql:"target_value"`
+	TargetUnit       string           `sql:"target_unit"`
+	TargetComparison TargetComparison `sql:"target_comparison"`
+	TargetPeriod     TargetPeriod     `sql:"target_period"`
+	Status           HabitStatus      `sql:"status"`
+	CreatedAt        time.Time        `sql:"created_at"`
+	UpdatedAt        time.Time        `sql:"updated_at"`
+	DeletedAt        *time.Time       `sql:"deleted_at"`
+	Version          uint64           `sql:"version"`
+	Tags             Tags
+	Pauses           HabitPauses
 }
 
 type HabitCategory struct {
-	ID           uint64 `sql:"id"`
-	CategoryName string `sql:"category_name"`
+	ID           uint64     `sql:"id"`
+	CategoryName string     `sql:"category_name"`
+	CreatedAt    time.Time  `sql:"created_at"`
+	UpdatedAt    time.Time  `sql:"updated_at"`
+	DeletedAt    *time.Time `sql:"deleted_at"`
+	Version      uint64     `sql:"version"`
 }
 
+// HabitRecord is the result of a habit on a day. Value optionally quantifies
+// it in Unit, e.g. 5.2 km; a Unit requires a Value.
 type HabitRecord struct {
-	ID          uint64    `sql:"id"`
-	HabitID     uint64    `sql:"habit_id"`
-	RecordDate  time.Time `sql:"record_date"`
-	Result      string    `sql:"result"`
-	Description string    `sql:"description"`
+	ID          uint64       `sql:"id"`
+	HabitID     uint64       `sql:"habit_id"`
+	RecordDate  time.Time    `sql:"record_date"`
+	Result      RecordResult `sql:"result"`
+	Value       *float64     `sql:"value"`
+	Unit        string       `sql:"unit"`
+	Description string       `sql:"description"`
+	CreatedAt   time.Time    `sql:"created_at"`
+	UpdatedAt   time.Time    `sql:"updated_at"`
+	DeletedAt   *time.Time   `sql:"deleted_at"`
+	Version     uint64       `sql:"version"`
+}
+
+type HabitFilter struct {
+	CategoryID     uint64
+	TagID          uint64
+	NamePrefix     string
+	IncludeDeleted bool
+	Page
+}
+
+type HabitCategoryFilter struct {
+	NamePrefix     string
+	IncludeDeleted bool
+	Page
+}
+
+// HabitRecordFilter matches records with From <= RecordDate < To.
+type HabitRecordFilter struct {
+	HabitID        uint64
+	From           time.Time
+	To             time.Time
+	IncludeDeleted bool
+	Page
+}
+
+// UpsertMode tells UpsertHabitRecords how to update the record already kept
+// for the same habit and day.
+type UpsertMode int
+
+const (
+	// UpsertReplace overwrites its RecordDate, Result, Value, Unit and
+	// Description.
+	UpsertReplace UpsertMode = iota
+	// UpsertMerge only overwrites its Result, Value, Unit and Description with
+	// the non-empty ones.
+	UpsertMerge
+)
+
+type RecordResult string
+
+const (
+	ResultDone    RecordResult = "done"
+	ResultSkipped RecordResult = "skipped"
+	ResultMissed  RecordResult = "missed"
+	ResultPartial RecordResult = "partial"
+)
+
+func (r RecordResult) Valid() bool {
+	switch r {
+	case ResultDone, ResultSkipped, ResultMissed, ResultPartial:
+		return true
+	default:
+		return false
+	}
 }
 
 type Habits []Habit
 type HabitCategories []HabitCategory
 type HabitRecords []HabitRecord
 
+func (r HabitRecord) Succeeded() bool {
+	return r.Result == ResultDone
+}
+
+// Validate returns a validation error listing the records with an invalid
+// Result, or a Unit without a Value.
+func (r HabitRecords) Validate() error {
+	return r.validate(0, false)
+}
+
+// ValidateMerge is Validate for UpsertMerge, which accepts an empty Result to
+// keep the one already recorded.
+func (r HabitRecords) ValidateMerge() error {
+	return r.validate(0, true)
+}
+
+// ValidateFrom is Validate for records that are part of a larger stream, the
+// first one at offset: the errors name each record by its index in the stream.
+func (r HabitRecords) ValidateFrom(offset int) error {
+	return r.validate(offset, false)
+}
+
+func (r HabitRecords) validate(offset int, emptyResult bool) error {
+	var (
+		ids  []uint64
+		errs []error
+	)
+	for i, record := range r {
+		var err error
+		switch {
+		case !record.Result.Valid() && (record.Result != "" || !emptyResult):
+			err = fmt.Errorf("unknown result %q", record.Result)
+		case record.Unit != "" && record.Value == nil:
+			err = fmt.Errorf("unit %q without value", record.Unit)
+		default:
+			continue
+		}
+
+		if record.ID != 0 {
+			ids = append(ids, record.ID)
+		}
+
+		errs = append(errs, fmt.Errorf("[habit_record:%d][err:%w]", offset+i, err))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &Error{
+		Kind:   ErrValidation,
+		Entity: "habit_records",
+		IDs:    ids,
+		Err:    errors.Join(errs...),
+	}
+}
+
+// WithTags sets the Tags of every habit from tags, keyed by habit ID, as
+// returned by TagRepository.ListTagsByHabitIDs.
+func (h Habits) WithTags(tags map[uint64]Tags) Habits {
+	habits := make(Habits, len(h))
+	for i, habit := range h {
+		habit.Tags = tags[habit.ID]
+		habits[i] = habit
+	}
+
+	return habits
+}
+
+// Validate returns a validation error listing the habits with an invalid
+// Schedule or target, or nil.
+func (h Habits) Validate() error {
+	var (
+		ids  []uint64
+		errs []error
+	)
+	for i, habit := range h {
+		err := habit.Schedule.Validate()
+		if err == nil {
+			err = habit.validateTarget()
+		}
+
+		if err != nil {
+			if habit.ID != 0 {
+				ids = append(ids, habit.ID)
+			}
+
+			errs = append(errs, fmt.Errorf("[habit:%d][err:%w]", i, err))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &Error{
+		Kind:   ErrValidation,
+		Entity: "habits",
+		IDs:    ids,
+		Err:    errors.Join(errs...),
+	}
+}
+
 //go:generate mockery --name HabitRepository --filename habit_repository.go --outpkg mocks --structname HabitRepository --disable-version-string
 type HabitRepository interface {
+	// InsertHabits fills the ID, CreatedAt, UpdatedAt and Version generated for
+	// each element. Like UpdateHabits, it fails with a validation error if a
+	// Schedule or target is invalid.
 	InsertHabits(ctx context.Context, habits Habits, now time.Time) error
 	InsertHabitCategories(ctx context.Context, habitCategories HabitCategories, now time.Time) error
 	InsertHabitRecords(ctx context.Context, habitRecords HabitRecords, now time.Time) error
-	UpdateHabits(ctx context.Context, habits Habits, now time.Time) error
-	UpdateHabitCategories(ctx context.Context, habitCategories HabitCategories, now time.Time) error
-	UpdateHabitRecords(ctx context.Context, habitRecords HabitRecords, now time.Time) error
+	// LoadHabitRecords bulk loads the records received until habitRecords is
+	// closed, all or none of them, and returns how many were loaded. Their IDs
+	// aren't reported, which suits backfills of many records. It fails with a
+	// validation error naming an invalid record by its index in the stream,
+	// see HabitRecords.ValidateFrom.
+	LoadHabitRecords(ctx context.Context, habitRecords <-chan HabitRecord, now time.Time) (int64, error)
+	// UpsertHabitRecords creates the records, or updates as set by mode the
+	// one their habit already has on the same calendar day. It fills the ID,
+	// CreatedAt, UpdatedAt and Version of each element, and returns whether
+	// each one was created. Two records of a batch can't share a habit and day.
+	UpsertHabitRecords(ctx context.Context, habitRecords HabitRecords, mode UpsertMode, now time.Time) ([]bool, error)
+	// UpdateHabits, UpdateHabitCategories and UpdateHabitRecords update all the
+	// elements or none: they fail with a conflict error listing the ones whose
+	// Version changed since they were read, or else with a not found error.
+	// They fill the Version each element was bumped to.
+	UpdateHabits(ctx context.Context, habits Habits, now time.Time) (int64, error)
+	UpdateHabitCategories(ctx context.Context, habitCategories HabitCategories, now time.Time) (int64, error)
+	UpdateHabitRecords(ctx context.Context, habitRecords HabitRecords, now time.Time) (int64, error)
+	GetHabitByID(ctx context.Context, id uint64) (Habit, error)
+	GetHabitsByIDs(ctx context.Context, ids []uint64) (Habits, error)
+	ListHabits(ctx context.Context, filter HabitFilter) (Habits, error)
+	GetHabitCategoryByID(ctx context.Context, id uint64) (HabitCategory, error)
+	GetHabitCategoriesByIDs(ctx context.Context, ids []uint64) (HabitCategories, error)
+	ListHabitCategories(ctx context.Context, filter HabitCategoryFilter) (HabitCategories, error)
+	GetHabitRecordByID(ctx context.Context, id uint64) (HabitRecord, error)
+	GetHabitRecordsByIDs(ctx context.Context, ids []uint64) (HabitRecords, error)
+	ListHabitRecords(ctx context.Context, filter HabitRecordFilter) (HabitRecords, error)
+	DeleteHabits(ctx context.Context, ids []uint64, now time.Time) error
+	DeleteHabitCategories(ctx context.Context, ids []uint64, now time.Time) error
+	DeleteHabitRecords(ctx context.Context, ids []uint64, now time.Time) error
+	RestoreHabits(ctx context.Context, ids []uint64, now time.Time) error
+	RestoreHabitCategories(ctx context.Context, ids []uint64, now time.Time) error
+	RestoreHabitRecords(ctx context.Context, ids []uint64, now time.Time) error
+	// PauseHabits pauses active habits from now, ResumeHabits makes paused or
+	// archived habits active again and ArchiveHabits retires active or paused
+	// habits, keeping them paused until resumed. They change all the habits or
+	// none: they fail with a conflict error listing the ones in another status,
+	// or else with a not found error. They bump the Version of the habits.
+	PauseHabits(ctx context.Context, ids []uint64, now time.Time) error
+	ResumeHabits(ctx context.Context, ids []uint64, now time.Time) error
+	ArchiveHabits(ctx context.Context, ids []uint64, now time.Time) error
+	// ListHabitPauses returns the pauses of each habit, from the oldest, keyed
+	// by habit ID.
+	ListHabitPauses(ctx context.Context, habitIDs []uint64) (map[uint64]HabitPauses, error)
+	// PurgeHabits removes the habits for good, along with their records, events,
+	// pauses and tag and goal links.
+	PurgeHabits(ctx context.Context, ids []uint64) error
+	// PurgeHabitCategories removes the categories for good, purging their habits.
+	PurgeHabitCategories(ctx context.Context, ids []uint64) error
+	PurgeHabitRecords(ctx context.Context, ids []uint64) error
 }
@@ -0,0 +1,108 @@
+package habit_tracker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHabits_WithTags(t *testing.T) {
+	habits := Habits{{ID: 1}, {ID: 2}}
+	tags := map[uint64]Tags{
+		1: {{ID: 5, Name: "Health"}},
+	}
+
+	got := habits.WithTags(tags)
+
+	assert.Equal(t, Habits{{ID: 1, Tags: Tags{{ID: 5, Name: "Health"}}}, {ID: 2}}, got)
+	assert.Nil(t, habits[0].Tags)
+}
+
+func TestHabitRecords_Validate(t *testing.T) {
+	value := 8.0
+
+	tests := []struct {
+		name    string
+		records HabitRecords
+		merge   bool
+		wantIDs []uint64
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "Success",
+			records: HabitRecords{
+				{Result: ResultDone, Value: &value, Unit: "glasses"},
+				{Result: ResultPartial, Value: &value},
+				{Result: ResultSkipped},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "ErrorUnknownResult",
+			records: HabitRecords{{ID: 4, Result: "Done"}},
+			wantIDs: []uint64{4},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrValidation, i...)
+			},
+		},
+		{
+			name:    "ErrorEmptyResult",
+			records: HabitRecords{{ID: 4}},
+			wantIDs: []uint64{4},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrValidation, i...)
+			},
+		},
+		{
+			name:    "MergeEmptyResult",
+			records: HabitRecords{{ID: 4, Value: &value}},
+			merge:   true,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "ErrorUnitWithoutValue",
+			records: HabitRecords{{Result: ResultDone}, {ID: 5, Result: ResultDone, Unit: "km"}},
+			wantIDs: []uint64{5},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrValidation, i...)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				validate := tt.records.Validate
+				if tt.merge {
+					validate = tt.records.ValidateMerge
+				}
+
+				err := validate()
+
+				tt.wantErr(t, err)
+
+				var validationErr *Error
+				if errors.As(err, &validationErr) {
+					assert.Equal(t, "habit_records", validationErr.Entity)
+					assert.Equal(t, tt.wantIDs, validationErr.IDs)
+				}
+			},
+		)
+	}
+}
+
+func TestHabitRecords_ValidateFrom(t *testing.T) {
+	records := HabitRecords{{Result: ResultDone}, {Result: ResultDone, Unit: "km"}}
+
+	assert.Contains(t, records.Validate().Error(), "[habit_record:1]")
+	assert.Contains(t, records.ValidateFrom(41).Error(), "[habit_record:42]")
+	assert.NoError(t, records[:1].ValidateFrom(41))
+}
+
+func TestHabitRecord_Succeeded(t *testing.T) {
+	assert.True(t, HabitRecord{Result: ResultDone}.Succeeded())
+	assert.False(t, HabitRecord{Result: ResultPartial}.Succeeded())
+	assert.False(t, HabitRecord{Result: ResultSkipped}.Succeeded())
+	assert.False(t, HabitRecord{Result: "Done"}.Succeeded())
+}
@@ -0,0 +1,22 @@
+package memory
+
+import (
+	"testing"
+
+	"habit-tracker/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(
+		t, func(t *testing.T) repotest.Repositories {
+			store := NewStore()
+
+			return repotest.Repositories{
+				Habits: NewHabitRepository(store),
+				Tags:   NewTagRepository(store),
+				Goals:  NewGoalRepository(store),
+				Events: NewEventRepository(store),
+			}
+		},
+	)
+}
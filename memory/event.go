@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"habit-tracker"
+)
+
+type EventRepository struct {
+	store *Store
+}
+
+func NewEventRepository(store *Store) habit_tracker.EventRepository {
+	return &EventRepository{
+		store: store,
+	}
+}
+
+func (er *EventRepository) InsertEvents(_ context.Context, events habit_tracker.Events, now time.Time) error {
+	er.store.mu.Lock()
+	defer er.store.mu.Unlock()
+
+	err := checkReferences(er.store.habits, eventHabitIDs(events))
+	if err != nil {
+		return err
+	}
+
+	er.store.events.insert(events, now)
+
+	return nil
+}
+
+func (er *EventRepository) LoadEvents(ctx context.Context, events <-chan habit_tracker.Event,
+	now time.Time) (int64, error) {
+	received, err := receiveAll(ctx, events)
+	if err != nil {
+		return 0, err
+	}
+
+	err = er.InsertEvents(ctx, received, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(received)), nil
+}
+
+func (er *EventRepository) UpdateEvents(_ context.Context, events habit_tracker.Events, now time.Time) error {
+	er.store.mu.Lock()
+	defer er.store.mu.Unlock()
+
+	err := checkReferences(er.store.habits, eventHabitIDs(events))
+	if err != nil {
+		return err
+	}
+
+	stale := er.store.events.stale(events)
+	if len(stale) > 0 {
+		return habit_tracker.NewConflictError(eventsTable, stale...)
+	}
+
+	missing := er.store.events.missingRows(events)
+	if len(missing) > 0 {
+		return habit_tracker.NewNotFoundError(eventsTable, missing...)
+	}
+
+	er.store.events.update(events, now)
+
+	return nil
+}
+
+func (er *EventRepository) GetEventByID(_ context.Context, id uint64) (habit_tracker.Event, error) {
+	er.store.mu.RLock()
+	defer er.store.mu.RUnlock()
+
+	return er.store.events.get(id)
+}
+
+func (er *EventRepository) GetEventsByIDs(_ context.Context, ids []uint64) (habit_tracker.Events, error) {
+	er.store.mu.RLock()
+	defer er.store.mu.RUnlock()
+
+	return er.store.events.getByIDs(ids), nil
+}
+
+func (er *EventRepository) ListEvents(_ context.Context, filter habit_tracker.EventFilter) (habit_tracker.Events, error) {
+	er.store.mu.RLock()
+	defer er.store.mu.RUnlock()
+
+	return er.store.events.list(
+		func(event habit_tracker.Event) bool {
+			if filter.HabitID > 0 && event.HabitID != filter.HabitID {
+				return false
+			}
+
+			if !filter.From.IsZero() && !event.EndAt.After(filter.From) {
+				return false
+			}
+
+			return filter.To.IsZero() || event.StartAt.Before(filter.To)
+		}, filter.IncludeDeleted, filter.Page,
+	), nil
+}
+
+func (er *EventRepository) DeleteEvents(_ context.Context, ids []uint64, now time.Time) error {
+	er.store.mu.Lock()
+	defer er.store.mu.Unlock()
+
+	return er.store.events.setDeleted(ids, true, now)
+}
+
+func (er *EventRepository) RestoreEvents(_ context.Context, ids []uint64, now time.Time) error {
+	er.store.mu.Lock()
+	defer er.store.mu.Unlock()
+
+	return er.store.events.setDeleted(ids, false, now)
+}
+
+func (er *EventRepository) PurgeEvents(_ context.Context, ids []uint64) error {
+	er.store.mu.Lock()
+	defer er.store.mu.Unlock()
+
+	err := er.store.events.purgeable(ids)
+	if err != nil {
+		return err
+	}
+
+	er.store.events.purge(ids)
+
+	return nil
+}
+
+func eventHabitIDs(events habit_tracker.Events) []uint64 {
+	ids := make([]uint64, len(events))
+	for i, event := range events {
+		ids[i] = event.HabitID
+	}
+
+	return ids
+}
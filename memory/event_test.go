@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func TestEventRepository_ListEvents(t *testing.T) {
+	ctx := context.Background()
+	er := NewEventRepository(seed(t))
+
+	type test struct {
+		name   string
+		filter habit_tracker.EventFilter
+		want   int
+	}
+
+	tests := []test{
+		{
+			name:   "Overlapping",
+			filter: habit_tracker.EventFilter{From: now.Add(30 * time.Minute), To: now.Add(2 * time.Hour)},
+			want:   1,
+		},
+		{
+			name:   "EndingAtFrom",
+			filter: habit_tracker.EventFilter{From: now.Add(time.Hour)},
+			want:   0,
+		},
+		{
+			name:   "StartingAtTo",
+			filter: habit_tracker.EventFilter{To: now},
+			want:   0,
+		},
+		{
+			name:   "ByHabit",
+			filter: habit_tracker.EventFilter{HabitID: 1},
+			want:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				got, err := er.ListEvents(ctx, tt.filter)
+
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.want)
+			},
+		)
+	}
+}
+
+func TestEventRepository_InsertEvents(t *testing.T) {
+	ctx := context.Background()
+	er := NewEventRepository(seed(t))
+
+	err := er.InsertEvents(ctx, habit_tracker.Events{{HabitID: 3, StartAt: now, EndAt: now}}, now)
+	assert.Equal(t, habit_tracker.NewForeignKeyError("habits", 3), err)
+
+	events := habit_tracker.Events{{HabitID: 1, Subject: "Swim", StartAt: now, EndAt: now}}
+	assert.NoError(t, er.InsertEvents(ctx, events, now))
+
+	got, err := er.GetEventsByIDs(ctx, []uint64{events[0].ID, 9})
+	assert.NoError(t, err)
+	assert.Equal(t, events, got)
+}
@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"habit-tracker"
+)
+
+type GoalRepository struct {
+	store *Store
+}
+
+func NewGoalRepository(store *Store) habit_tracker.GoalRepository {
+	return &GoalRepository{
+		store: store,
+	}
+}
+
+func (gr *GoalRepository) InsertGoals(_ context.Context, goals habit_tracker.Goals, now time.Time) error {
+	err := goals.Validate()
+	if err != nil {
+		return err
+	}
+
+	gr.store.mu.Lock()
+	defer gr.store.mu.Unlock()
+
+	gr.store.goals.insert(goals, now)
+
+	return nil
+}
+
+func (gr *GoalRepository) UpdateGoals(_ context.Context, goals habit_tracker.Goals, now time.Time) error {
+	err := goals.Validate()
+	if err != nil {
+		return err
+	}
+
+	gr.store.mu.Lock()
+	defer gr.store.mu.Unlock()
+
+	stale := gr.store.goals.stale(goals)
+	if len(stale) > 0 {
+		return habit_tracker.NewConflictError(goalsTable, stale...)
+	}
+
+	missing := gr.store.goals.missingRows(goals)
+	if len(missing) > 0 {
+		return habit_tracker.NewNotFoundError(goalsTable, missing...)
+	}
+
+	gr.store.goals.update(goals, now)
+
+	return nil
+}
+
+func (gr *GoalRepository) GetGoalByID(_ context.Context, id uint64) (habit_tracker.Goal, error) {
+	gr.store.mu.RLock()
+	defer gr.store.mu.RUnlock()
+
+	return gr.store.goals.get(id)
+}
+
+func (gr *GoalRepository) GetGoalsByIDs(_ context.Context, ids []uint64) (habit_tracker.Goals, error) {
+	gr.store.mu.RLock()
+	defer gr.store.mu.RUnlock()
+
+	return gr.store.goals.getByIDs(ids), nil
+}
+
+func (gr *GoalRepository) ListGoals(_ context.Context, filter habit_tracker.GoalFilter) (habit_tracker.Goals, error) {
+	gr.store.mu.RLock()
+	defer gr.store.mu.RUnlock()
+
+	return gr.store.goals.list(nil, filter.IncludeDeleted, filter.Page), nil
+}
+
+func (gr *GoalRepository) DeleteGoals(_ context.Context, ids []uint64, now time.Time) error {
+	gr.store.mu.Lock()
+	defer gr.store.mu.Unlock()
+
+	return gr.store.goals.setDeleted(ids, true, now)
+}
+
+func (gr *GoalRepository) RestoreGoals(_ context.Context, ids []uint64, now time.Time) error {
+	gr.store.mu.Lock()
+	defer gr.store.mu.Unlock()
+
+	return gr.store.goals.setDeleted(ids, false, now)
+}
+
+func (gr *GoalRepository) PurgeGoals(_ context.Context, ids []uint64) error {
+	gr.store.mu.Lock()
+	defer gr.store.mu.Unlock()
+
+	err := gr.store.goals.purgeable(ids)
+	if err != nil {
+		return err
+	}
+
+	goalIDs := idSet(ids)
+	for habitGoal := range gr.store.habitGoals {
+		if goalIDs[habitGoal.GoalID] {
+			delete(gr.store.habitGoals, habitGoal)
+		}
+	}
+
+	gr.store.goals.purge(ids)
+
+	return nil
+}
+
+func (gr *GoalRepository) LinkHabits(_ context.Context, habitGoals habit_tracker.HabitGoals) error {
+	gr.store.mu.Lock()
+	defer gr.store.mu.Unlock()
+
+	habitIDs := make([]uint64, len(habitGoals))
+	goalIDs := make([]uint64, len(habitGoals))
+	for i, habitGoal := range habitGoals {
+		habitIDs[i] = habitGoal.HabitID
+		goalIDs[i] = habitGoal.GoalID
+	}
+
+	err := checkReferences(gr.store.habits, habitIDs)
+	if err != nil {
+		return err
+	}
+
+	err = checkReferences(gr.store.goals, goalIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, habitGoal := range habitGoals {
+		gr.store.habitGoals[habitGoal] = true
+	}
+
+	return nil
+}
+
+func (gr *GoalRepository) UnlinkHabits(_ context.Context, habitGoals habit_tracker.HabitGoals) error {
+	gr.store.mu.Lock()
+	defer gr.store.mu.Unlock()
+
+	for _, habitGoal := range habitGoals {
+		delete(gr.store.habitGoals, habitGoal)
+	}
+
+	return nil
+}
+
+// ListHabitIDsByGoalIDs leaves deleted habits out, as the postgres one does.
+func (gr *GoalRepository) ListHabitIDsByGoalIDs(_ context.Context,
+	goalIDs []uint64) (map[uint64][]uint64, error) {
+	gr.store.mu.RLock()
+	defer gr.store.mu.RUnlock()
+
+	wanted := idSet(goalIDs)
+	habitIDs := make(map[uint64][]uint64)
+	for habitGoal := range gr.store.habitGoals {
+		if !wanted[habitGoal.GoalID] {
+			continue
+		}
+
+		habit, ok := gr.store.habits.rows[habitGoal.HabitID]
+		if !ok || habit.DeletedAt != nil {
+			continue
+		}
+
+		habitIDs[habitGoal.GoalID] = append(habitIDs[habitGoal.GoalID], habitGoal.HabitID)
+	}
+
+	for _, ids := range habitIDs {
+		sort.Slice(
+			ids, func(i, j int) bool {
+				return ids[i] < ids[j]
+			},
+		)
+	}
+
+	return habitIDs, nil
+}
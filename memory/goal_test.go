@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func TestGoalRepository_ListHabitIDsByGoalIDs(t *testing.T) {
+	ctx := context.Background()
+	store := seed(t)
+	gr := NewGoalRepository(store)
+	hr := NewHabitRepository(store)
+
+	assert.NoError(t, hr.InsertHabits(ctx, habit_tracker.Habits{{CategoryID: 1}, {CategoryID: 1}}, now))
+	assert.NoError(
+		t, gr.LinkHabits(
+			ctx, habit_tracker.HabitGoals{{HabitID: 3, GoalID: 1}, {HabitID: 2, GoalID: 1}, {HabitID: 1, GoalID: 1}},
+		),
+	)
+	assert.ErrorIs(t, gr.LinkHabits(ctx, habit_tracker.HabitGoals{{HabitID: 1, GoalID: 4}}), habit_tracker.ErrForeignKeyViolation)
+	assert.NoError(t, hr.DeleteHabits(ctx, []uint64{2}, now))
+
+	got, err := gr.ListHabitIDsByGoalIDs(ctx, []uint64{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint64][]uint64{1: {1, 3}}, got)
+
+	assert.NoError(t, gr.UnlinkHabits(ctx, habit_tracker.HabitGoals{{HabitID: 1, GoalID: 1}}))
+	got, err = gr.ListHabitIDsByGoalIDs(ctx, []uint64{1})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint64][]uint64{1: {3}}, got)
+}
+
+func TestGoalRepository_UpdateGoals(t *testing.T) {
+	ctx := context.Background()
+	gr := NewGoalRepository(seed(t))
+
+	deadline := now.AddDate(0, 1, 0)
+	goals := habit_tracker.Goals{
+		{ID: 1, Description: "Run daily", TargetValue: 20, Deadline: &deadline, Version: 1},
+		{ID: 6, Description: "Unknown", TargetValue: 1},
+	}
+	assert.Equal(t, habit_tracker.NewNotFoundError("goals", 6), gr.UpdateGoals(ctx, goals, now.Add(time.Hour)))
+
+	got, err := gr.GetGoalByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), got.Version)
+
+	assert.NoError(t, gr.UpdateGoals(ctx, goals[:1], now.Add(time.Hour)))
+
+	deadline = deadline.AddDate(1, 0, 0)
+
+	got, err = gr.GetGoalByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		habit_tracker.Goal{
+			ID:          1,
+			Description: "Run daily",
+			TargetType:  habit_tracker.GoalTargetCount,
+			TargetValue: 20,
+			Deadline:    timePtr(now.AddDate(0, 1, 0)),
+			CreatedAt:   now,
+			UpdatedAt:   now.Add(time.Hour),
+			Version:     2,
+		},
+		got,
+	)
+
+	progress, err := habit_tracker.GoalsProgress(ctx, gr, NewHabitRepository(NewStore()), []uint64{1}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, habit_tracker.GoalProgress{GoalID: 1}, progress[1])
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,695 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"habit-tracker"
+)
+
+type HabitRepository struct {
+	store *Store
+}
+
+func NewHabitRepository(store *Store) habit_tracker.HabitRepository {
+	return &HabitRepository{
+		store: store,
+	}
+}
+
+func (hr *HabitRepository) InsertHabits(_ context.Context, habits habit_tracker.Habits, now time.Time) error {
+	err := habits.Validate()
+	if err != nil {
+		return err
+	}
+
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	err = checkReferences(hr.store.habitCategories, habitCategoryIDs(habits))
+	if err != nil {
+		return err
+	}
+
+	// Habits are stored active whatever their Status, as in Postgres.
+	for i := range habits {
+		habits[i].Status = habit_tracker.HabitActive
+	}
+
+	hr.store.habits.insert(habits, now)
+
+	return nil
+}
+
+func (hr *HabitRepository) InsertHabitCategories(_ context.Context,
+	habitCategories habit_tracker.HabitCategories, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	hr.store.habitCategories.insert(habitCategories, now)
+
+	return nil
+}
+
+func (hr *HabitRepository) InsertHabitRecords(_ context.Context,
+	habitRecords habit_tracker.HabitRecords, now time.Time) error {
+	err := habitRecords.Validate()
+	if err != nil {
+		return err
+	}
+
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	err = checkReferences(hr.store.habits, recordHabitIDs(habitRecords))
+	if err != nil {
+		return err
+	}
+
+	err = hr.store.checkRecordDays(habitRecords, nil)
+	if err != nil {
+		return err
+	}
+
+	hr.store.habitRecords.insert(habitRecords, now)
+
+	return nil
+}
+
+func (hr *HabitRepository) LoadHabitRecords(ctx context.Context,
+	habitRecords <-chan habit_tracker.HabitRecord, now time.Time) (int64, error) {
+	records, err := receiveAll(ctx, habitRecords)
+	if err != nil {
+		return 0, err
+	}
+
+	err = hr.InsertHabitRecords(ctx, records, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(records)), nil
+}
+
+func (hr *HabitRepository) UpsertHabitRecords(_ context.Context, habitRecords habit_tracker.HabitRecords,
+	mode habit_tracker.UpsertMode, now time.Time) ([]bool, error) {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	if mode != habit_tracker.UpsertReplace && mode != habit_tracker.UpsertMerge {
+		return nil, &habit_tracker.Error{
+			Kind:   habit_tracker.ErrValidation,
+			Entity: habitRecordsTable,
+			Err:    fmt.Errorf("unknown upsert mode %d", mode),
+		}
+	}
+
+	validate := habitRecords.Validate
+	if mode == habit_tracker.UpsertMerge {
+		validate = habitRecords.ValidateMerge
+	}
+
+	err := validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkReferences(hr.store.habits, recordHabitIDs(habitRecords))
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[recordDay]bool, len(habitRecords))
+	for _, record := range habitRecords {
+		day := dayOf(record)
+		if days[day] {
+			return nil, &habit_tracker.Error{
+				Kind:   habit_tracker.ErrValidation,
+				Entity: habitRecordsTable,
+				Err:    fmt.Errorf("two records of habit %d on %s", day.habitID, day.date),
+			}
+		}
+		days[day] = true
+	}
+
+	live := hr.store.liveRecordDays(nil)
+	for _, record := range habitRecords {
+		if _, ok := live[dayOf(record)]; !ok && record.Result == "" {
+			return nil, &habit_tracker.Error{
+				Kind:   habit_tracker.ErrValidation,
+				Entity: habitRecordsTable,
+				Err:    fmt.Errorf("new record of habit %d on %s without result", record.HabitID, dayOf(record).date),
+			}
+		}
+	}
+
+	created := make([]bool, len(habitRecords))
+	for i := range habitRecords {
+		id, ok := live[dayOf(habitRecords[i])]
+		if !ok {
+			hr.store.habitRecords.insert(habitRecords[i:i+1], now)
+			created[i] = true
+
+			continue
+		}
+
+		stored := hr.store.habitRecords.rows[id]
+		replace := mode == habit_tracker.UpsertReplace
+		if replace {
+			stored.RecordDate = habitRecords[i].RecordDate
+		}
+		if replace || habitRecords[i].Result != "" {
+			stored.Result = habitRecords[i].Result
+		}
+		if replace || habitRecords[i].Value != nil {
+			stored.Value = habitRecords[i].Value
+		}
+		if replace || habitRecords[i].Unit != "" {
+			stored.Unit = habitRecords[i].Unit
+		}
+		if replace || habitRecords[i].Description != "" {
+			stored.Description = habitRecords[i].Description
+		}
+
+		hr.store.habitRecords.update([]habit_tracker.HabitRecord{stored}, now)
+		habitRecords[i], _ = hr.store.habitRecords.get(id)
+	}
+
+	return created, nil
+}
+
+func (hr *HabitRepository) UpdateHabits(_ context.Context, habits habit_tracker.Habits, now time.Time) (int64, error) {
+	err := habits.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	stale := hr.store.habits.stale(habits)
+	if len(stale) > 0 {
+		return 0, habit_tracker.NewConflictError(habitsTable, stale...)
+	}
+
+	missing := hr.store.habits.missing(habitIDs(habits), false)
+	if len(missing) > 0 {
+		return 0, habit_tracker.NewNotFoundError(habitsTable, missing...)
+	}
+
+	err = checkReferences(hr.store.habitCategories, habitCategoryIDs(habits))
+	if err != nil {
+		return 0, err
+	}
+
+	// The status is only changed by PauseHabits, ResumeHabits and ArchiveHabits.
+	rows := make(habit_tracker.Habits, len(habits))
+	for i, habit := range habits {
+		habit.Status = hr.store.habits.rows[habit.ID].Status
+		rows[i] = habit
+	}
+
+	updated := hr.store.habits.update(rows, now)
+	for i := range habits {
+		habits[i].Version = rows[i].Version
+	}
+
+	return int64(len(updated)), nil
+}
+
+func (hr *HabitRepository) UpdateHabitCategories(_ context.Context,
+	habitCategories habit_tracker.HabitCategories, now time.Time) (int64, error) {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	ids := make([]uint64, len(habitCategories))
+	for i, habitCategory := range habitCategories {
+		ids[i] = habitCategory.ID
+	}
+
+	stale := hr.store.habitCategories.stale(habitCategories)
+	if len(stale) > 0 {
+		return 0, habit_tracker.NewConflictError(habitCategoriesTable, stale...)
+	}
+
+	missing := hr.store.habitCategories.missing(ids, false)
+	if len(missing) > 0 {
+		return 0, habit_tracker.NewNotFoundError(habitCategoriesTable, missing...)
+	}
+
+	return int64(len(hr.store.habitCategories.update(habitCategories, now))), nil
+}
+
+func (hr *HabitRepository) UpdateHabitRecords(_ context.Context,
+	habitRecords habit_tracker.HabitRecords, now time.Time) (int64, error) {
+	err := habitRecords.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	ids := make([]uint64, len(habitRecords))
+	for i, habitRecord := range habitRecords {
+		ids[i] = habitRecord.ID
+	}
+
+	stale := hr.store.habitRecords.stale(habitRecords)
+	if len(stale) > 0 {
+		return 0, habit_tracker.NewConflictError(habitRecordsTable, stale...)
+	}
+
+	missing := hr.store.habitRecords.missing(ids, false)
+	if len(missing) > 0 {
+		return 0, habit_tracker.NewNotFoundError(habitRecordsTable, missing...)
+	}
+
+	err = checkReferences(hr.store.habits, recordHabitIDs(habitRecords))
+	if err != nil {
+		return 0, err
+	}
+
+	err = hr.store.checkRecordDays(habitRecords, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(hr.store.habitRecords.update(habitRecords, now))), nil
+}
+
+func (hr *HabitRepository) GetHabitByID(_ context.Context, id uint64) (habit_tracker.Habit, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	return hr.store.habits.get(id)
+}
+
+func (hr *HabitRepository) GetHabitsByIDs(_ context.Context, ids []uint64) (habit_tracker.Habits, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	return hr.store.habits.getByIDs(ids), nil
+}
+
+func (hr *HabitRepository) ListHabits(_ context.Context, filter habit_tracker.HabitFilter) (habit_tracker.Habits, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	return hr.store.habits.list(
+		func(habit habit_tracker.Habit) bool {
+			if filter.CategoryID > 0 && habit.CategoryID != filter.CategoryID {
+				return false
+			}
+
+			if filter.TagID > 0 && !hr.store.habitTags[habit_tracker.HabitTag{HabitID: habit.ID, TagID: filter.TagID}] {
+				return false
+			}
+
+			return strings.HasPrefix(habit.Name, filter.NamePrefix)
+		}, filter.IncludeDeleted, filter.Page,
+	), nil
+}
+
+func (hr *HabitRepository) GetHabitCategoryByID(_ context.Context, id uint64) (habit_tracker.HabitCategory, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	return hr.store.habitCategories.get(id)
+}
+
+func (hr *HabitRepository) GetHabitCategoriesByIDs(_ context.Context,
+	ids []uint64) (habit_tracker.HabitCategories, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	return hr.store.habitCategories.getByIDs(ids), nil
+}
+
+func (hr *HabitRepository) ListHabitCategories(_ context.Context,
+	filter habit_tracker.HabitCategoryFilter) (habit_tracker.HabitCategories, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	return hr.store.habitCategories.list(
+		func(habitCategory habit_tracker.HabitCategory) bool {
+			return strings.HasPrefix(habitCategory.CategoryName, filter.NamePrefix)
+		}, filter.IncludeDeleted, filter.Page,
+	), nil
+}
+
+func (hr *HabitRepository) GetHabitRecordByID(_ context.Context, id uint64) (habit_tracker.HabitRecord, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	return hr.store.habitRecords.get(id)
+}
+
+func (hr *HabitRepository) GetHabitRecordsByIDs(_ context.Context, ids []uint64) (habit_tracker.HabitRecords, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	return hr.store.habitRecords.getByIDs(ids), nil
+}
+
+func (hr *HabitRepository) ListHabitRecords(_ context.Context,
+	filter habit_tracker.HabitRecordFilter) (habit_tracker.HabitRecords, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	return hr.store.habitRecords.list(
+		func(habitRecord habit_tracker.HabitRecord) bool {
+			if filter.HabitID > 0 && habitRecord.HabitID != filter.HabitID {
+				return false
+			}
+
+			if !filter.From.IsZero() && habitRecord.RecordDate.Before(filter.From) {
+				return false
+			}
+
+			return filter.To.IsZero() || habitRecord.RecordDate.Before(filter.To)
+		}, filter.IncludeDeleted, filter.Page,
+	), nil
+}
+
+func (hr *HabitRepository) DeleteHabits(_ context.Context, ids []uint64, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	return hr.store.habits.setDeleted(ids, true, now)
+}
+
+func (hr *HabitRepository) DeleteHabitCategories(_ context.Context, ids []uint64, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	return hr.store.habitCategories.setDeleted(ids, true, now)
+}
+
+func (hr *HabitRepository) DeleteHabitRecords(_ context.Context, ids []uint64, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	return hr.store.habitRecords.setDeleted(ids, true, now)
+}
+
+func (hr *HabitRepository) RestoreHabits(_ context.Context, ids []uint64, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	return hr.store.habits.setDeleted(ids, false, now)
+}
+
+func (hr *HabitRepository) RestoreHabitCategories(_ context.Context, ids []uint64, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	return hr.store.habitCategories.setDeleted(ids, false, now)
+}
+
+func (hr *HabitRepository) RestoreHabitRecords(_ context.Context, ids []uint64, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	missing := hr.store.habitRecords.missing(ids, true)
+	if len(missing) > 0 {
+		return habit_tracker.NewNotFoundError(habitRecordsTable, missing...)
+	}
+
+	restored := make(habit_tracker.HabitRecords, len(ids))
+	for i, id := range ids {
+		restored[i] = hr.store.habitRecords.rows[id]
+	}
+
+	err := hr.store.checkRecordDays(restored, ids)
+	if err != nil {
+		return err
+	}
+
+	return hr.store.habitRecords.setDeleted(ids, false, now)
+}
+
+func (hr *HabitRepository) PauseHabits(_ context.Context, ids []uint64, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	return hr.store.setHabitStatus(ids, habit_tracker.HabitPaused, now, habit_tracker.HabitActive)
+}
+
+func (hr *HabitRepository) ResumeHabits(_ context.Context, ids []uint64, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	return hr.store.setHabitStatus(
+		ids, habit_tracker.HabitActive, now, habit_tracker.HabitPaused, habit_tracker.HabitArchived,
+	)
+}
+
+func (hr *HabitRepository) ArchiveHabits(_ context.Context, ids []uint64, now time.Time) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	return hr.store.setHabitStatus(
+		ids, habit_tracker.HabitArchived, now, habit_tracker.HabitActive, habit_tracker.HabitPaused,
+	)
+}
+
+func (hr *HabitRepository) ListHabitPauses(_ context.Context,
+	habitIDs []uint64) (map[uint64]habit_tracker.HabitPauses, error) {
+	hr.store.mu.RLock()
+	defer hr.store.mu.RUnlock()
+
+	pauses := make(map[uint64]habit_tracker.HabitPauses)
+	for id := range idSet(habitIDs) {
+		for _, pause := range hr.store.habitPauses[id] {
+			pause.ResumedAt = copyTime(pause.ResumedAt)
+			pauses[id] = append(pauses[id], pause)
+		}
+	}
+
+	return pauses, nil
+}
+
+func (hr *HabitRepository) PurgeHabits(_ context.Context, ids []uint64) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	err := hr.store.habits.purgeable(ids)
+	if err != nil {
+		return err
+	}
+
+	hr.store.purgeHabits(idSet(ids))
+
+	return nil
+}
+
+func (hr *HabitRepository) PurgeHabitCategories(_ context.Context, ids []uint64) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	err := hr.store.habitCategories.purgeable(ids)
+	if err != nil {
+		return err
+	}
+
+	categoryIDs := idSet(ids)
+	habitIDs := make(map[uint64]bool)
+	for id, habit := range hr.store.habits.rows {
+		if categoryIDs[habit.CategoryID] {
+			habitIDs[id] = true
+		}
+	}
+
+	hr.store.purgeHabits(habitIDs)
+	hr.store.habitCategories.purge(ids)
+
+	return nil
+}
+
+func (hr *HabitRepository) PurgeHabitRecords(_ context.Context, ids []uint64) error {
+	hr.store.mu.Lock()
+	defer hr.store.mu.Unlock()
+
+	err := hr.store.habitRecords.purgeable(ids)
+	if err != nil {
+		return err
+	}
+
+	hr.store.habitRecords.purge(ids)
+
+	return nil
+}
+
+// setHabitStatus moves the habits from one of the statuses from to status,
+// opening or closing their pause the way the postgres queries do. It changes
+// nothing and fails with a conflict error listing the live habits in another
+// status, or else with a not found error, when any of them can't be changed.
+func (s *Store) setHabitStatus(ids []uint64, status habit_tracker.HabitStatus, now time.Time,
+	from ...habit_tracker.HabitStatus) error {
+	// ids without duplicates, in order.
+	unique := missingIDs(ids, nil)
+
+	conflicting := make([]uint64, 0)
+	for _, id := range unique {
+		habit, ok := s.habits.rows[id]
+		if ok && !s.habits.deleted(habit) && !hasStatus(habit, from) {
+			conflicting = append(conflicting, id)
+		}
+	}
+
+	if len(conflicting) > 0 {
+		return habit_tracker.NewConflictError(habitsTable, conflicting...)
+	}
+
+	missing := s.habits.missing(ids, false)
+	if len(missing) > 0 {
+		return habit_tracker.NewNotFoundError(habitsTable, missing...)
+	}
+
+	for _, id := range unique {
+		habit := s.habits.rows[id]
+		habit.Status = status
+		habit.UpdatedAt = now
+		habit.Version++
+		s.habits.rows[id] = habit
+
+		pauses := s.habitPauses[id]
+		open := len(pauses) > 0 && pauses[len(pauses)-1].ResumedAt == nil
+		switch {
+		case status == habit_tracker.HabitActive && open:
+			resumedAt := now
+			pauses[len(pauses)-1].ResumedAt = &resumedAt
+		case status != habit_tracker.HabitActive && !open:
+			s.habitPauses[id] = append(pauses, habit_tracker.HabitPause{HabitID: id, PausedAt: now})
+		}
+	}
+
+	return nil
+}
+
+func hasStatus(habit habit_tracker.Habit, statuses []habit_tracker.HabitStatus) bool {
+	for _, status := range statuses {
+		if habit.Status == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// purgeHabits removes the habits along with their records, events, pauses
+// and tag and goal links.
+func (s *Store) purgeHabits(ids map[uint64]bool) {
+	s.habitRecords.purgeWhere(
+		func(habitRecord habit_tracker.HabitRecord) bool {
+			return ids[habitRecord.HabitID]
+		},
+	)
+	s.events.purgeWhere(
+		func(event habit_tracker.Event) bool {
+			return ids[event.HabitID]
+		},
+	)
+
+	for habitTag := range s.habitTags {
+		if ids[habitTag.HabitID] {
+			delete(s.habitTags, habitTag)
+		}
+	}
+
+	for habitGoal := range s.habitGoals {
+		if ids[habitGoal.HabitID] {
+			delete(s.habitGoals, habitGoal)
+		}
+	}
+
+	for id := range ids {
+		delete(s.habitPauses, id)
+	}
+
+	s.habits.purgeWhere(
+		func(habit habit_tracker.Habit) bool {
+			return ids[habit.ID]
+		},
+	)
+}
+
+func habitIDs(habits habit_tracker.Habits) []uint64 {
+	ids := make([]uint64, len(habits))
+	for i, habit := range habits {
+		ids[i] = habit.ID
+	}
+
+	return ids
+}
+
+func habitCategoryIDs(habits habit_tracker.Habits) []uint64 {
+	ids := make([]uint64, len(habits))
+	for i, habit := range habits {
+		ids[i] = habit.CategoryID
+	}
+
+	return ids
+}
+
+// recordDay identifies a live habit record, which is unique by habit and
+// calendar day in UTC as in the postgres schema.
+type recordDay struct {
+	habitID uint64
+	date    string
+}
+
+func dayOf(record habit_tracker.HabitRecord) recordDay {
+	return recordDay{
+		habitID: record.HabitID,
+		date:    record.RecordDate.UTC().Format(time.DateOnly),
+	}
+}
+
+// liveRecordDays returns the ID of the live record of each habit and day,
+// leaving out the records of replaced. s.mu must be held.
+func (s *Store) liveRecordDays(replaced map[uint64]bool) map[recordDay]uint64 {
+	live := make(map[recordDay]uint64, len(s.habitRecords.rows))
+	for id, record := range s.habitRecords.rows {
+		if record.DeletedAt == nil && !replaced[id] {
+			live[dayOf(record)] = id
+		}
+	}
+
+	return live
+}
+
+// checkRecordDays returns a conflict error when storing records as live rows,
+// in place of the rows of replaced, would give a habit two live records on
+// the same day. s.mu must be held.
+func (s *Store) checkRecordDays(records habit_tracker.HabitRecords, replaced []uint64) error {
+	live := s.liveRecordDays(idSet(replaced))
+	for _, record := range records {
+		day := dayOf(record)
+
+		id, ok := live[day]
+		if ok && (record.ID == 0 || id != record.ID) {
+			if id == 0 {
+				return habit_tracker.NewConflictError(habitRecordsTable)
+			}
+
+			return habit_tracker.NewConflictError(habitRecordsTable, id)
+		}
+
+		live[day] = record.ID
+	}
+
+	return nil
+}
+
+func recordHabitIDs(habitRecords habit_tracker.HabitRecords) []uint64 {
+	ids := make([]uint64, len(habitRecords))
+	for i, habitRecord := range habitRecords {
+		ids[i] = habitRecord.HabitID
+	}
+
+	return ids
+}
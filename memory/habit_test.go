@@ -0,0 +1,359 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+var now = time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+
+// seed stores a category, a habit with a record and an event, a tag attached
+// to the habit and a goal linked to it, all with ID 1.
+func seed(t *testing.T) *Store {
+	ctx := context.Background()
+	store := NewStore()
+	hr := NewHabitRepository(store)
+
+	assert.NoError(t, hr.InsertHabitCategories(ctx, habit_tracker.HabitCategories{{CategoryName: "Health"}}, now))
+	assert.NoError(t, hr.InsertHabits(ctx, habit_tracker.Habits{{CategoryID: 1, Name: "Exercise"}}, now))
+	assert.NoError(t, hr.InsertHabitRecords(ctx, habit_tracker.HabitRecords{{HabitID: 1, RecordDate: now, Result: "done"}}, now))
+	assert.NoError(
+		t, NewEventRepository(store).InsertEvents(
+			ctx, habit_tracker.Events{{HabitID: 1, Subject: "Run", StartAt: now, EndAt: now.Add(time.Hour)}}, now,
+		),
+	)
+	assert.NoError(t, NewTagRepository(store).InsertTags(ctx, habit_tracker.Tags{{Name: "morning"}}, now))
+	assert.NoError(t, NewTagRepository(store).AttachTags(ctx, habit_tracker.HabitTags{{HabitID: 1, TagID: 1}}))
+	assert.NoError(
+		t, NewGoalRepository(store).InsertGoals(ctx, habit_tracker.Goals{{Description: "Run more", TargetValue: 10}}, now),
+	)
+	assert.NoError(t, NewGoalRepository(store).LinkHabits(ctx, habit_tracker.HabitGoals{{HabitID: 1, GoalID: 1}}))
+
+	return store
+}
+
+func TestHabitRepository_InsertHabits(t *testing.T) {
+	type args struct {
+		habits habit_tracker.Habits
+	}
+	type test struct {
+		name    string
+		args    args
+		want    habit_tracker.Habits
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	tests := []test{
+		{
+			name: "Success",
+			args: args{
+				habits: habit_tracker.Habits{
+					{CategoryID: 1, Name: "Read", Tags: habit_tracker.Tags{{ID: 1}}},
+					{CategoryID: 1, Name: "Meditate", Status: habit_tracker.HabitArchived},
+				},
+			},
+			want: habit_tracker.Habits{
+				{
+					ID:         2,
+					CategoryID: 1,
+					Name:       "Read",
+					Status:     habit_tracker.HabitActive,
+					CreatedAt:  now,
+					UpdatedAt:  now,
+					Version:    1,
+					Tags:       habit_tracker.Tags{{ID: 1}},
+				},
+				{
+					ID:         3,
+					CategoryID: 1,
+					Name:       "Meditate",
+					Status:     habit_tracker.HabitActive,
+					CreatedAt:  now,
+					UpdatedAt:  now,
+					Version:    1,
+				},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "ErrorForeignKey",
+			args: args{
+				habits: habit_tracker.Habits{
+					{CategoryID: 1, Name: "Read"},
+					{CategoryID: 9, Name: "Meditate"},
+				},
+			},
+			want: habit_tracker.Habits{
+				{CategoryID: 1, Name: "Read"},
+				{CategoryID: 9, Name: "Meditate"},
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, habit_tracker.ErrForeignKeyViolation) &&
+					assert.Equal(t, habit_tracker.NewForeignKeyError("habit_categories", 9), err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				hr := NewHabitRepository(seed(t))
+
+				tt.wantErr(t, hr.InsertHabits(context.Background(), tt.args.habits, now))
+				assert.Equal(t, tt.want, tt.args.habits)
+
+				habits, err := hr.ListHabits(context.Background(), habit_tracker.HabitFilter{})
+				assert.NoError(t, err)
+				for _, habit := range habits {
+					assert.Nil(t, habit.Tags)
+				}
+			},
+		)
+	}
+}
+
+func TestHabitRepository_InsertHabits_Concurrent(t *testing.T) {
+	hr := NewHabitRepository(seed(t))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, hr.InsertHabits(context.Background(), habit_tracker.Habits{{CategoryID: 1}}, now))
+		}()
+	}
+	wg.Wait()
+
+	habits, err := hr.ListHabits(context.Background(), habit_tracker.HabitFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, habits, 11)
+	for i, habit := range habits {
+		assert.Equal(t, uint64(i+1), habit.ID)
+	}
+}
+
+func TestHabitRepository_CopiesRows(t *testing.T) {
+	ctx := context.Background()
+	hr := NewHabitRepository(seed(t))
+
+	habits := habit_tracker.Habits{
+		{
+			CategoryID: 1,
+			Schedule: habit_tracker.Schedule{
+				Kind:     habit_tracker.ScheduleWeekdays,
+				Weekdays: []time.Weekday{time.Monday},
+			},
+		},
+	}
+	value := 5.0
+	records := habit_tracker.HabitRecords{{HabitID: 1, RecordDate: now.AddDate(0, 0, 1), Result: "done", Value: &value}}
+	assert.NoError(t, hr.InsertHabits(ctx, habits, now))
+	assert.NoError(t, hr.InsertHabitRecords(ctx, records, now))
+
+	habits[0].Schedule.Weekdays[0] = time.Wednesday
+	value = 99
+
+	habit, err := hr.GetHabitByID(ctx, habits[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Monday}, habit.Schedule.Weekdays)
+
+	record, err := hr.GetHabitRecordByID(ctx, records[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, *record.Value)
+
+	habit.Schedule.Weekdays[0] = time.Friday
+	*record.Value = 7
+
+	habit, err = hr.GetHabitByID(ctx, habits[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Monday}, habit.Schedule.Weekdays)
+
+	record, err = hr.GetHabitRecordByID(ctx, records[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, *record.Value)
+}
+
+func TestHabitRepository_UpdateHabits(t *testing.T) {
+	type args struct {
+		habits habit_tracker.Habits
+	}
+	type test struct {
+		name    string
+		args    args
+		want    int64
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	later := now.Add(time.Hour)
+
+	tests := []test{
+		{
+			name: "Success",
+			args: args{
+				habits: habit_tracker.Habits{{ID: 1, CategoryID: 1, Name: "Run", Version: 1}},
+			},
+			want:    1,
+			wantErr: assert.NoError,
+		},
+		{
+			name: "ErrorNotFound",
+			args: args{
+				habits: habit_tracker.Habits{{ID: 1, CategoryID: 1, Name: "Run", Version: 1}, {ID: 7, CategoryID: 1}},
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(t, habit_tracker.NewNotFoundError("habits", 7), err)
+			},
+		},
+		{
+			name: "ErrorForeignKey",
+			args: args{
+				habits: habit_tracker.Habits{{ID: 1, CategoryID: 4, Name: "Run", Version: 1}},
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, habit_tracker.ErrForeignKeyViolation)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				hr := NewHabitRepository(seed(t))
+
+				got, err := hr.UpdateHabits(context.Background(), tt.args.habits, later)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+
+				habit, err := hr.GetHabitByID(context.Background(), 1)
+				assert.NoError(t, err)
+				assert.Equal(t, now, habit.CreatedAt)
+				if tt.want > 0 {
+					assert.Equal(t, "Run", habit.Name)
+					assert.Equal(t, later, habit.UpdatedAt)
+				} else {
+					assert.Equal(t, "Exercise", habit.Name)
+					assert.Equal(t, now, habit.UpdatedAt)
+				}
+			},
+		)
+	}
+}
+
+func TestHabitRepository_ListHabitRecords(t *testing.T) {
+	ctx := context.Background()
+	hr := NewHabitRepository(seed(t))
+
+	assert.NoError(
+		t, hr.InsertHabitRecords(
+			ctx, habit_tracker.HabitRecords{
+				{HabitID: 1, RecordDate: now.AddDate(0, 0, 1), Result: "done"},
+				{HabitID: 1, RecordDate: now.AddDate(0, 0, 2), Result: "done"},
+			}, now,
+		),
+	)
+	assert.NoError(t, hr.DeleteHabitRecords(ctx, []uint64{2}, now))
+
+	type test struct {
+		name   string
+		filter habit_tracker.HabitRecordFilter
+		want   []uint64
+	}
+
+	tests := []test{
+		{
+			name:   "All",
+			filter: habit_tracker.HabitRecordFilter{HabitID: 1},
+			want:   []uint64{1, 3},
+		},
+		{
+			name:   "IncludeDeleted",
+			filter: habit_tracker.HabitRecordFilter{IncludeDeleted: true},
+			want:   []uint64{1, 2, 3},
+		},
+		{
+			name:   "DateRange",
+			filter: habit_tracker.HabitRecordFilter{From: now, To: now.AddDate(0, 0, 2), IncludeDeleted: true},
+			want:   []uint64{1, 2},
+		},
+		{
+			name:   "Page",
+			filter: habit_tracker.HabitRecordFilter{IncludeDeleted: true, Page: habit_tracker.Page{After: 1, Limit: 1}},
+			want:   []uint64{2},
+		},
+		{
+			name:   "OffsetPastEnd",
+			filter: habit_tracker.HabitRecordFilter{Page: habit_tracker.Page{Offset: 5}},
+			want:   []uint64{},
+		},
+		{
+			name:   "OtherHabit",
+			filter: habit_tracker.HabitRecordFilter{HabitID: 2},
+			want:   []uint64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				got, err := hr.ListHabitRecords(ctx, tt.filter)
+				assert.NoError(t, err)
+
+				ids := make([]uint64, len(got))
+				for i, habitRecord := range got {
+					ids[i] = habitRecord.ID
+				}
+				assert.Equal(t, tt.want, ids)
+			},
+		)
+	}
+}
+
+func TestHabitRepository_DeleteHabits(t *testing.T) {
+	ctx := context.Background()
+	hr := NewHabitRepository(seed(t))
+
+	assert.ErrorIs(t, hr.RestoreHabits(ctx, []uint64{1}, now), habit_tracker.ErrNotFound)
+	assert.Equal(t, habit_tracker.NewNotFoundError("habits", 2), hr.DeleteHabits(ctx, []uint64{1, 2}, now))
+
+	_, err := hr.GetHabitByID(ctx, 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, hr.DeleteHabits(ctx, []uint64{1}, now))
+	_, err = hr.GetHabitByID(ctx, 1)
+	assert.ErrorIs(t, err, habit_tracker.ErrNotFound)
+
+	assert.NoError(t, hr.RestoreHabits(ctx, []uint64{1}, now.Add(time.Hour)))
+	habit, err := hr.GetHabitByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, habit.DeletedAt)
+	assert.Equal(t, now.Add(time.Hour), habit.UpdatedAt)
+}
+
+func TestHabitRepository_PurgeHabitCategories(t *testing.T) {
+	ctx := context.Background()
+	store := seed(t)
+	hr := NewHabitRepository(store)
+
+	assert.ErrorIs(t, hr.PurgeHabitCategories(ctx, []uint64{1, 2}), habit_tracker.ErrNotFound)
+	assert.Len(t, store.habits.rows, 1)
+
+	assert.NoError(t, hr.DeleteHabitCategories(ctx, []uint64{1}, now))
+	assert.NoError(t, hr.PurgeHabitCategories(ctx, []uint64{1}))
+
+	assert.Empty(t, store.habitCategories.rows)
+	assert.Empty(t, store.habits.rows)
+	assert.Empty(t, store.habitRecords.rows)
+	assert.Empty(t, store.events.rows)
+	assert.Empty(t, store.habitTags)
+	assert.Empty(t, store.habitGoals)
+	assert.Len(t, store.tags.rows, 1)
+	assert.Len(t, store.goals.rows, 1)
+}
@@ -0,0 +1,427 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"habit-tracker"
+)
+
+// Tables, named as in the postgres schema so errors report the same entities.
+const (
+	eventsTable          = "events"
+	goalsTable           = "goals"
+	tagsTable            = "tags"
+	habitsTable          = "habits"
+	habitCategoriesTable = "habit_categories"
+	habitRecordsTable    = "habit_records"
+)
+
+// Store holds the rows of every table. The repositories built on the same
+// Store see each other's rows, so foreign keys are checked across them the way
+// the postgres schema does. It is safe for concurrent use.
+type Store struct {
+	mu sync.RWMutex
+
+	habitCategories *table[habit_tracker.HabitCategory]
+	habits          *table[habit_tracker.Habit]
+	habitRecords    *table[habit_tracker.HabitRecord]
+	tags            *table[habit_tracker.Tag]
+	goals           *table[habit_tracker.Goal]
+	events          *table[habit_tracker.Event]
+	habitTags       map[habit_tracker.HabitTag]bool
+	habitGoals      map[habit_tracker.HabitGoal]bool
+	habitPauses     map[uint64]habit_tracker.HabitPauses
+}
+
+func NewStore() *Store {
+	return &Store{
+		habitCategories: newTable(
+			habitCategoriesTable, func(c *habit_tracker.HabitCategory) columns {
+				return columns{&c.ID, &c.CreatedAt, &c.UpdatedAt, &c.DeletedAt, &c.Version}
+			}, nil,
+		),
+		habits: newTable(
+			habitsTable, func(h *habit_tracker.Habit) columns {
+				return columns{&h.ID, &h.CreatedAt, &h.UpdatedAt, &h.DeletedAt, &h.Version}
+			}, func(h *habit_tracker.Habit) {
+				h.Tags = nil
+				h.Pauses = nil
+				if h.Schedule.Weekdays != nil {
+					h.Schedule.Weekdays = append(make([]time.Weekday, 0, len(h.Schedule.Weekdays)), h.Schedule.Weekdays...)
+				}
+			},
+		),
+		habitRecords: newTable(
+			habitRecordsTable, func(r *habit_tracker.HabitRecord) columns {
+				return columns{&r.ID, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt, &r.Version}
+			}, func(r *habit_tracker.HabitRecord) {
+				if r.Value != nil {
+					value := *r.Value
+					r.Value = &value
+				}
+			},
+		),
+		tags: newTable(
+			tagsTable, func(t *habit_tracker.Tag) columns {
+				return columns{&t.ID, &t.CreatedAt, &t.UpdatedAt, &t.DeletedAt, &t.Version}
+			}, nil,
+		),
+		goals: newTable(
+			goalsTable, func(g *habit_tracker.Goal) columns {
+				return columns{&g.ID, &g.CreatedAt, &g.UpdatedAt, &g.DeletedAt, &g.Version}
+			}, func(g *habit_tracker.Goal) {
+				g.WindowStart = copyTime(g.WindowStart)
+				g.WindowEnd = copyTime(g.WindowEnd)
+				g.Deadline = copyTime(g.Deadline)
+				if g.TargetType == "" {
+					g.TargetType = habit_tracker.GoalTargetCount
+				}
+			},
+		),
+		events: newTable(
+			eventsTable, func(e *habit_tracker.Event) columns {
+				return columns{&e.ID, &e.CreatedAt, &e.UpdatedAt, &e.DeletedAt, &e.Version}
+			}, nil,
+		),
+		habitTags:   make(map[habit_tracker.HabitTag]bool),
+		habitGoals:  make(map[habit_tracker.HabitGoal]bool),
+		habitPauses: make(map[uint64]habit_tracker.HabitPauses),
+	}
+}
+
+// columns points to the fields of a row the table manages itself.
+type columns struct {
+	ID        *uint64
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+	DeletedAt **time.Time
+	Version   *uint64
+}
+
+// table stores copies of its rows keyed by ID, generating IDs the way a
+// SERIAL column does: increasing and never reused.
+type table[T any] struct {
+	name    string
+	rows    map[uint64]T
+	lastID  uint64
+	columns func(*T) columns
+	// normalize, when set, adjusts a copy of a row before it is stored.
+	normalize func(*T)
+}
+
+func newTable[T any](name string, columns func(*T) columns, normalize func(*T)) *table[T] {
+	return &table[T]{
+		name:      name,
+		rows:      make(map[uint64]T),
+		columns:   columns,
+		normalize: normalize,
+	}
+}
+
+// copy returns row without pointers shared with it.
+func (t *table[T]) copy(row T) T {
+	cols := t.columns(&row)
+	*cols.DeletedAt = copyTime(*cols.DeletedAt)
+	if t.normalize != nil {
+		t.normalize(&row)
+	}
+
+	return row
+}
+
+func (t *table[T]) deleted(row T) bool {
+	return *t.columns(&row).DeletedAt != nil
+}
+
+// exists reports whether the row is stored, even if soft deleted, which is
+// what a foreign key checks.
+func (t *table[T]) exists(id uint64) bool {
+	_, ok := t.rows[id]
+
+	return ok
+}
+
+// insert stores the rows, filling their ID, CreatedAt and UpdatedAt.
+func (t *table[T]) insert(rows []T, now time.Time) {
+	for i := range rows {
+		t.lastID++
+
+		cols := t.columns(&rows[i])
+		*cols.ID = t.lastID
+		*cols.CreatedAt = now
+		*cols.UpdatedAt = now
+		*cols.DeletedAt = nil
+		*cols.Version = 1
+
+		t.rows[t.lastID] = t.copy(rows[i])
+	}
+}
+
+func (t *table[T]) get(id uint64) (T, error) {
+	row, ok := t.rows[id]
+	if !ok || t.deleted(row) {
+		var zero T
+
+		return zero, habit_tracker.NewNotFoundError(t.name, id)
+	}
+
+	return t.copy(row), nil
+}
+
+func (t *table[T]) getByIDs(ids []uint64) []T {
+	wanted := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	return t.list(
+		func(row T) bool {
+			return wanted[*t.columns(&row).ID]
+		}, false, habit_tracker.Page{},
+	)
+}
+
+// list returns the rows matching match ordered by ID, paginated like the
+// postgres queries: After first, then Offset and Limit.
+func (t *table[T]) list(match func(T) bool, includeDeleted bool, page habit_tracker.Page) []T {
+	ids := make([]uint64, 0, len(t.rows))
+	for id, row := range t.rows {
+		if id <= page.After || (!includeDeleted && t.deleted(row)) || (match != nil && !match(row)) {
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	sort.Slice(
+		ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		},
+	)
+
+	if page.Offset >= uint64(len(ids)) {
+		ids = ids[:0]
+	} else {
+		ids = ids[page.Offset:]
+	}
+
+	if page.Limit > 0 && page.Limit < uint64(len(ids)) {
+		ids = ids[:page.Limit]
+	}
+
+	rows := make([]T, len(ids))
+	for i, id := range ids {
+		rows[i] = t.copy(t.rows[id])
+	}
+
+	return rows
+}
+
+// missing returns the ids of rows that don't exist or whose deleted state is
+// not deleted, without duplicates.
+func (t *table[T]) missing(ids []uint64, deleted bool) []uint64 {
+	found := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		row, ok := t.rows[id]
+		if ok && t.deleted(row) == deleted {
+			found = append(found, id)
+		}
+	}
+
+	return missingIDs(ids, found)
+}
+
+// missingRows returns the IDs of rows that don't exist or are deleted,
+// without duplicates.
+func (t *table[T]) missingRows(rows []T) []uint64 {
+	ids := make([]uint64, len(rows))
+	for i := range rows {
+		ids[i] = *t.columns(&rows[i]).ID
+	}
+
+	return t.missing(ids, false)
+}
+
+// update replaces the stored rows that aren't deleted and are still at the
+// version of rows, keeping the columns managed by the table, setting UpdatedAt
+// and bumping the version, which is filled in rows. It returns the ids
+// updated, without duplicates, and leaves the other rows out.
+func (t *table[T]) update(rows []T, now time.Time) []uint64 {
+	updated := make([]uint64, 0, len(rows))
+	for i := range rows {
+		id := *t.columns(&rows[i]).ID
+
+		stored, ok := t.rows[id]
+		if !ok || t.deleted(stored) || *t.columns(&stored).Version != *t.columns(&rows[i]).Version {
+			continue
+		}
+
+		storedCols := t.columns(&stored)
+		row := t.copy(rows[i])
+		cols := t.columns(&row)
+		*cols.CreatedAt = *storedCols.CreatedAt
+		*cols.UpdatedAt = now
+		*cols.DeletedAt = nil
+		*cols.Version = *storedCols.Version + 1
+
+		t.rows[id] = row
+		*t.columns(&rows[i]).Version = *cols.Version
+		updated = append(updated, id)
+	}
+
+	return updated
+}
+
+// stale returns the ids of the rows stored and not deleted at another version
+// than the one of rows, without duplicates.
+func (t *table[T]) stale(rows []T) []uint64 {
+	stale := make([]uint64, 0)
+	seen := make(map[uint64]bool, len(rows))
+	for i := range rows {
+		cols := t.columns(&rows[i])
+
+		stored, ok := t.rows[*cols.ID]
+		if !ok || t.deleted(stored) || seen[*cols.ID] || *t.columns(&stored).Version == *cols.Version {
+			continue
+		}
+
+		seen[*cols.ID] = true
+		stale = append(stale, *cols.ID)
+	}
+
+	return stale
+}
+
+// setDeleted soft deletes, or restores, the rows. It changes nothing and
+// returns a not found error when any of them doesn't exist or is already in
+// that state.
+func (t *table[T]) setDeleted(ids []uint64, deleted bool, now time.Time) error {
+	missing := t.missing(ids, !deleted)
+	if len(missing) > 0 {
+		return habit_tracker.NewNotFoundError(t.name, missing...)
+	}
+
+	for _, id := range ids {
+		row := t.rows[id]
+		cols := t.columns(&row)
+		*cols.UpdatedAt = now
+		*cols.DeletedAt = nil
+		*cols.Version++
+		if deleted {
+			deletedAt := now
+			*cols.DeletedAt = &deletedAt
+		}
+
+		t.rows[id] = row
+	}
+
+	return nil
+}
+
+// purgeable fails with a not found error when any of ids doesn't exist.
+func (t *table[T]) purgeable(ids []uint64) error {
+	found := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if t.exists(id) {
+			found = append(found, id)
+		}
+	}
+
+	missing := missingIDs(ids, found)
+	if len(missing) > 0 {
+		return habit_tracker.NewNotFoundError(t.name, missing...)
+	}
+
+	return nil
+}
+
+// purgeWhere removes every row matching match.
+func (t *table[T]) purgeWhere(match func(T) bool) {
+	for id, row := range t.rows {
+		if match(row) {
+			delete(t.rows, id)
+		}
+	}
+}
+
+func (t *table[T]) purge(ids []uint64) {
+	for _, id := range ids {
+		delete(t.rows, id)
+	}
+}
+
+// checkReferences fails with a foreign key error naming the ids of ref that
+// don't exist.
+func checkReferences[T any](ref *table[T], ids []uint64) error {
+	found := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if ref.exists(id) {
+			found = append(found, id)
+		}
+	}
+
+	missing := missingIDs(ids, found)
+	if len(missing) > 0 {
+		return habit_tracker.NewForeignKeyError(ref.name, missing...)
+	}
+
+	return nil
+}
+
+// missingIDs returns the ids of want that are not in got, without duplicates.
+func missingIDs(want, got []uint64) []uint64 {
+	found := make(map[uint64]bool, len(got))
+	for _, id := range got {
+		found[id] = true
+	}
+
+	missing := make([]uint64, 0)
+	for _, id := range want {
+		if !found[id] {
+			missing = append(missing, id)
+			found[id] = true
+		}
+	}
+
+	return missing
+}
+
+func idSet(ids []uint64) map[uint64]bool {
+	set := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+
+	return set
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+
+	return &c
+}
+
+// receiveAll returns the values received from ch until it's closed, or the
+// error of ctx when it's done first.
+func receiveAll[T any](ctx context.Context, ch <-chan T) ([]T, error) {
+	values := make([]T, 0)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case value, ok := <-ch:
+			if !ok {
+				return values, nil
+			}
+
+			values = append(values, value)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"habit-tracker"
+)
+
+type TagRepository struct {
+	store *Store
+}
+
+func NewTagRepository(store *Store) habit_tracker.TagRepository {
+	return &TagRepository{
+		store: store,
+	}
+}
+
+func (tr *TagRepository) InsertTags(_ context.Context, tags habit_tracker.Tags, now time.Time) error {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	tr.store.tags.insert(tags, now)
+
+	return nil
+}
+
+func (tr *TagRepository) UpdateTags(_ context.Context, tags habit_tracker.Tags, now time.Time) error {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	stale := tr.store.tags.stale(tags)
+	if len(stale) > 0 {
+		return habit_tracker.NewConflictError(tagsTable, stale...)
+	}
+
+	missing := tr.store.tags.missingRows(tags)
+	if len(missing) > 0 {
+		return habit_tracker.NewNotFoundError(tagsTable, missing...)
+	}
+
+	tr.store.tags.update(tags, now)
+
+	return nil
+}
+
+func (tr *TagRepository) GetTagByID(_ context.Context, id uint64) (habit_tracker.Tag, error) {
+	tr.store.mu.RLock()
+	defer tr.store.mu.RUnlock()
+
+	return tr.store.tags.get(id)
+}
+
+func (tr *TagRepository) GetTagsByIDs(_ context.Context, ids []uint64) (habit_tracker.Tags, error) {
+	tr.store.mu.RLock()
+	defer tr.store.mu.RUnlock()
+
+	return tr.store.tags.getByIDs(ids), nil
+}
+
+func (tr *TagRepository) ListTags(_ context.Context, filter habit_tracker.TagFilter) (habit_tracker.Tags, error) {
+	tr.store.mu.RLock()
+	defer tr.store.mu.RUnlock()
+
+	return tr.store.tags.list(
+		func(tag habit_tracker.Tag) bool {
+			return strings.HasPrefix(tag.Name, filter.NamePrefix)
+		}, filter.IncludeDeleted, filter.Page,
+	), nil
+}
+
+func (tr *TagRepository) DeleteTags(_ context.Context, ids []uint64, now time.Time) error {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	return tr.store.tags.setDeleted(ids, true, now)
+}
+
+func (tr *TagRepository) RestoreTags(_ context.Context, ids []uint64, now time.Time) error {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	return tr.store.tags.setDeleted(ids, false, now)
+}
+
+func (tr *TagRepository) PurgeTags(_ context.Context, ids []uint64) error {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	err := tr.store.tags.purgeable(ids)
+	if err != nil {
+		return err
+	}
+
+	tagIDs := idSet(ids)
+	for habitTag := range tr.store.habitTags {
+		if tagIDs[habitTag.TagID] {
+			delete(tr.store.habitTags, habitTag)
+		}
+	}
+
+	tr.store.tags.purge(ids)
+
+	return nil
+}
+
+func (tr *TagRepository) AttachTags(_ context.Context, habitTags habit_tracker.HabitTags) error {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	habitIDs := make([]uint64, len(habitTags))
+	tagIDs := make([]uint64, len(habitTags))
+	for i, habitTag := range habitTags {
+		habitIDs[i] = habitTag.HabitID
+		tagIDs[i] = habitTag.TagID
+	}
+
+	err := tr.checkHabitTags(habitIDs, tagIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, habitTag := range habitTags {
+		tr.store.habitTags[habitTag] = true
+	}
+
+	return nil
+}
+
+func (tr *TagRepository) DetachTags(_ context.Context, habitTags habit_tracker.HabitTags) error {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	for _, habitTag := range habitTags {
+		delete(tr.store.habitTags, habitTag)
+	}
+
+	return nil
+}
+
+func (tr *TagRepository) ReplaceHabitTags(_ context.Context, habitID uint64, tagIDs []uint64) error {
+	tr.store.mu.Lock()
+	defer tr.store.mu.Unlock()
+
+	if len(tagIDs) > 0 {
+		err := tr.checkHabitTags([]uint64{habitID}, tagIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	for habitTag := range tr.store.habitTags {
+		if habitTag.HabitID == habitID {
+			delete(tr.store.habitTags, habitTag)
+		}
+	}
+
+	for _, tagID := range tagIDs {
+		tr.store.habitTags[habit_tracker.HabitTag{HabitID: habitID, TagID: tagID}] = true
+	}
+
+	return nil
+}
+
+func (tr *TagRepository) ListTagsByHabitIDs(_ context.Context,
+	habitIDs []uint64) (map[uint64]habit_tracker.Tags, error) {
+	tr.store.mu.RLock()
+	defer tr.store.mu.RUnlock()
+
+	wanted := idSet(habitIDs)
+	tags := make(map[uint64]habit_tracker.Tags)
+	for habitTag := range tr.store.habitTags {
+		if !wanted[habitTag.HabitID] {
+			continue
+		}
+
+		tag, ok := tr.store.tags.rows[habitTag.TagID]
+		if !ok || tag.DeletedAt != nil {
+			continue
+		}
+
+		tags[habitTag.HabitID] = append(tags[habitTag.HabitID], tr.store.tags.copy(tag))
+	}
+
+	for _, habitTags := range tags {
+		sort.Slice(
+			habitTags, func(i, j int) bool {
+				return habitTags[i].ID < habitTags[j].ID
+			},
+		)
+	}
+
+	return tags, nil
+}
+
+func (tr *TagRepository) checkHabitTags(habitIDs, tagIDs []uint64) error {
+	err := checkReferences(tr.store.habits, habitIDs)
+	if err != nil {
+		return err
+	}
+
+	return checkReferences(tr.store.tags, tagIDs)
+}
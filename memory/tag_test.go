@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func TestTagRepository_ReplaceHabitTags(t *testing.T) {
+	type args struct {
+		habitID uint64
+		tagIDs  []uint64
+	}
+	type test struct {
+		name    string
+		args    args
+		want    map[uint64]habit_tracker.Tags
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	tests := []test{
+		{
+			name: "Success",
+			args: args{
+				habitID: 1,
+				tagIDs:  []uint64{3, 2},
+			},
+			want: map[uint64]habit_tracker.Tags{
+				1: {{ID: 2, Name: "evening", CreatedAt: now, UpdatedAt: now, Version: 1}},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "SuccessEmpty",
+			args: args{
+				habitID: 1,
+			},
+			want:    map[uint64]habit_tracker.Tags{},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "ErrorForeignKey",
+			args: args{
+				habitID: 1,
+				tagIDs:  []uint64{2, 8},
+			},
+			want: map[uint64]habit_tracker.Tags{
+				1: {{ID: 1, Name: "morning", CreatedAt: now, UpdatedAt: now, Version: 1}},
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(t, habit_tracker.NewForeignKeyError("tags", 8), err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				ctx := context.Background()
+				tr := NewTagRepository(seed(t))
+				assert.NoError(t, tr.InsertTags(ctx, habit_tracker.Tags{{Name: "evening"}, {Name: "weekend"}}, now))
+				assert.NoError(t, tr.DeleteTags(ctx, []uint64{3}, now))
+
+				tt.wantErr(t, tr.ReplaceHabitTags(ctx, tt.args.habitID, tt.args.tagIDs))
+
+				got, err := tr.ListTagsByHabitIDs(ctx, []uint64{1})
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestTagRepository_AttachTags(t *testing.T) {
+	ctx := context.Background()
+	store := seed(t)
+	tr := NewTagRepository(store)
+
+	assert.NoError(t, tr.AttachTags(ctx, habit_tracker.HabitTags{{HabitID: 1, TagID: 1}, {HabitID: 1, TagID: 1}}))
+	assert.Len(t, store.habitTags, 1)
+
+	assert.ErrorIs(t, tr.AttachTags(ctx, habit_tracker.HabitTags{{HabitID: 5, TagID: 1}}), habit_tracker.ErrForeignKeyViolation)
+
+	habits, err := NewHabitRepository(store).ListHabits(ctx, habit_tracker.HabitFilter{TagID: 1})
+	assert.NoError(t, err)
+	assert.Len(t, habits, 1)
+
+	assert.NoError(t, tr.DetachTags(ctx, habit_tracker.HabitTags{{HabitID: 1, TagID: 1}}))
+	habits, err = NewHabitRepository(store).ListHabits(ctx, habit_tracker.HabitFilter{TagID: 1})
+	assert.NoError(t, err)
+	assert.Empty(t, habits)
+}
+
+func TestTagRepository_PurgeTags(t *testing.T) {
+	ctx := context.Background()
+	store := seed(t)
+	tr := NewTagRepository(store)
+
+	assert.NoError(t, tr.PurgeTags(ctx, []uint64{1}))
+	assert.Empty(t, store.tags.rows)
+	assert.Empty(t, store.habitTags)
+	assert.ErrorIs(t, tr.PurgeTags(ctx, []uint64{1}), habit_tracker.ErrNotFound)
+}
@@ -0,0 +1,365 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration was modified")
+	ErrMissingMigration = errors.New("applied migration is missing")
+)
+
+// lockID is the pg_advisory_lock key held while migrating, so concurrent
+// runners wait for each other instead of applying the same version twice.
+const lockID = 7268032110
+
+const (
+	createHistoryQuery = `
+CREATE TABLE IF NOT EXISTS schema_history
+(
+    version      BIGINT PRIMARY KEY,
+    description  VARCHAR(255) NOT NULL,
+    script       VARCHAR(255) NOT NULL,
+    checksum     VARCHAR(64)  NOT NULL,
+    installed_at TIMESTAMP    NOT NULL DEFAULT NOW()
+);`
+	historyExistsQuery = `SELECT to_regclass('schema_history') IS NOT NULL;`
+	selectHistoryQuery = `
+SELECT
+	version, description, script, checksum, installed_at
+FROM
+	schema_history
+ORDER BY
+	version;`
+	insertHistoryQuery = `
+INSERT
+	INTO
+	schema_history
+(version, description, script, checksum)
+VALUES ($1, $2, $3, $4);`
+	lockQuery   = `SELECT pg_advisory_lock($1);`
+	unlockQuery = `SELECT pg_advisory_unlock($1);`
+)
+
+var scriptName = regexp.MustCompile(`^V(\d+)__(.+)\.sql$`)
+
+type Migration struct {
+	Version     uint64
+	Description string
+	Script      string
+	Checksum    string
+	SQL         string
+}
+
+type State string
+
+const (
+	StatePending State = "pending"
+	StateApplied State = "applied"
+	// StateModified is an applied migration whose script changed since.
+	StateModified State = "modified"
+	// StateMissing is an applied migration whose script no longer exists.
+	StateMissing State = "missing"
+)
+
+type Status struct {
+	Version     uint64
+	Description string
+	Script      string
+	State       State
+	InstalledAt *time.Time
+}
+
+type applied struct {
+	Migration
+	InstalledAt time.Time
+}
+
+// Load reads the V<version>__<description>.sql scripts at the root of fsys,
+// ordered by version. Other files are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	scripts := make(map[uint64]string, len(entries))
+	for _, entry := range entries {
+		match := scriptName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("[script:%s][err:%w]", entry.Name(), err)
+		}
+
+		script, ok := scripts[version]
+		if ok {
+			return nil, fmt.Errorf("[version:%d][err:duplicated by %s and %s]", version, script, entry.Name())
+		}
+		scripts[version] = entry.Name()
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("[script:%s][err:%w]", entry.Name(), err)
+		}
+
+		checksum := sha256.Sum256(content)
+		migrations = append(
+			migrations, Migration{
+				Version:     version,
+				Description: strings.ReplaceAll(match[2], "_", " "),
+				Script:      entry.Name(),
+				Checksum:    hex.EncodeToString(checksum[:]),
+				SQL:         string(content),
+			},
+		)
+	}
+
+	sort.Slice(
+		migrations, func(i, j int) bool {
+			return migrations[i].Version < migrations[j].Version
+		},
+	)
+
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies the pending migrations in version order, each in its own
+// transaction, and returns the ones applied. It refuses to run when an
+// applied migration was modified or removed.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, lockQuery, lockID)
+	if err != nil {
+		return nil, fmt.Errorf("[lock][err:%w]", err)
+	}
+	defer conn.ExecContext(context.Background(), unlockQuery, lockID)
+
+	_, err = conn.ExecContext(ctx, createHistoryQuery)
+	if err != nil {
+		return nil, fmt.Errorf("[history][err:%w]", err)
+	}
+
+	history, err := selectHistory(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validate(m.status(history))
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+	for _, migration := range m.migrations {
+		_, ok := history[migration.Version]
+		if ok {
+			continue
+		}
+
+		err = apply(ctx, conn, migration)
+		if err != nil {
+			return done, fmt.Errorf("[version:%d][script:%s][err:%w]", migration.Version, migration.Script, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status reports every known migration, from the scripts and the schema
+// history, ordered by version.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var exists bool
+
+	err = conn.QueryRowContext(ctx, historyExistsQuery).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("[history][err:%w]", err)
+	}
+
+	history := make(map[uint64]applied)
+	if exists {
+		history, err = selectHistory(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m.status(history), nil
+}
+
+// Validate fails when an applied migration was modified or removed.
+func (m *Migrator) Validate(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	return validate(statuses)
+}
+
+func (m *Migrator) status(history map[uint64]applied) []Status {
+	statuses := make([]Status, 0, len(m.migrations)+len(history))
+	known := make(map[uint64]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+
+		status := Status{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Script:      migration.Script,
+			State:       StatePending,
+		}
+
+		installed, ok := history[migration.Version]
+		if ok {
+			status.State = StateApplied
+			status.InstalledAt = &installed.InstalledAt
+			if installed.Checksum != migration.Checksum {
+				status.State = StateModified
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	for version, installed := range history {
+		if known[version] {
+			continue
+		}
+
+		installedAt := installed.InstalledAt
+		statuses = append(
+			statuses, Status{
+				Version:     version,
+				Description: installed.Description,
+				Script:      installed.Script,
+				State:       StateMissing,
+				InstalledAt: &installedAt,
+			},
+		)
+	}
+
+	sort.Slice(
+		statuses, func(i, j int) bool {
+			return statuses[i].Version < statuses[j].Version
+		},
+	)
+
+	return statuses
+}
+
+func validate(statuses []Status) error {
+	errs := make([]error, 0)
+	for _, status := range statuses {
+		switch status.State {
+		case StateModified:
+			errs = append(errs, fmt.Errorf("[version:%d][script:%s][err:%w]", status.Version, status.Script, ErrChecksumMismatch))
+		case StateMissing:
+			errs = append(errs, fmt.Errorf("[version:%d][script:%s][err:%w]", status.Version, status.Script, ErrMissingMigration))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func selectHistory(ctx context.Context, conn *sql.Conn) (map[uint64]applied, error) {
+	rows, err := conn.QueryContext(ctx, selectHistoryQuery)
+	if err != nil {
+		return nil, fmt.Errorf("[history][err:%w]", err)
+	}
+	defer rows.Close()
+
+	history := make(map[uint64]applied)
+	for rows.Next() {
+		var installed applied
+
+		err = rows.Scan(
+			&installed.Version,
+			&installed.Description,
+			&installed.Script,
+			&installed.Checksum,
+			&installed.InstalledAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("[history][err:%w]", err)
+		}
+
+		history[installed.Version] = installed
+	}
+
+	return history, rows.Err()
+}
+
+func apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, migration.SQL)
+	if err == nil {
+		_, err = tx.ExecContext(
+			ctx,
+			insertHistoryQuery,
+			migration.Version,
+			migration.Description,
+			migration.Script,
+			migration.Checksum,
+		)
+	}
+	if err != nil {
+		rollbackError := tx.Rollback()
+		if rollbackError != nil {
+			return rollbackError
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,320 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker/migrations"
+)
+
+const (
+	v1 = "CREATE TABLE a (id SERIAL PRIMARY KEY);"
+	v2 = "CREATE TABLE b (id SERIAL PRIMARY KEY);"
+)
+
+var (
+	testFS = fstest.MapFS{
+		"V2__Create_b.sql": {Data: []byte(v2)},
+		"V1__Create_a.sql": {Data: []byte(v1)},
+		"README.md":        {Data: []byte("ignored")},
+	}
+	installedAt = time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+)
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func historyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"version", "description", "script", "checksum", "installed_at"})
+}
+
+func TestLoad(t *testing.T) {
+	type test struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	tests := []test{
+		{
+			name: "Success",
+			fsys: testFS,
+			want: []Migration{
+				{Version: 1, Description: "Create a", Script: "V1__Create_a.sql", Checksum: checksum(v1), SQL: v1},
+				{Version: 2, Description: "Create b", Script: "V2__Create_b.sql", Checksum: checksum(v2), SQL: v2},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "ErrorDuplicatedVersion",
+			fsys: fstest.MapFS{
+				"V1__Create_a.sql":  {Data: []byte(v1)},
+				"V01__Create_b.sql": {Data: []byte(v2)},
+			},
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				got, err := Load(tt.fsys)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	got, err := Load(migrations.FS)
+	assert.NoError(t, err)
+
+	for i, migration := range got {
+		assert.Equal(t, uint64(i+1), migration.Version)
+	}
+	assert.Equal(t, "Create tables", got[0].Description)
+}
+
+func TestMigrator_Up(t *testing.T) {
+	type test struct {
+		name    string
+		db      *sql.DB
+		mock    sqlmock.Sqlmock
+		want    []uint64
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(lockQuery)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createHistoryQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(selectHistoryQuery)).WillReturnRows(
+				historyRows().AddRow(1, "Create a", "V1__Create_a.sql", checksum(v1), installedAt),
+			)
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(v2)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(insertHistoryQuery)).
+				WithArgs(2, "Create b", "V2__Create_b.sql", checksum(v2)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			mock.ExpectExec(regexp.QuoteMeta(unlockQuery)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			return test{
+				name:    "SuccessAppliesPending",
+				db:      db,
+				mock:    mock,
+				want:    []uint64{2},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(lockQuery)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createHistoryQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(selectHistoryQuery)).WillReturnRows(historyRows())
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(v1)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(insertHistoryQuery)).
+				WithArgs(1, "Create a", "V1__Create_a.sql", checksum(v1)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(v2)).WillReturnError(assert.AnError)
+			mock.ExpectRollback()
+			mock.ExpectExec(regexp.QuoteMeta(unlockQuery)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			return test{
+				name:    "ErrorStopsAtFailedVersion",
+				db:      db,
+				mock:    mock,
+				want:    []uint64{1},
+				wantErr: assert.Error,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(lockQuery)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createHistoryQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(selectHistoryQuery)).WillReturnRows(
+				historyRows().AddRow(1, "Create a", "V1__Create_a.sql", checksum("edited"), installedAt),
+			)
+			mock.ExpectExec(regexp.QuoteMeta(unlockQuery)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			return test{
+				name: "ErrorChecksumMismatch",
+				db:   db,
+				mock: mock,
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, ErrChecksumMismatch)
+				},
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(lockQuery)).WithArgs(lockID).WillReturnError(assert.AnError)
+
+			return test{
+				name:    "ErrorLock",
+				db:      db,
+				mock:    mock,
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				m, err := New(tt.db, testFS)
+				assert.NoError(t, err)
+
+				got, err := m.Up(context.Background())
+
+				tt.wantErr(t, err)
+				versions := make([]uint64, 0)
+				for _, migration := range got {
+					versions = append(versions, migration.Version)
+				}
+				if tt.want != nil {
+					assert.Equal(t, tt.want, versions)
+				}
+				assert.NoError(t, tt.mock.ExpectationsWereMet())
+			},
+		)
+	}
+}
+
+func TestMigrator_Status(t *testing.T) {
+	type test struct {
+		name    string
+		db      *sql.DB
+		want    []Status
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(historyExistsQuery)).WillReturnRows(
+				sqlmock.NewRows([]string{"exists"}).AddRow(false),
+			)
+
+			return test{
+				name: "SuccessNoHistory",
+				db:   db,
+				want: []Status{
+					{Version: 1, Description: "Create a", Script: "V1__Create_a.sql", State: StatePending},
+					{Version: 2, Description: "Create b", Script: "V2__Create_b.sql", State: StatePending},
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(historyExistsQuery)).WillReturnRows(
+				sqlmock.NewRows([]string{"exists"}).AddRow(true),
+			)
+			mock.ExpectQuery(regexp.QuoteMeta(selectHistoryQuery)).WillReturnRows(
+				historyRows().
+					AddRow(0, "Baseline", "V0__Baseline.sql", checksum(""), installedAt).
+					AddRow(1, "Create a", "V1__Create_a.sql", checksum("edited"), installedAt),
+			)
+
+			return test{
+				name: "SuccessModifiedAndMissing",
+				db:   db,
+				want: []Status{
+					{
+						Version:     0,
+						Description: "Baseline",
+						Script:      "V0__Baseline.sql",
+						State:       StateMissing,
+						InstalledAt: &installedAt,
+					},
+					{
+						Version:     1,
+						Description: "Create a",
+						Script:      "V1__Create_a.sql",
+						State:       StateModified,
+						InstalledAt: &installedAt,
+					},
+					{Version: 2, Description: "Create b", Script: "V2__Create_b.sql", State: StatePending},
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(historyExistsQuery)).WillReturnError(assert.AnError)
+
+			return test{
+				name:    "Error",
+				db:      db,
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				m, err := New(tt.db, testFS)
+				assert.NoError(t, err)
+
+				got, err := m.Status(context.Background())
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestMigrator_Validate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(historyExistsQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"exists"}).AddRow(true),
+	)
+	mock.ExpectQuery(regexp.QuoteMeta(selectHistoryQuery)).WillReturnRows(
+		historyRows().AddRow(3, "Create c", "V3__Create_c.sql", checksum(""), installedAt),
+	)
+
+	m, err := New(db, testFS)
+	assert.NoError(t, err)
+
+	err = m.Validate(context.Background())
+	assert.ErrorIs(t, err, ErrMissingMigration)
+	assert.NotErrorIs(t, err, ErrChecksumMismatch)
+}
@@ -0,0 +1,12 @@
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// FS holds the Flyway style scripts of the sql directory, at its root.
+var FS, _ = fs.Sub(files, "sql")
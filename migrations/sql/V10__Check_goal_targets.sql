@@ -0,0 +1,13 @@
+-- Unknown target types were treated as counts, and targets that aren't positive were reached by any goal.
+UPDATE goals
+SET target_type = 'count'
+WHERE target_type NOT IN ('count', 'percentage');
+
+UPDATE goals
+SET target_value = 1
+WHERE NOT (target_value > 0 AND target_value < 'Infinity');
+
+ALTER TABLE goals
+    ALTER COLUMN target_value DROP DEFAULT,
+    ADD CONSTRAINT goals_target_type_check CHECK (target_type IN ('count', 'percentage')),
+    ADD CONSTRAINT goals_target_value_check CHECK (target_value > 0 AND target_value < 'Infinity');
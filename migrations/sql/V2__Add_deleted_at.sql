@@ -0,0 +1,17 @@
+ALTER TABLE habit_categories
+    ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE habits
+    ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE habit_records
+    ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE tags
+    ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE goals
+    ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE events
+    ADD COLUMN deleted_at TIMESTAMP;
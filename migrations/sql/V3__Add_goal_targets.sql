@@ -0,0 +1,6 @@
+ALTER TABLE goals
+    ADD COLUMN target_type  VARCHAR(20)      NOT NULL DEFAULT 'count',
+    ADD COLUMN target_value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN window_start TIMESTAMP,
+    ADD COLUMN window_end   TIMESTAMP,
+    ADD COLUMN deadline     TIMESTAMP;
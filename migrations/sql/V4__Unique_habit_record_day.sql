@@ -0,0 +1,15 @@
+-- Keep only the latest live record of each habit and day, soft deleting the
+-- others, so that the unique index can be built.
+UPDATE habit_records r
+SET deleted_at = now()
+WHERE r.deleted_at IS NULL
+  AND EXISTS (SELECT 1
+              FROM habit_records o
+              WHERE o.habit_id = r.habit_id
+                AND o.record_date::date = r.record_date::date
+                AND o.deleted_at IS NULL
+                AND (o.updated_at, o.id) > (r.updated_at, r.id));
+
+CREATE UNIQUE INDEX habit_records_habit_id_day_key
+    ON habit_records (habit_id, (record_date::date))
+    WHERE deleted_at IS NULL;
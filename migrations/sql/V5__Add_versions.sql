@@ -0,0 +1,17 @@
+ALTER TABLE habit_categories
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE habits
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE habit_records
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE tags
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE goals
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE events
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
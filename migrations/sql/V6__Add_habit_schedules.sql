@@ -0,0 +1,2 @@
+ALTER TABLE habits
+    ADD COLUMN schedule JSONB NOT NULL DEFAULT '{}';
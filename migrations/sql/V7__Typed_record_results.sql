@@ -0,0 +1,32 @@
+ALTER TABLE habit_records
+    ADD COLUMN value DOUBLE PRECISION,
+    ADD COLUMN unit  VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Numbers, e.g. '8', were recorded as the result of quantified habits.
+UPDATE habit_records
+SET value = btrim(result)::DOUBLE PRECISION
+WHERE btrim(result) ~ '^[0-9]+(\.[0-9]+)?$';
+
+-- Keep the results that can't be mapped in the description rather than lose them.
+UPDATE habit_records
+SET description = btrim(concat_ws(' ', NULLIF(description, ''), '[' || result || ']'))
+WHERE value IS NULL
+  AND lower(btrim(result)) NOT IN ('done', 'success', 'succeeded', 'completed', 'complete', 'yes', 'y', 'true', 'ok',
+                                   'skipped', 'skip', 'rest', 'partial', 'missed', 'miss', 'failed', 'fail', 'no',
+                                   'n', 'false');
+
+UPDATE habit_records
+SET result = CASE
+    WHEN value = 0 THEN 'missed'
+    WHEN value IS NOT NULL THEN 'done'
+    WHEN lower(btrim(result)) IN ('done', 'success', 'succeeded', 'completed', 'complete', 'yes', 'y', 'true', 'ok')
+        THEN 'done'
+    WHEN lower(btrim(result)) IN ('skipped', 'skip', 'rest') THEN 'skipped'
+    WHEN lower(btrim(result)) = 'partial' THEN 'partial'
+    ELSE 'missed'
+END;
+
+ALTER TABLE habit_records
+    ALTER COLUMN result TYPE VARCHAR(20),
+    ADD CONSTRAINT habit_records_result_check CHECK (result IN ('done', 'skipped', 'missed', 'partial')),
+    ADD CONSTRAINT habit_records_unit_check CHECK (unit = '' OR value IS NOT NULL);
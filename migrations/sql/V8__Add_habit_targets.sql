@@ -0,0 +1,7 @@
+ALTER TABLE habits
+    ADD COLUMN target_value      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN target_unit       VARCHAR(20)      NOT NULL DEFAULT '',
+    ADD COLUMN target_comparison VARCHAR(20)      NOT NULL DEFAULT '',
+    ADD COLUMN target_period     VARCHAR(20)      NOT NULL DEFAULT '',
+    ADD CONSTRAINT habits_target_comparison_check CHECK (target_comparison IN ('', 'at_least', 'at_most', 'exactly')),
+    ADD CONSTRAINT habits_target_period_check CHECK (target_period IN ('', 'day', 'week'));
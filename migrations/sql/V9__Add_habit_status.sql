@@ -0,0 +1,19 @@
+ALTER TABLE habits
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active',
+    ADD CONSTRAINT habits_status_check CHECK (status IN ('active', 'paused', 'archived'));
+
+CREATE TABLE habit_pauses
+(
+    id         SERIAL PRIMARY KEY,
+    habit_id   INT       NOT NULL,
+    paused_at  TIMESTAMP NOT NULL,
+    resumed_at TIMESTAMP,
+
+    FOREIGN KEY (habit_id) REFERENCES habits (id),
+    CHECK (resumed_at IS NULL OR resumed_at >= paused_at)
+);
+
+-- A paused or archived habit has one open pause, an active one none.
+CREATE UNIQUE INDEX habit_pauses_habit_id_open_key
+    ON habit_pauses (habit_id)
+    WHERE resumed_at IS NULL;
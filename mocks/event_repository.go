@@ -16,6 +16,64 @@ type EventRepository struct {
 	mock.Mock
 }
 
+// DeleteEvents provides a mock function with given fields: ctx, ids, now
+func (_m *EventRepository) DeleteEvents(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetEventByID provides a mock function with given fields: ctx, id
+func (_m *EventRepository) GetEventByID(ctx context.Context, id uint64) (habit_tracker.Event, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 habit_tracker.Event
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) habit_tracker.Event); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(habit_tracker.Event)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEventsByIDs provides a mock function with given fields: ctx, ids
+func (_m *EventRepository) GetEventsByIDs(ctx context.Context, ids []uint64) (habit_tracker.Events, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 habit_tracker.Events
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) habit_tracker.Events); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.Events)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertEvents provides a mock function with given fields: ctx, events, now
 func (_m *EventRepository) InsertEvents(ctx context.Context, events habit_tracker.Events, now time.Time) error {
 	ret := _m.Called(ctx, events, now)
@@ -30,6 +88,78 @@ func (_m *EventRepository) InsertEvents(ctx context.Context, events habit_tracke
 	return r0
 }
 
+// ListEvents provides a mock function with given fields: ctx, filter
+func (_m *EventRepository) ListEvents(ctx context.Context, filter habit_tracker.EventFilter) (habit_tracker.Events, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 habit_tracker.Events
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.EventFilter) habit_tracker.Events); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.Events)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.EventFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadEvents provides a mock function with given fields: ctx, events, now
+func (_m *EventRepository) LoadEvents(ctx context.Context, events <-chan habit_tracker.Event, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, events, now)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, <-chan habit_tracker.Event, time.Time) int64); ok {
+		r0 = rf(ctx, events, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, <-chan habit_tracker.Event, time.Time) error); ok {
+		r1 = rf(ctx, events, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeEvents provides a mock function with given fields: ctx, ids
+func (_m *EventRepository) PurgeEvents(ctx context.Context, ids []uint64) error {
+	ret := _m.Called(ctx, ids)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreEvents provides a mock function with given fields: ctx, ids, now
+func (_m *EventRepository) RestoreEvents(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateEvents provides a mock function with given fields: ctx, events, now
 func (_m *EventRepository) UpdateEvents(ctx context.Context, events habit_tracker.Events, now time.Time) error {
 	ret := _m.Called(ctx, events, now)
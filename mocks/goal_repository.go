@@ -16,6 +16,64 @@ type GoalRepository struct {
 	mock.Mock
 }
 
+// DeleteGoals provides a mock function with given fields: ctx, ids, now
+func (_m *GoalRepository) DeleteGoals(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetGoalByID provides a mock function with given fields: ctx, id
+func (_m *GoalRepository) GetGoalByID(ctx context.Context, id uint64) (habit_tracker.Goal, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 habit_tracker.Goal
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) habit_tracker.Goal); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(habit_tracker.Goal)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGoalsByIDs provides a mock function with given fields: ctx, ids
+func (_m *GoalRepository) GetGoalsByIDs(ctx context.Context, ids []uint64) (habit_tracker.Goals, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 habit_tracker.Goals
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) habit_tracker.Goals); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.Goals)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertGoals provides a mock function with given fields: ctx, habits, now
 func (_m *GoalRepository) InsertGoals(ctx context.Context, habits habit_tracker.Goals, now time.Time) error {
 	ret := _m.Called(ctx, habits, now)
@@ -30,6 +88,108 @@ func (_m *GoalRepository) InsertGoals(ctx context.Context, habits habit_tracker.
 	return r0
 }
 
+// LinkHabits provides a mock function with given fields: ctx, habitGoals
+func (_m *GoalRepository) LinkHabits(ctx context.Context, habitGoals habit_tracker.HabitGoals) error {
+	ret := _m.Called(ctx, habitGoals)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitGoals) error); ok {
+		r0 = rf(ctx, habitGoals)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListGoals provides a mock function with given fields: ctx, filter
+func (_m *GoalRepository) ListGoals(ctx context.Context, filter habit_tracker.GoalFilter) (habit_tracker.Goals, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 habit_tracker.Goals
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.GoalFilter) habit_tracker.Goals); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.Goals)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.GoalFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListHabitIDsByGoalIDs provides a mock function with given fields: ctx, goalIDs
+func (_m *GoalRepository) ListHabitIDsByGoalIDs(ctx context.Context, goalIDs []uint64) (map[uint64][]uint64, error) {
+	ret := _m.Called(ctx, goalIDs)
+
+	var r0 map[uint64][]uint64
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) map[uint64][]uint64); ok {
+		r0 = rf(ctx, goalIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint64][]uint64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, goalIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeGoals provides a mock function with given fields: ctx, ids
+func (_m *GoalRepository) PurgeGoals(ctx context.Context, ids []uint64) error {
+	ret := _m.Called(ctx, ids)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreGoals provides a mock function with given fields: ctx, ids, now
+func (_m *GoalRepository) RestoreGoals(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UnlinkHabits provides a mock function with given fields: ctx, habitGoals
+func (_m *GoalRepository) UnlinkHabits(ctx context.Context, habitGoals habit_tracker.HabitGoals) error {
+	ret := _m.Called(ctx, habitGoals)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitGoals) error); ok {
+		r0 = rf(ctx, habitGoals)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateGoals provides a mock function with given fields: ctx, habits, now
 func (_m *GoalRepository) UpdateGoals(ctx context.Context, habits habit_tracker.Goals, now time.Time) error {
 	ret := _m.Called(ctx, habits, now)
@@ -16,6 +16,194 @@ type HabitRepository struct {
 	mock.Mock
 }
 
+// ArchiveHabits provides a mock function with given fields: ctx, ids, now
+func (_m *HabitRepository) ArchiveHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteHabitCategories provides a mock function with given fields: ctx, ids, now
+func (_m *HabitRepository) DeleteHabitCategories(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteHabitRecords provides a mock function with given fields: ctx, ids, now
+func (_m *HabitRepository) DeleteHabitRecords(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteHabits provides a mock function with given fields: ctx, ids, now
+func (_m *HabitRepository) DeleteHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetHabitByID provides a mock function with given fields: ctx, id
+func (_m *HabitRepository) GetHabitByID(ctx context.Context, id uint64) (habit_tracker.Habit, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 habit_tracker.Habit
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) habit_tracker.Habit); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(habit_tracker.Habit)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHabitCategoriesByIDs provides a mock function with given fields: ctx, ids
+func (_m *HabitRepository) GetHabitCategoriesByIDs(ctx context.Context, ids []uint64) (habit_tracker.HabitCategories, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 habit_tracker.HabitCategories
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) habit_tracker.HabitCategories); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.HabitCategories)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHabitCategoryByID provides a mock function with given fields: ctx, id
+func (_m *HabitRepository) GetHabitCategoryByID(ctx context.Context, id uint64) (habit_tracker.HabitCategory, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 habit_tracker.HabitCategory
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) habit_tracker.HabitCategory); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(habit_tracker.HabitCategory)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHabitRecordByID provides a mock function with given fields: ctx, id
+func (_m *HabitRepository) GetHabitRecordByID(ctx context.Context, id uint64) (habit_tracker.HabitRecord, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 habit_tracker.HabitRecord
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) habit_tracker.HabitRecord); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(habit_tracker.HabitRecord)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHabitRecordsByIDs provides a mock function with given fields: ctx, ids
+func (_m *HabitRepository) GetHabitRecordsByIDs(ctx context.Context, ids []uint64) (habit_tracker.HabitRecords, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 habit_tracker.HabitRecords
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) habit_tracker.HabitRecords); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.HabitRecords)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHabitsByIDs provides a mock function with given fields: ctx, ids
+func (_m *HabitRepository) GetHabitsByIDs(ctx context.Context, ids []uint64) (habit_tracker.Habits, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 habit_tracker.Habits
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) habit_tracker.Habits); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.Habits)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertHabitCategories provides a mock function with given fields: ctx, habitCategories, now
 func (_m *HabitRepository) InsertHabitCategories(ctx context.Context, habitCategories habit_tracker.HabitCategories, now time.Time) error {
 	ret := _m.Called(ctx, habitCategories, now)
@@ -58,13 +246,126 @@ func (_m *HabitRepository) InsertHabits(ctx context.Context, habits habit_tracke
 	return r0
 }
 
-// UpdateHabitCategories provides a mock function with given fields: ctx, habitCategories, now
-func (_m *HabitRepository) UpdateHabitCategories(ctx context.Context, habitCategories habit_tracker.HabitCategories, now time.Time) error {
-	ret := _m.Called(ctx, habitCategories, now)
+// ListHabitCategories provides a mock function with given fields: ctx, filter
+func (_m *HabitRepository) ListHabitCategories(ctx context.Context, filter habit_tracker.HabitCategoryFilter) (habit_tracker.HabitCategories, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 habit_tracker.HabitCategories
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitCategoryFilter) habit_tracker.HabitCategories); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.HabitCategories)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.HabitCategoryFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListHabitPauses provides a mock function with given fields: ctx, habitIDs
+func (_m *HabitRepository) ListHabitPauses(ctx context.Context, habitIDs []uint64) (map[uint64]habit_tracker.HabitPauses, error) {
+	ret := _m.Called(ctx, habitIDs)
+
+	var r0 map[uint64]habit_tracker.HabitPauses
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) map[uint64]habit_tracker.HabitPauses); ok {
+		r0 = rf(ctx, habitIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint64]habit_tracker.HabitPauses)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, habitIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListHabitRecords provides a mock function with given fields: ctx, filter
+func (_m *HabitRepository) ListHabitRecords(ctx context.Context, filter habit_tracker.HabitRecordFilter) (habit_tracker.HabitRecords, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 habit_tracker.HabitRecords
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitRecordFilter) habit_tracker.HabitRecords); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.HabitRecords)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.HabitRecordFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListHabits provides a mock function with given fields: ctx, filter
+func (_m *HabitRepository) ListHabits(ctx context.Context, filter habit_tracker.HabitFilter) (habit_tracker.Habits, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 habit_tracker.Habits
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitFilter) habit_tracker.Habits); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.Habits)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.HabitFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoadHabitRecords provides a mock function with given fields: ctx, habitRecords, now
+func (_m *HabitRepository) LoadHabitRecords(ctx context.Context, habitRecords <-chan habit_tracker.HabitRecord, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, habitRecords, now)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, <-chan habit_tracker.HabitRecord, time.Time) int64); ok {
+		r0 = rf(ctx, habitRecords, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, <-chan habit_tracker.HabitRecord, time.Time) error); ok {
+		r1 = rf(ctx, habitRecords, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PauseHabits provides a mock function with given fields: ctx, ids, now
+func (_m *HabitRepository) PauseHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitCategories, time.Time) error); ok {
-		r0 = rf(ctx, habitCategories, now)
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -72,13 +373,13 @@ func (_m *HabitRepository) UpdateHabitCategories(ctx context.Context, habitCateg
 	return r0
 }
 
-// UpdateHabitRecords provides a mock function with given fields: ctx, habitRecords, now
-func (_m *HabitRepository) UpdateHabitRecords(ctx context.Context, habitRecords habit_tracker.HabitRecords, now time.Time) error {
-	ret := _m.Called(ctx, habitRecords, now)
+// PurgeHabitCategories provides a mock function with given fields: ctx, ids
+func (_m *HabitRepository) PurgeHabitCategories(ctx context.Context, ids []uint64) error {
+	ret := _m.Called(ctx, ids)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitRecords, time.Time) error); ok {
-		r0 = rf(ctx, habitRecords, now)
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) error); ok {
+		r0 = rf(ctx, ids)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -86,13 +387,41 @@ func (_m *HabitRepository) UpdateHabitRecords(ctx context.Context, habitRecords
 	return r0
 }
 
-// UpdateHabits provides a mock function with given fields: ctx, habits, now
-func (_m *HabitRepository) UpdateHabits(ctx context.Context, habits habit_tracker.Habits, now time.Time) error {
-	ret := _m.Called(ctx, habits, now)
+// PurgeHabitRecords provides a mock function with given fields: ctx, ids
+func (_m *HabitRepository) PurgeHabitRecords(ctx context.Context, ids []uint64) error {
+	ret := _m.Called(ctx, ids)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.Habits, time.Time) error); ok {
-		r0 = rf(ctx, habits, now)
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeHabits provides a mock function with given fields: ctx, ids
+func (_m *HabitRepository) PurgeHabits(ctx context.Context, ids []uint64) error {
+	ret := _m.Called(ctx, ids)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreHabitCategories provides a mock function with given fields: ctx, ids, now
+func (_m *HabitRepository) RestoreHabitCategories(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -100,6 +429,134 @@ func (_m *HabitRepository) UpdateHabits(ctx context.Context, habits habit_tracke
 	return r0
 }
 
+// RestoreHabitRecords provides a mock function with given fields: ctx, ids, now
+func (_m *HabitRepository) RestoreHabitRecords(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreHabits provides a mock function with given fields: ctx, ids, now
+func (_m *HabitRepository) RestoreHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResumeHabits provides a mock function with given fields: ctx, ids, now
+func (_m *HabitRepository) ResumeHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateHabitCategories provides a mock function with given fields: ctx, habitCategories, now
+func (_m *HabitRepository) UpdateHabitCategories(ctx context.Context, habitCategories habit_tracker.HabitCategories, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, habitCategories, now)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitCategories, time.Time) int64); ok {
+		r0 = rf(ctx, habitCategories, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.HabitCategories, time.Time) error); ok {
+		r1 = rf(ctx, habitCategories, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateHabitRecords provides a mock function with given fields: ctx, habitRecords, now
+func (_m *HabitRepository) UpdateHabitRecords(ctx context.Context, habitRecords habit_tracker.HabitRecords, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, habitRecords, now)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitRecords, time.Time) int64); ok {
+		r0 = rf(ctx, habitRecords, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.HabitRecords, time.Time) error); ok {
+		r1 = rf(ctx, habitRecords, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateHabits provides a mock function with given fields: ctx, habits, now
+func (_m *HabitRepository) UpdateHabits(ctx context.Context, habits habit_tracker.Habits, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, habits, now)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.Habits, time.Time) int64); ok {
+		r0 = rf(ctx, habits, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.Habits, time.Time) error); ok {
+		r1 = rf(ctx, habits, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpsertHabitRecords provides a mock function with given fields: ctx, habitRecords, mode, now
+func (_m *HabitRepository) UpsertHabitRecords(ctx context.Context, habitRecords habit_tracker.HabitRecords, mode habit_tracker.UpsertMode, now time.Time) ([]bool, error) {
+	ret := _m.Called(ctx, habitRecords, mode, now)
+
+	var r0 []bool
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitRecords, habit_tracker.UpsertMode, time.Time) []bool); ok {
+		r0 = rf(ctx, habitRecords, mode, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bool)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.HabitRecords, habit_tracker.UpsertMode, time.Time) error); ok {
+		r1 = rf(ctx, habitRecords, mode, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewHabitRepository creates a new instance of HabitRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewHabitRepository(t interface {
@@ -16,6 +16,92 @@ type TagRepository struct {
 	mock.Mock
 }
 
+// AttachTags provides a mock function with given fields: ctx, habitTags
+func (_m *TagRepository) AttachTags(ctx context.Context, habitTags habit_tracker.HabitTags) error {
+	ret := _m.Called(ctx, habitTags)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitTags) error); ok {
+		r0 = rf(ctx, habitTags)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteTags provides a mock function with given fields: ctx, ids, now
+func (_m *TagRepository) DeleteTags(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DetachTags provides a mock function with given fields: ctx, habitTags
+func (_m *TagRepository) DetachTags(ctx context.Context, habitTags habit_tracker.HabitTags) error {
+	ret := _m.Called(ctx, habitTags)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.HabitTags) error); ok {
+		r0 = rf(ctx, habitTags)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetTagByID provides a mock function with given fields: ctx, id
+func (_m *TagRepository) GetTagByID(ctx context.Context, id uint64) (habit_tracker.Tag, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 habit_tracker.Tag
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) habit_tracker.Tag); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(habit_tracker.Tag)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTagsByIDs provides a mock function with given fields: ctx, ids
+func (_m *TagRepository) GetTagsByIDs(ctx context.Context, ids []uint64) (habit_tracker.Tags, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 habit_tracker.Tags
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) habit_tracker.Tags); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.Tags)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertTags provides a mock function with given fields: ctx, tags, now
 func (_m *TagRepository) InsertTags(ctx context.Context, tags habit_tracker.Tags, now time.Time) error {
 	ret := _m.Called(ctx, tags, now)
@@ -30,6 +116,94 @@ func (_m *TagRepository) InsertTags(ctx context.Context, tags habit_tracker.Tags
 	return r0
 }
 
+// ListTags provides a mock function with given fields: ctx, filter
+func (_m *TagRepository) ListTags(ctx context.Context, filter habit_tracker.TagFilter) (habit_tracker.Tags, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 habit_tracker.Tags
+	if rf, ok := ret.Get(0).(func(context.Context, habit_tracker.TagFilter) habit_tracker.Tags); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(habit_tracker.Tags)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, habit_tracker.TagFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTagsByHabitIDs provides a mock function with given fields: ctx, habitIDs
+func (_m *TagRepository) ListTagsByHabitIDs(ctx context.Context, habitIDs []uint64) (map[uint64]habit_tracker.Tags, error) {
+	ret := _m.Called(ctx, habitIDs)
+
+	var r0 map[uint64]habit_tracker.Tags
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) map[uint64]habit_tracker.Tags); ok {
+		r0 = rf(ctx, habitIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint64]habit_tracker.Tags)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, habitIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeTags provides a mock function with given fields: ctx, ids
+func (_m *TagRepository) PurgeTags(ctx context.Context, ids []uint64) error {
+	ret := _m.Called(ctx, ids)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceHabitTags provides a mock function with given fields: ctx, habitID, tagIDs
+func (_m *TagRepository) ReplaceHabitTags(ctx context.Context, habitID uint64, tagIDs []uint64) error {
+	ret := _m.Called(ctx, habitID, tagIDs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, []uint64) error); ok {
+		r0 = rf(ctx, habitID, tagIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreTags provides a mock function with given fields: ctx, ids, now
+func (_m *TagRepository) RestoreTags(ctx context.Context, ids []uint64, now time.Time) error {
+	ret := _m.Called(ctx, ids, now)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64, time.Time) error); ok {
+		r0 = rf(ctx, ids, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateTags provides a mock function with given fields: ctx, tags, now
 func (_m *TagRepository) UpdateTags(ctx context.Context, tags habit_tracker.Tags, now time.Time) error {
 	ret := _m.Called(ctx, tags, now)
@@ -0,0 +1,10 @@
+package habit_tracker
+
+// Page limits a listing. Results are always ordered by ID, so After can be
+// used as a keyset cursor by passing the ID of the last row already seen.
+// Zero values mean no limit, no offset and no cursor.
+type Page struct {
+	Limit  uint64
+	Offset uint64
+	After  uint64
+}
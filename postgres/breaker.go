@@ -0,0 +1,326 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"habit-tracker"
+	"habit-tracker/logger"
+)
+
+// ErrCircuitOpen is returned without reaching the database while the circuit
+// breaker is open.
+var ErrCircuitOpen = fmt.Errorf("circuit breaker is open: %w", habit_tracker.ErrUnavailable)
+
+// breakerBuckets is the number of slices the failure-rate window is split in,
+// so that it slides instead of resetting at once.
+const breakerBuckets = 10
+
+type BreakerState int
+
+const (
+	// BreakerClosed lets every operation through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails every operation fast until the cooldown is over.
+	BreakerOpen
+	// BreakerHalfOpen lets a few trial operations through, closing the
+	// breaker when they succeed and opening it again when one fails.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+type BreakerConfig struct {
+	// Window is the period over which the failure rate is measured.
+	Window time.Duration
+	// MinRequests is the number of operations in the window below which the
+	// breaker never opens, whatever their failure rate.
+	MinRequests int
+	// FailureRate, from 0 to 1, opens the breaker once reached.
+	FailureRate float64
+	// Cooldown is how long the breaker stays open before trying again.
+	Cooldown time.Duration
+	// HalfOpenRequests is the number of trial operations that must succeed
+	// to close the breaker.
+	HalfOpenRequests int
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Window:           10 * time.Second,
+		MinRequests:      10,
+		FailureRate:      0.5,
+		Cooldown:         30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+type breakerBucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+// Breaker is a circuit breaker around Drivers. Only the failures showing
+// that the database is unavailable count, not the ones caused by a query
+// such as constraint violations.
+type Breaker struct {
+	drivers Drivers
+	cfg     BreakerConfig
+	now     func() time.Time
+
+	mu    sync.Mutex
+	state BreakerState
+	// generation changes with state, so that the outcome of an operation let
+	// through in a previous state is ignored.
+	generation uint64
+	openedAt   time.Time
+	buckets    [breakerBuckets]breakerBucket
+	// trials and successes count the operations let through while half-open.
+	trials    int
+	successes int
+}
+
+// NewBreaker wraps drivers in a closed circuit breaker. The zero fields of
+// cfg are taken from DefaultBreakerConfig.
+func NewBreaker(drivers Drivers, cfg BreakerConfig) *Breaker {
+	defaults := DefaultBreakerConfig()
+	if cfg.Window <= 0 {
+		cfg.Window = defaults.Window
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = defaults.MinRequests
+	}
+	if cfg.FailureRate <= 0 {
+		cfg.FailureRate = defaults.FailureRate
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaults.Cooldown
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = defaults.HalfOpenRequests
+	}
+
+	return &Breaker{
+		drivers: drivers,
+		cfg:     cfg,
+		now:     time.Now,
+	}
+}
+
+// State returns the current state, open turning to half-open once the
+// cooldown is over.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cfg.Cooldown {
+		return BreakerHalfOpen
+	}
+
+	return b.state
+}
+
+func (b *Breaker) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	var rows Rows
+	err := b.guard(
+		ctx, func() error {
+			var err error
+			rows, err = b.drivers.QueryContext(ctx, query, args...)
+
+			return err
+		},
+	)
+
+	return rows, err
+}
+
+func (b *Breaker) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := b.guard(
+		ctx, func() error {
+			var err error
+			result, err = b.drivers.ExecContext(ctx, query, args...)
+
+			return err
+		},
+	)
+
+	return result, err
+}
+
+func (b *Breaker) DoTransaction(ctx context.Context, fnStmt ExecStmt) error {
+	return b.guard(
+		ctx, func() error {
+			return b.drivers.DoTransaction(ctx, fnStmt)
+		},
+	)
+}
+
+// WithinTransaction guards the transaction of the wrapped drivers, which must
+// be a habit_tracker.Transactor.
+func (b *Breaker) WithinTransaction(ctx context.Context, fn func(context.Context) error) error {
+	transactor, ok := b.drivers.(habit_tracker.Transactor)
+	if !ok {
+		return fmt.Errorf("[breaker][err:%T doesn't support transactions]", b.drivers)
+	}
+
+	return b.guard(
+		ctx, func() error {
+			return transactor.WithinTransaction(ctx, fn)
+		},
+	)
+}
+
+// guard runs fn unless the breaker is open, and records its outcome. The
+// operations within a transaction already let through are run as is, its
+// outcome being recorded as a whole.
+func (b *Breaker) guard(ctx context.Context, fn func() error) error {
+	_, ok := txFromContext(ctx)
+	if ok {
+		return fn()
+	}
+
+	generation, err := b.allow(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	b.done(ctx, generation, err)
+
+	return err
+}
+
+// allow returns ErrCircuitOpen when the operation must fail fast, or the
+// generation of the state letting it through.
+func (b *Breaker) allow(ctx context.Context) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		if b.now().Sub(b.openedAt) < b.cfg.Cooldown {
+			return 0, ErrCircuitOpen
+		}
+
+		b.transition(ctx, BreakerHalfOpen)
+	}
+
+	if b.state == BreakerHalfOpen {
+		if b.trials >= b.cfg.HalfOpenRequests {
+			return 0, ErrCircuitOpen
+		}
+
+		b.trials++
+	}
+
+	return b.generation, nil
+}
+
+// done records the outcome of an operation let through by allow, unless the
+// state changed since.
+func (b *Breaker) done(ctx context.Context, generation uint64, err error) {
+	failed := isUnavailable(err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case BreakerHalfOpen:
+		if failed {
+			b.transition(ctx, BreakerOpen)
+
+			return
+		}
+
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenRequests {
+			b.transition(ctx, BreakerClosed)
+		}
+	case BreakerClosed:
+		bucket := b.bucket()
+		bucket.requests++
+		if failed {
+			bucket.failures++
+		}
+
+		requests, failures := b.counts()
+		if requests >= b.cfg.MinRequests && float64(failures)/float64(requests) >= b.cfg.FailureRate {
+			b.transition(ctx, BreakerOpen)
+		}
+	}
+}
+
+// transition moves the breaker to state, starting the cooldown or the
+// trials and clearing the window. b.mu must be held.
+func (b *Breaker) transition(ctx context.Context, state BreakerState) {
+	logger.GetLogger(ctx).Warnf("[postgres][breaker][state:%s][from:%s]", state, b.state)
+
+	b.state = state
+	b.generation++
+	b.trials, b.successes = 0, 0
+	b.buckets = [breakerBuckets]breakerBucket{}
+	if state == BreakerOpen {
+		b.openedAt = b.now()
+	}
+}
+
+// bucket returns the bucket of the current slice of the window, reusing the
+// one of the slice it replaces. b.mu must be held.
+func (b *Breaker) bucket() *breakerBucket {
+	width := b.cfg.Window / breakerBuckets
+	if width <= 0 {
+		width = 1
+	}
+
+	start := b.now().Truncate(width)
+	bucket := &b.buckets[(start.UnixNano()/int64(width))%breakerBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+
+	return bucket
+}
+
+// counts sums the operations of the buckets still in the window. b.mu must be
+// held.
+func (b *Breaker) counts() (requests, failures int) {
+	since := b.now().Add(-b.cfg.Window)
+	for _, bucket := range b.buckets {
+		if bucket.start.After(since) {
+			requests += bucket.requests
+			failures += bucket.failures
+		}
+	}
+
+	return requests, failures
+}
+
+// isUnavailable reports whether err shows that the database can't be reached,
+// as opposed to an operation it rejected.
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return errors.Is(err, habit_tracker.ErrUnavailable) || errorKind(err) == habit_tracker.ErrUnavailable
+}
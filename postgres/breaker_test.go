@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+// newTestBreaker returns a breaker around a mocked Postgres, with a clock
+// moved forward by advance.
+func newTestBreaker(t *testing.T, cfg BreakerConfig) (*Breaker, sqlmock.Sqlmock, func(time.Duration)) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker(&Postgres{db: db}, cfg)
+	b.now = func() time.Time {
+		return now
+	}
+
+	return b, mock, func(d time.Duration) {
+		now = now.Add(d)
+	}
+}
+
+func TestBreaker_Opens(t *testing.T) {
+	type test struct {
+		name      string
+		errs      []error
+		wantState BreakerState
+	}
+
+	refused := syscall.ECONNREFUSED
+
+	tests := []test{
+		{
+			name:      "FailureRateReached",
+			errs:      []error{nil, refused, nil, refused},
+			wantState: BreakerOpen,
+		},
+		{
+			name:      "FailureRateNotReached",
+			errs:      []error{nil, refused, nil, nil},
+			wantState: BreakerClosed,
+		},
+		{
+			name:      "BelowMinRequests",
+			errs:      []error{refused, refused, refused},
+			wantState: BreakerClosed,
+		},
+		{
+			name: "QueryErrorsIgnored",
+			errs: []error{
+				&pq.Error{Code: uniqueViolation},
+				&pq.Error{Code: foreignKeyViolation},
+				&pq.Error{Code: uniqueViolation},
+				&pq.Error{Code: foreignKeyViolation},
+			},
+			wantState: BreakerClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				b, mock, _ := newTestBreaker(t, BreakerConfig{MinRequests: 4, FailureRate: 0.5})
+
+				for _, err := range tt.errs {
+					exec := mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM test;`))
+					if err != nil {
+						exec.WillReturnError(err)
+					} else {
+						exec.WillReturnResult(sqlmock.NewResult(0, 1))
+					}
+
+					_, _ = b.ExecContext(context.Background(), `DELETE FROM test;`)
+				}
+
+				assert.Equal(t, tt.wantState, b.State())
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		)
+	}
+}
+
+func TestBreaker_FailsFast(t *testing.T) {
+	b, mock, advance := newTestBreaker(t, BreakerConfig{MinRequests: 1, Cooldown: time.Minute})
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT 1;`)).WillReturnError(syscall.ECONNRESET)
+	_, err := b.QueryContext(context.Background(), `SELECT 1;`)
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+	assert.Equal(t, BreakerOpen, b.State())
+
+	_, err = b.QueryContext(context.Background(), `SELECT 1;`)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.ErrorIs(t, err, habit_tracker.ErrUnavailable)
+
+	err = b.DoTransaction(
+		context.Background(), func(*sql.Tx) error {
+			t.Fatal("transaction started while the breaker is open")
+
+			return nil
+		},
+	)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	advance(59 * time.Second)
+	assert.Equal(t, BreakerOpen, b.State())
+	advance(time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	type test struct {
+		name      string
+		trialErr  error
+		wantState BreakerState
+	}
+
+	tests := []test{
+		{
+			name:      "TrialSucceeded",
+			wantState: BreakerClosed,
+		},
+		{
+			name:      "TrialSucceededWithQueryError",
+			trialErr:  &pq.Error{Code: uniqueViolation},
+			wantState: BreakerClosed,
+		},
+		{
+			name:      "TrialFailed",
+			trialErr:  syscall.ECONNREFUSED,
+			wantState: BreakerOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				b, mock, advance := newTestBreaker(t, BreakerConfig{MinRequests: 1, Cooldown: time.Minute})
+
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM test;`)).WillReturnError(syscall.ECONNREFUSED)
+				_, _ = b.ExecContext(context.Background(), `DELETE FROM test;`)
+				advance(time.Minute)
+
+				mock.ExpectBegin()
+				if tt.trialErr == nil {
+					mock.ExpectCommit()
+				} else {
+					mock.ExpectRollback()
+				}
+
+				started := make(chan struct{})
+				finish := make(chan struct{})
+				trial := make(chan error)
+				go func() {
+					trial <- b.DoTransaction(
+						context.Background(), func(*sql.Tx) error {
+							close(started)
+							<-finish
+
+							return tt.trialErr
+						},
+					)
+				}()
+
+				<-started
+				_, err := b.ExecContext(context.Background(), `DELETE FROM test;`)
+				assert.ErrorIs(t, err, ErrCircuitOpen, "a second trial was let through")
+
+				close(finish)
+				assert.ErrorIs(t, <-trial, tt.trialErr)
+
+				assert.Equal(t, tt.wantState, b.State())
+				assert.NoError(t, mock.ExpectationsWereMet())
+			},
+		)
+	}
+}
+
+func TestBreaker_WindowSlides(t *testing.T) {
+	b, mock, advance := newTestBreaker(
+		t, BreakerConfig{Window: 10 * time.Second, MinRequests: 4, FailureRate: 0.5},
+	)
+
+	for i := 0; i < 3; i++ {
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM test;`)).WillReturnError(syscall.ECONNREFUSED)
+		_, _ = b.ExecContext(context.Background(), `DELETE FROM test;`)
+	}
+
+	advance(11 * time.Second)
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM test;`)).WillReturnError(syscall.ECONNREFUSED)
+	_, _ = b.ExecContext(context.Background(), `DELETE FROM test;`)
+
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBreaker_WithinTransaction(t *testing.T) {
+	b, mock, advance := newTestBreaker(t, BreakerConfig{MinRequests: 1, Cooldown: time.Minute})
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM test;`)).WillReturnError(syscall.ECONNREFUSED)
+	_, _ = b.ExecContext(context.Background(), `DELETE FROM test;`)
+	advance(time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM test;`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM other;`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := b.WithinTransaction(
+		context.Background(), func(ctx context.Context) error {
+			_, err := b.ExecContext(ctx, `DELETE FROM test;`)
+			if err != nil {
+				return err
+			}
+
+			_, err = b.ExecContext(ctx, `DELETE FROM other;`)
+
+			return err
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
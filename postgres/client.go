@@ -3,115 +3,178 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"time"
+	"sync"
 
-	"github.com/kelseyhightower/envconfig"
 	"github.com/lib/pq"
 	sqlTrace "github.com/signalfx/signalfx-go-tracing/contrib/database/sql"
 
 	"habit-tracker/logger"
 )
 
-type Environments struct {
-	Host     string `envconfig:"POSTGRES_HOST" required:"true"`
-	Port     string `envconfig:"POSTGRES_PORT" required:"true"`
-	Name     string `envconfig:"POSTGRES_DATABASE" required:"true"`
-	Username string `envconfig:"POSTGRES_USER" required:"true"`
-	Password string `envconfig:"POSTGRES_PASSWORD" required:"true"`
+type Postgres struct {
+	db    *sql.DB
+	retry RetryPolicy
+
+	// mu guards closed, so that no operation is acquired once Close started
+	// waiting for inFlight.
+	mu       sync.RWMutex
+	closed   bool
+	inFlight sync.WaitGroup
 }
 
-type Connection struct {
-	DbPQTimeout        int
-	MaxConnections     int
-	MaxIdleConnections int
-	Host               string
-	Port               string
-	DBName             string
-	User               string
-	Password           string
-	ConnMaxLifeTime    time.Duration
-}
+var registerDriver sync.Once
+
+// NewPostgres opens a pool of connections as configured by cfg and checks
+// that the database can be reached.
+func NewPostgres(ctx context.Context, cfg Config) (*Postgres, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
 
-var postgresConnection Connection
+	registerDriver.Do(
+		func() {
+			sqlTrace.Register("postgres", &pq.Driver{})
+		},
+	)
 
-func init() {
-	environments := Environments{}
-	err := envconfig.Process("", &environments)
+	log := logger.GetLogger(ctx)
+
+	db, err := sqlTrace.Open("postgres", cfg.DSN())
 	if err != nil {
-		logger.New("-").Panicf("[postgres][process][err:%s]", err.Error())
+		return nil, fmt.Errorf("[postgres][new][open][dsn:%s][err:%w]", cfg.RedactedDSN(), cfg.redact(err))
 	}
 
-	postgresConnection = Connection{
-		DbPQTimeout:        30,
-		MaxConnections:     10,
-		MaxIdleConnections: 1,
-		Host:               environments.Host,
-		Port:               environments.Port,
-		DBName:             environments.Name,
-		User:               environments.Username,
-		Password:           environments.Password,
-		ConnMaxLifeTime:    30 * time.Minute,
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf(
+			"[postgres][new][ping][dsn:%s][err:%w]", cfg.RedactedDSN(), classifyError(cfg.Database, cfg.redact(err)),
+		)
 	}
-}
 
-type Postgres struct {
-	db *sql.DB
-}
+	log.Infof("[postgres][new][dsn:%s][connected]", cfg.RedactedDSN())
 
-func NewPostgres() *Postgres {
-	log := logger.New("-")
-
-	connectionURL := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s&connect_timeout=%d",
-		postgresConnection.User,
-		postgresConnection.Password,
-		postgresConnection.Host,
-		postgresConnection.Port,
-		postgresConnection.DBName,
-		"disable",
-		postgresConnection.DbPQTimeout,
-	)
+	return &Postgres{
+		db:    db,
+		retry: cfg.Retry,
+	}, nil
+}
 
-	sqlTrace.Register("postgres", &pq.Driver{})
+// QueryContext holds its in-flight slot until the returned rows are closed,
+// as they keep using a connection while they are read.
+func (p *Postgres) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	atx, ok := txFromContext(ctx)
+	if ok {
+		return atx.tx.QueryContext(ctx, query, args...)
+	}
 
-	db, err := sqlTrace.Open("postgres", connectionURL)
+	err := p.acquire()
 	if err != nil {
-		log.Panicf("[postgres][new][open][err:%s]", err.Error())
+		return nil, err
 	}
 
-	db.SetMaxIdleConns(postgresConnection.MaxIdleConnections)
-	db.SetMaxOpenConns(postgresConnection.MaxConnections)
-	db.SetConnMaxLifetime(postgresConnection.ConnMaxLifeTime)
+	var rows *sql.Rows
+	err = p.withRetry(
+		ctx, "query", func() error {
+			rows, err = p.db.QueryContext(ctx, query, args...)
 
-	err = db.Ping()
+			return err
+		},
+	)
 	if err != nil {
-		log.Panicf("[postgres][new][ping][err:%s]", err.Error())
-	}
+		p.release()
 
-	return &Postgres{
-		db: db,
+		return nil, err
 	}
+
+	return &releasingRows{Rows: rows, release: p.release}, nil
+}
+
+// releasingRows releases the in-flight slot of its query on the first Close.
+type releasingRows struct {
+	*sql.Rows
+	release func()
+	once    sync.Once
 }
 
-func (p *Postgres) QueryContext(ctx context.Context, query string) (*sql.Rows, error) {
-	return p.db.QueryContext(ctx, query)
+func (r *releasingRows) Close() error {
+	err := r.Rows.Close()
+	r.once.Do(r.release)
+
+	return err
 }
 
-func (p *Postgres) ExecContext(ctx context.Context, query string) (sql.Result, error) {
-	return p.db.ExecContext(ctx, query)
+func (p *Postgres) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	atx, ok := txFromContext(ctx)
+	if ok {
+		return atx.tx.ExecContext(ctx, query, args...)
+	}
+
+	err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer p.release()
+
+	var result sql.Result
+	err = p.withRetry(
+		ctx, "exec", func() error {
+			result, err = p.db.ExecContext(ctx, query, args...)
+
+			return err
+		},
+	)
+
+	return result, err
 }
 
+// DoTransaction runs fnStmt in a new transaction, or in a savepoint of the
+// transaction carried by ctx when called within WithinTransaction.
 func (p *Postgres) DoTransaction(ctx context.Context, fnStmt ExecStmt) error {
+	atx, ok := txFromContext(ctx)
+	if ok {
+		return atx.savepoint(ctx, fnStmt)
+	}
+
+	err := p.acquire()
+	if err != nil {
+		return err
+	}
+	defer p.release()
+
+	return p.withRetry(
+		ctx, "transaction", func() error {
+			return p.doTransaction(ctx, fnStmt)
+		},
+	)
+}
+
+func (p *Postgres) doTransaction(ctx context.Context, fnStmt ExecStmt) error {
 	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+			panic(r)
+		}
+	}()
+
 	err = fnStmt(tx)
 	if err != nil {
+		// The transaction is already rolled back when ctx is done.
 		rollbackError := tx.Rollback()
-		if rollbackError != nil {
+		if rollbackError != nil && !errors.Is(rollbackError, sql.ErrTxDone) {
 			return rollbackError
 		}
 
@@ -120,7 +183,7 @@ func (p *Postgres) DoTransaction(ctx context.Context, fnStmt ExecStmt) error {
 
 	err = tx.Commit()
 	if err != nil {
-		return err
+		return &commitError{err: err}
 	}
 
 	return nil
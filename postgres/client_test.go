@@ -5,9 +5,12 @@ import (
 	"database/sql"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
 )
 
 func TestPostgres_QueryContext(t *testing.T) {
@@ -15,8 +18,9 @@ func TestPostgres_QueryContext(t *testing.T) {
 		db *sql.DB
 	}
 	type args struct {
-		ctx   context.Context
-		query string
+		ctx       context.Context
+		query     string
+		queryArgs []interface{}
 	}
 	type test struct {
 		name    string
@@ -71,6 +75,30 @@ func TestPostgres_QueryContext(t *testing.T) {
 				wantErr: assert.Error,
 			}
 		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM test WHERE id = $1;`)).WithArgs(1).WillReturnRows(
+				sqlmock.NewRows([]string{"id"}).AddRow(1),
+			)
+
+			return test{
+				name: "SuccessWithArgs",
+				fields: fields{
+					db: db,
+				},
+				args: args{
+					ctx:       context.Background(),
+					query:     `SELECT * FROM test WHERE id = $1;`,
+					queryArgs: []interface{}{1},
+				},
+				want: func(t assert.TestingT, i2 ...interface{}) bool {
+					return assert.NotNil(t, i2)
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(
@@ -79,7 +107,7 @@ func TestPostgres_QueryContext(t *testing.T) {
 				p := &Postgres{
 					db: tt.fields.db,
 				}
-				got, err := p.QueryContext(tt.args.ctx, tt.args.query)
+				got, err := p.QueryContext(tt.args.ctx, tt.args.query, tt.args.queryArgs...)
 
 				tt.wantErr(t, err)
 				tt.want(t, got)
@@ -93,8 +121,9 @@ func TestPostgres_ExecContext(t *testing.T) {
 		db *sql.DB
 	}
 	type args struct {
-		ctx   context.Context
-		query string
+		ctx       context.Context
+		query     string
+		queryArgs []interface{}
 	}
 	type test struct {
 		name    string
@@ -150,6 +179,30 @@ func TestPostgres_ExecContext(t *testing.T) {
 				wantErr: assert.Error,
 			}
 		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO test (id, name) VALUES ($1, $2);`)).
+				WithArgs(1, "Mom's run").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+
+			return test{
+				name: "SuccessWithArgs",
+				fields: fields{
+					db: db,
+				},
+				args: args{
+					ctx:       context.Background(),
+					query:     `INSERT INTO test (id, name) VALUES ($1, $2);`,
+					queryArgs: []interface{}{1, "Mom's run"},
+				},
+				want: func(t assert.TestingT, i2 ...interface{}) bool {
+					return assert.NotNil(t, i2)
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(
@@ -158,7 +211,7 @@ func TestPostgres_ExecContext(t *testing.T) {
 				p := &Postgres{
 					db: tt.fields.db,
 				}
-				got, err := p.ExecContext(tt.args.ctx, tt.args.query)
+				got, err := p.ExecContext(tt.args.ctx, tt.args.query, tt.args.queryArgs...)
 
 				tt.wantErr(t, err)
 				tt.want(t, got)
@@ -313,3 +366,48 @@ func TestPostgres_DoTransaction(t *testing.T) {
 		)
 	}
 }
+
+func TestNewPostgres(t *testing.T) {
+	type test struct {
+		name    string
+		cfg     func() Config
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	tests := []test{
+		{
+			name: "ErrorInvalidConfig",
+			cfg:  DefaultConfig,
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrInvalidConfig)
+			},
+		},
+		{
+			name: "ErrorUnreachable",
+			cfg: func() Config {
+				cfg := DefaultConfig()
+				cfg.Host = "127.0.0.1"
+				cfg.Port = "1"
+				cfg.Database = "habit-tracker"
+				cfg.User = "postgres"
+				cfg.ConnectTimeout = time.Second
+
+				return cfg
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, habit_tracker.ErrUnavailable)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				got, err := NewPostgres(context.Background(), tt.cfg())
+
+				tt.wantErr(t, err)
+				assert.Nil(t, got)
+			},
+		)
+	}
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"habit-tracker/migrate"
+	"habit-tracker/migrations"
+	"habit-tracker/repotest"
+)
+
+// TestConformance runs the repository contracts against the database of
+// POSTGRES_TEST_URL, migrating it first. Every table is emptied before each
+// test, so it must not point to a database holding data worth keeping.
+func TestConformance(t *testing.T) {
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL is not set")
+	}
+
+	ctx := context.Background()
+
+	db, err := sql.Open("postgres", url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrations.FS)
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	repotest.Run(
+		t, func(t *testing.T) repotest.Repositories {
+			_, err := db.ExecContext(
+				ctx,
+				`TRUNCATE habit_pauses, habit_tags, habit_goals, events, habit_records, habits, habit_categories, tags, goals RESTART IDENTITY;`,
+			)
+			require.NoError(t, err)
+
+			p := &Postgres{
+				db: db,
+			}
+
+			return repotest.Repositories{
+				Habits: NewHabitRepository(p),
+				Tags:   NewTagRepository(p),
+				Goals:  NewGoalRepository(p),
+				Events: NewEventRepository(p),
+			}
+		},
+	)
+}
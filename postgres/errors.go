@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"syscall"
+
+	"github.com/lib/pq"
+
+	"habit-tracker"
+)
+
+// SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	foreignKeyViolation  = "23503"
+	uniqueViolation      = "23505"
+	notNullViolation     = "23502"
+	checkViolation       = "23514"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	queryCanceled        = "57014"
+	adminShutdown        = "57P01"
+	crashShutdown        = "57P02"
+	cannotConnectNow     = "57P03"
+	tooManyConnections   = "53300"
+)
+
+// missingKeyDetail matches the detail of a foreign key violation raised by a
+// row referencing another that doesn't exist.
+var missingKeyDetail = regexp.MustCompile(`^Key \([^)]+\)=\((\d+)\) is not present in table "([^"]+)"`)
+
+// classifyError turns err into a habit_tracker.Error of the kind matching
+// its cause, reported against entity and ids. Foreign key violations are
+// reported against the missing row instead, when Postgres names it. Errors
+// that are already classified are returned as is, and the ones matching no
+// kind are only wrapped.
+func classifyError(entity string, err error, ids ...uint64) error {
+	var classified *habit_tracker.Error
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	kind := errorKind(err)
+	if kind == nil {
+		return fmt.Errorf("[%s][err:%w]", entity, err)
+	}
+
+	var pqErr *pq.Error
+	if kind == habit_tracker.ErrForeignKeyViolation && errors.As(err, &pqErr) {
+		match := missingKeyDetail.FindStringSubmatch(pqErr.Detail)
+		if match != nil {
+			id, parseErr := strconv.ParseUint(match[1], 10, 64)
+			if parseErr == nil {
+				entity, ids = match[2], []uint64{id}
+			}
+		}
+	}
+
+	return &habit_tracker.Error{
+		Kind:   kind,
+		Entity: entity,
+		IDs:    ids,
+		Err:    err,
+	}
+}
+
+// errorKind returns the habit_tracker error kind matching err, or nil.
+func errorKind(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case foreignKeyViolation:
+			return habit_tracker.ErrForeignKeyViolation
+		case uniqueViolation, serializationFailure, deadlockDetected:
+			return habit_tracker.ErrConflict
+		case notNullViolation, checkViolation:
+			return habit_tracker.ErrValidation
+		case queryCanceled, adminShutdown, crashShutdown, cannotConnectNow, tooManyConnections:
+			return habit_tracker.ErrUnavailable
+		}
+
+		switch pqErr.Code.Class() {
+		case "08":
+			return habit_tracker.ErrUnavailable
+		case "22":
+			return habit_tracker.ErrValidation
+		}
+
+		return nil
+	}
+
+	if isConnectionError(err) {
+		return habit_tracker.ErrUnavailable
+	}
+
+	return nil
+}
+
+// isConnectionError reports whether err comes from a connection that couldn't
+// be opened or broke while in use.
+func isConnectionError(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &netErr)
+}
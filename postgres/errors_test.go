@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func Test_classifyError(t *testing.T) {
+	type args struct {
+		entity string
+		err    error
+		ids    []uint64
+	}
+	type test struct {
+		name     string
+		args     args
+		wantKind error
+		want     func(t assert.TestingT, err error) bool
+	}
+
+	foreignKey := &pq.Error{
+		Code:   foreignKeyViolation,
+		Table:  "habits",
+		Detail: `Key (category_id)=(9) is not present in table "habit_categories".`,
+	}
+	stillReferenced := &pq.Error{
+		Code:   foreignKeyViolation,
+		Detail: `Key (id)=(1) is still referenced from table "habits".`,
+	}
+
+	tests := []test{
+		{
+			name: "ForeignKeyMissingRow",
+			args: args{
+				entity: habitsTable,
+				err:    fmt.Errorf("[tx][err:%w]", foreignKey),
+			},
+			wantKind: habit_tracker.ErrForeignKeyViolation,
+			want: func(t assert.TestingT, err error) bool {
+				var classified *habit_tracker.Error
+
+				return assert.ErrorAs(t, err, &classified) &&
+					assert.Equal(t, "habit_categories", classified.Entity) &&
+					assert.Equal(t, []uint64{9}, classified.IDs) &&
+					assert.ErrorIs(t, err, foreignKey)
+			},
+		},
+		{
+			name: "ForeignKeyStillReferenced",
+			args: args{
+				entity: habitCategoriesTable,
+				err:    stillReferenced,
+				ids:    []uint64{1},
+			},
+			wantKind: habit_tracker.ErrForeignKeyViolation,
+			want: func(t assert.TestingT, err error) bool {
+				var classified *habit_tracker.Error
+
+				return assert.ErrorAs(t, err, &classified) &&
+					assert.Equal(t, habitCategoriesTable, classified.Entity) &&
+					assert.Equal(t, []uint64{1}, classified.IDs)
+			},
+		},
+		{
+			name: "UniqueViolation",
+			args: args{
+				entity: tagsTable,
+				err:    &pq.Error{Code: uniqueViolation},
+			},
+			wantKind: habit_tracker.ErrConflict,
+		},
+		{
+			name: "SerializationFailure",
+			args: args{
+				entity: goalsTable,
+				err:    &pq.Error{Code: serializationFailure},
+				ids:    []uint64{1, 2},
+			},
+			wantKind: habit_tracker.ErrConflict,
+			want: func(t assert.TestingT, err error) bool {
+				var classified *habit_tracker.Error
+
+				return assert.ErrorAs(t, err, &classified) && assert.Equal(t, []uint64{1, 2}, classified.IDs)
+			},
+		},
+		{
+			name: "StringTooLong",
+			args: args{
+				entity: habitsTable,
+				err:    &pq.Error{Code: "22001"},
+			},
+			wantKind: habit_tracker.ErrValidation,
+		},
+		{
+			name: "QueryCanceled",
+			args: args{
+				entity: eventsTable,
+				err:    &pq.Error{Code: queryCanceled},
+			},
+			wantKind: habit_tracker.ErrUnavailable,
+		},
+		{
+			name: "ConnectionFailure",
+			args: args{
+				entity: eventsTable,
+				err:    &pq.Error{Code: "08006"},
+			},
+			wantKind: habit_tracker.ErrUnavailable,
+		},
+		{
+			name: "ConnectionRefused",
+			args: args{
+				entity: eventsTable,
+				err:    &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+			},
+			wantKind: habit_tracker.ErrUnavailable,
+		},
+		{
+			name: "AlreadyClassified",
+			args: args{
+				entity: eventsTable,
+				err:    habit_tracker.NewNotFoundError(habitsTable, 3),
+			},
+			wantKind: habit_tracker.ErrNotFound,
+			want: func(t assert.TestingT, err error) bool {
+				return assert.Equal(t, habit_tracker.NewNotFoundError(habitsTable, 3), err)
+			},
+		},
+		{
+			name: "Unclassified",
+			args: args{
+				entity: eventsTable,
+				err:    assert.AnError,
+			},
+			want: func(t assert.TestingT, err error) bool {
+				var classified *habit_tracker.Error
+
+				return assert.ErrorIs(t, err, assert.AnError) && assert.False(t, errors.As(err, &classified))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				err := classifyError(tt.args.entity, tt.args.err, tt.args.ids...)
+
+				assert.ErrorIs(t, err, tt.args.err)
+				if tt.wantKind != nil {
+					assert.ErrorIs(t, err, tt.wantKind)
+				}
+				if tt.want != nil {
+					tt.want(t, err)
+				}
+			},
+		)
+	}
+}
@@ -3,7 +3,6 @@ package postgres
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"habit-tracker"
@@ -20,55 +19,161 @@ func NewEventRepository(db Drivers) habit_tracker.EventRepository {
 }
 
 func (er *EventRepository) InsertEvents(ctx context.Context, events habit_tracker.Events, now time.Time) error {
-	_, err := er.db.ExecContext(ctx, buildInsertEventsQuery(events, now))
+	if len(events) == 0 {
+		return nil
+	}
+
+	query, args := buildInsertEventsQuery(events, now)
+	inserted, err := execInsert[insertedRow](ctx, er.db, query, args, len(events))
 	if err != nil {
-		return fmt.Errorf("[err:%w]", err)
+		return classifyError(eventsTable, err)
+	}
+
+	for i, row := range inserted {
+		events[i].ID = row.ID
+		events[i].CreatedAt = row.CreatedAt
+		events[i].UpdatedAt = row.UpdatedAt
+		events[i].Version = row.Version
 	}
 
 	return nil
 }
 
+func (er *EventRepository) LoadEvents(ctx context.Context, events <-chan habit_tracker.Event,
+	now time.Time) (int64, error) {
+	loaded, err := execCopy(
+		ctx, er.db, eventsTable, copyEventsColumns, events,
+		func(event habit_tracker.Event) ([]interface{}, error) {
+			return []interface{}{event.HabitID, event.Subject, event.StartAt.UTC(), event.EndAt.UTC(), now, now}, nil
+		},
+	)
+	if err != nil {
+		return 0, classifyError(eventsTable, err)
+	}
+
+	return loaded, nil
+}
+
 func (er *EventRepository) UpdateEvents(ctx context.Context, events habit_tracker.Events, now time.Time) error {
-	_, err := er.db.ExecContext(ctx, buildUpdateEventsQuery(events, now))
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+
+	query, args := buildUpdateEventsQuery(events, now)
+	versions, err := execVersionedUpdate(ctx, er.db, eventsTable, ids, query, args)
+	if err != nil {
+		return classifyError(eventsTable, err, ids...)
+	}
+
+	for i := range events {
+		version, ok := versions[events[i].ID]
+		if ok {
+			events[i].Version = version
+		}
+	}
+
+	return nil
+}
+
+func (er *EventRepository) DeleteEvents(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execSoftDelete(ctx, er.db, eventsTable, ids, now)
 	if err != nil {
-		return fmt.Errorf("[err:%w]", err)
+		return classifyError(eventsTable, err, ids...)
 	}
 
 	return nil
 }
 
-func buildInsertEventsQuery(events habit_tracker.Events, now time.Time) string {
-	str := strings.Builder{}
-	nowStr := now.Format(time.RFC3339)
+func (er *EventRepository) RestoreEvents(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execRestore(ctx, er.db, eventsTable, ids, now)
+	if err != nil {
+		return classifyError(eventsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (er *EventRepository) PurgeEvents(ctx context.Context, ids []uint64) error {
+	err := execPurge(ctx, er.db, eventsTable, ids)
+	if err != nil {
+		return classifyError(eventsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func buildInsertEventsQuery(events habit_tracker.Events, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(events)*6)
 	for _, event := range events {
-		str.WriteString(
-			fmt.Sprintf(
-				`(%d, '%s', '%s', '%s', '%s', '%s'), `,
-				event.HabitID, event.Subject, event.StartAt.Format(time.RFC3339),
-				event.EndAt.Format(time.RFC3339), nowStr, nowStr,
-			),
-		)
+		args = append(args, event.HabitID, event.Subject, event.StartAt.UTC(), event.EndAt.UTC(), now, now)
 	}
 
-	return fmt.Sprintf(insertEventsQuery, strings.TrimSuffix(str.String(), ", "))
+	return fmt.Sprintf(insertEventsQuery, buildValues(len(events), 6)), args
 }
 
-func buildUpdateEventsQuery(events habit_tracker.Events, now time.Time) string {
-	str := strings.Builder{}
-	nowStr := now.Format(time.RFC3339)
+func buildUpdateEventsQuery(events habit_tracker.Events, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(events)*len(updateEventsTypes))
 	for _, event := range events {
-		str.WriteString(
-			fmt.Sprintf(
-				UpdateEventsQuery,
-				event.HabitID,
-				event.Subject,
-				event.StartAt.Format(time.RFC3339),
-				event.EndAt.Format(time.RFC3339),
-				nowStr,
-				event.ID,
-			),
+		args = append(
+			args, event.ID, event.HabitID, event.Subject, event.StartAt.UTC(), event.EndAt.UTC(), now, event.Version,
 		)
 	}
 
-	return str.String()
+	return fmt.Sprintf(UpdateEventsQuery, buildTypedValues(len(events), updateEventsTypes)), args
+}
+
+func (er *EventRepository) GetEventByID(ctx context.Context, id uint64) (habit_tracker.Event, error) {
+	event, err := selectByID[habit_tracker.Event](ctx, er.db, eventsTable, id)
+	if err != nil {
+		return event, classifyError(eventsTable, err, id)
+	}
+
+	return event, nil
+}
+
+func (er *EventRepository) GetEventsByIDs(ctx context.Context, ids []uint64) (habit_tracker.Events, error) {
+	events, err := selectByIDs[habit_tracker.Event](ctx, er.db, eventsTable, ids)
+	if err != nil {
+		return nil, classifyError(eventsTable, err, ids...)
+	}
+
+	return events, nil
+}
+
+func (er *EventRepository) ListEvents(ctx context.Context,
+	filter habit_tracker.EventFilter) (habit_tracker.Events, error) {
+	events, err := selectRows[habit_tracker.Event](ctx, er.db, eventsTable, buildEventsWhere(filter), filter.Page)
+	if err != nil {
+		return nil, classifyError(eventsTable, err)
+	}
+
+	return events, nil
+}
+
+func buildEventsWhere(filter habit_tracker.EventFilter) whereClause {
+	where := whereClause{}
+	if filter.HabitID > 0 {
+		where.add("habit_id = $%d", filter.HabitID)
+	}
+
+	// start_at and end_at have no time zone: the bounds are compared in UTC,
+	// as the events are stored.
+	if !filter.From.IsZero() {
+		where.add("end_at > $%d", filter.From.UTC())
+	}
+
+	if !filter.To.IsZero() {
+		where.add("start_at < $%d", filter.To.UTC())
+	}
+
+	if !filter.IncludeDeleted {
+		where.notDeleted()
+	}
+
+	return where
 }
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql/driver"
 	"regexp"
 	"testing"
 	"time"
@@ -33,13 +34,29 @@ INSERT
 	INTO
 	events
 (habit_id, subject, start_at, end_at, created_at, updated_at)
-VALUES (2, 'Go to gym', '2023-07-27T12:00:00Z', '2023-07-27T14:00:00Z', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z'), (3, 'Painting class', '2023-07-27T14:00:00Z', '2023-07-27T16:00:00Z', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z');`
+VALUES ($1, $2, $3, $4, $5, $6), ($7, $8, $9, $10, $11, $12)
+RETURNING
+	id, created_at, updated_at, version;`
+	queryArgs := []driver.Value{
+		int64(2),
+		"Go to gym",
+		time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+		time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+		int64(3),
+		"Painting class",
+		time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 27, 16, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+		time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+	}
 	tests := []test{
 		func() test {
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(3, 2))
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(insertedRows(2, 3))
 
 			return test{
 				name: "Success",
@@ -73,7 +90,7 @@ VALUES (2, 'Go to gym', '2023-07-27T12:00:00Z', '2023-07-27T14:00:00Z', '2023-07
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(assert.AnError)
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
 
 			return test{
 				name: "ErrorExecContext",
@@ -134,31 +151,46 @@ func TestEventRepository_UpdateEvents(t *testing.T) {
 
 	query := `
 UPDATE
-	events
+	events AS e
 SET
-	habit_id = 2,
-	subject = 'Go to gym',
-	start_at = '2023-07-27T12:00:00Z',
-	end_at = '2023-07-27T14:00:00Z',
-	updated_at = '2023-07-20T15:32:00Z'
+	habit_id = v.habit_id,
+	subject = v.subject,
+	start_at = v.start_at,
+	end_at = v.end_at,
+	updated_at = v.updated_at,
+	version = e.version + 1
+FROM
+	(VALUES ($1::INT, $2::INT, $3::TEXT, $4::TIMESTAMP, $5::TIMESTAMP, $6::TIMESTAMP, $7::BIGINT), ($8::INT, $9::INT, $10::TEXT, $11::TIMESTAMP, $12::TIMESTAMP, $13::TIMESTAMP, $14::BIGINT)) AS v(id, habit_id, subject, start_at, end_at, updated_at, version)
 WHERE
-	id = 1;
-UPDATE
-	events
-SET
-	habit_id = 3,
-	subject = 'Painting class',
-	start_at = '2023-07-27T14:00:00Z',
-	end_at = '2023-07-27T16:00:00Z',
-	updated_at = '2023-07-20T15:32:00Z'
-WHERE
-	id = 2;`
+	e.id = v.id
+	AND e.deleted_at IS NULL
+	AND e.version = v.version
+RETURNING
+	e.id, e.version;`
+	queryArgs := []driver.Value{
+		int64(1),
+		int64(2),
+		"Go to gym",
+		time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+		int64(1),
+		int64(2),
+		int64(3),
+		"Painting class",
+		time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 27, 16, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+		int64(1),
+	}
 	tests := []test{
 		func() test {
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(2, 2))
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(updatedRows(1, 2))
+			mock.ExpectCommit()
 
 			return test{
 				name: "Success",
@@ -176,6 +208,7 @@ WHERE
 							Subject: "Go to gym",
 							StartAt: time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
 							EndAt:   time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+							Version: 1,
 						},
 						{
 							ID:      2,
@@ -183,6 +216,7 @@ WHERE
 							Subject: "Painting class",
 							StartAt: time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
 							EndAt:   time.Date(2023, 7, 27, 16, 0, 0, 0, time.UTC),
+							Version: 1,
 						},
 					},
 					now: time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
@@ -194,10 +228,12 @@ WHERE
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(assert.AnError)
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
+			mock.ExpectRollback()
 
 			return test{
-				name: "ErrorExecContext",
+				name: "ErrorQueryContext",
 				fields: fields{
 					db: &Postgres{
 						db: db,
@@ -212,6 +248,7 @@ WHERE
 							Subject: "Go to gym",
 							StartAt: time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
 							EndAt:   time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+							Version: 1,
 						},
 						{
 							ID:      2,
@@ -219,6 +256,7 @@ WHERE
 							Subject: "Painting class",
 							StartAt: time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
 							EndAt:   time.Date(2023, 7, 27, 16, 0, 0, 0, time.UTC),
+							Version: 1,
 						},
 					},
 					now: time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
@@ -246,9 +284,10 @@ func Test_buildInsertEventsQuery(t *testing.T) {
 		now    time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			name: "Success",
@@ -274,14 +313,33 @@ INSERT
 	INTO
 	events
 (habit_id, subject, start_at, end_at, created_at, updated_at)
-VALUES (2, 'Go to gym', '2023-07-27T12:00:00Z', '2023-07-27T14:00:00Z', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z'), (3, 'Painting class', '2023-07-27T14:00:00Z', '2023-07-27T16:00:00Z', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z');`,
+VALUES ($1, $2, $3, $4, $5, $6), ($7, $8, $9, $10, $11, $12)
+RETURNING
+	id, created_at, updated_at, version;`,
+			wantArgs: []interface{}{
+				uint64(2),
+				"Go to gym",
+				time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+				time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+				uint64(3),
+				"Painting class",
+				time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 27, 16, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+				time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				assert.Equal(t, tt.want, buildInsertEventsQuery(tt.args.events, tt.args.now))
+				got, gotArgs := buildInsertEventsQuery(tt.args.events, tt.args.now)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
 			},
 		)
 	}
@@ -293,9 +351,10 @@ func Test_buildUpdateEventsQuery(t *testing.T) {
 		now    time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			name: "Success",
@@ -320,33 +379,249 @@ func Test_buildUpdateEventsQuery(t *testing.T) {
 			},
 			want: `
 UPDATE
-	events
+	events AS e
 SET
-	habit_id = 2,
-	subject = 'Go to gym',
-	start_at = '2023-07-27T12:00:00Z',
-	end_at = '2023-07-27T14:00:00Z',
-	updated_at = '2023-07-20T15:32:00Z'
+	habit_id = v.habit_id,
+	subject = v.subject,
+	start_at = v.start_at,
+	end_at = v.end_at,
+	updated_at = v.updated_at,
+	version = e.version + 1
+FROM
+	(VALUES ($1::INT, $2::INT, $3::TEXT, $4::TIMESTAMP, $5::TIMESTAMP, $6::TIMESTAMP, $7::BIGINT), ($8::INT, $9::INT, $10::TEXT, $11::TIMESTAMP, $12::TIMESTAMP, $13::TIMESTAMP, $14::BIGINT)) AS v(id, habit_id, subject, start_at, end_at, updated_at, version)
 WHERE
-	id = 1;
-UPDATE
+	e.id = v.id
+	AND e.deleted_at IS NULL
+	AND e.version = v.version
+RETURNING
+	e.id, e.version;`,
+			wantArgs: []interface{}{
+				uint64(1),
+				uint64(2),
+				"Go to gym",
+				time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+				uint64(0),
+				uint64(2),
+				uint64(3),
+				"Painting class",
+				time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 27, 16, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+				uint64(0),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				got, gotArgs := buildUpdateEventsQuery(tt.args.events, tt.args.now)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
+			},
+		)
+	}
+}
+
+func TestEventRepository_GetEventByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	"id", "habit_id", "subject", "start_at", "end_at", "created_at", "updated_at", "deleted_at", "version"
+FROM
 	events
-SET
-	habit_id = 3,
-	subject = 'Painting class',
-	start_at = '2023-07-27T14:00:00Z',
-	end_at = '2023-07-27T16:00:00Z',
-	updated_at = '2023-07-20T15:32:00Z'
 WHERE
-	id = 2;`,
+	id = $1
+	AND deleted_at IS NULL
+ORDER BY
+	id;`)).WithArgs(1).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "habit_id", "subject", "start_at", "end_at"}).AddRow(
+			1, 2, "Go to gym",
+			time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
+			time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+		),
+	)
+
+	er := NewEventRepository(&Postgres{db: db})
+	got, err := er.GetEventByID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		habit_tracker.Event{
+			ID:      1,
+			HabitID: 2,
+			Subject: "Go to gym",
+			StartAt: time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
+			EndAt:   time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
 		},
+		got,
+	)
+}
+
+func TestEventRepository_ListEvents(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx    context.Context
+		filter habit_tracker.EventFilter
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		want    habit_tracker.Events
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+SELECT
+	"id", "habit_id", "subject", "start_at", "end_at", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	events
+WHERE
+	habit_id = $1
+	AND end_at > $2
+	AND start_at < $3
+	AND deleted_at IS NULL
+ORDER BY
+	id;`
+	from := time.Date(2023, 7, 27, 13, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 7, 27, 15, 0, 0, 0, time.UTC)
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(2, from, to).WillReturnRows(
+				sqlmock.NewRows([]string{"id", "habit_id", "subject", "start_at", "end_at"}).AddRow(
+					1, 2, "Go to gym",
+					time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
+					time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+				),
+			)
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					filter: habit_tracker.EventFilter{
+						HabitID: 2,
+						From:    from,
+						To:      to.In(time.FixedZone("EDT", -4*60*60)),
+					},
+				},
+				want: habit_tracker.Events{
+					{
+						ID:      1,
+						HabitID: 2,
+						Subject: "Go to gym",
+						StartAt: time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC),
+						EndAt:   time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC),
+					},
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(2, from, to).WillReturnError(assert.AnError)
+
+			return test{
+				name: "ErrorQueryContext",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					filter: habit_tracker.EventFilter{
+						HabitID: 2,
+						From:    from,
+						To:      to,
+					},
+				},
+				wantErr: assert.Error,
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				assert.Equal(t, tt.want, buildUpdateEventsQuery(tt.args.events, tt.args.now))
+				er := NewEventRepository(tt.fields.db)
+				got, err := er.ListEvents(tt.args.ctx, tt.args.filter)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
 			},
 		)
 	}
 }
+
+func TestEventRepository_PurgeEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+DELETE
+FROM
+	events
+WHERE
+	id = ANY($1)
+RETURNING
+	id;`)).WithArgs("{1,2}").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectRollback()
+
+	er := NewEventRepository(&Postgres{db: db})
+
+	assert.ErrorIs(t, er.PurgeEvents(context.Background(), []uint64{1, 2}), habit_tracker.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventRepository_LoadEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC)
+	startAt := time.Date(2023, 7, 27, 12, 0, 0, 0, time.UTC)
+	endAt := time.Date(2023, 7, 27, 14, 0, 0, 0, time.UTC)
+	copyIn := `COPY "events" ("habit_id", "subject", "start_at", "end_at", "created_at", "updated_at") FROM STDIN`
+
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare(regexp.QuoteMeta(copyIn))
+	prepare.ExpectExec().WithArgs(int64(2), "Go to gym", startAt, endAt, now, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs(int64(3), "Painting class", startAt, endAt, now, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	events := make(chan habit_tracker.Event, 2)
+	events <- habit_tracker.Event{HabitID: 2, Subject: "Go to gym", StartAt: startAt, EndAt: endAt}
+	events <- habit_tracker.Event{HabitID: 3, Subject: "Painting class", StartAt: startAt, EndAt: endAt}
+	close(events)
+
+	er := NewEventRepository(&Postgres{db: db})
+	loaded, err := er.LoadEvents(context.Background(), events, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), loaded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
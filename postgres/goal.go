@@ -3,9 +3,10 @@ package postgres
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"habit-tracker"
 )
 
@@ -20,41 +21,216 @@ func NewGoalRepository(db Drivers) habit_tracker.GoalRepository {
 }
 
 func (gr *GoalRepository) InsertGoals(ctx context.Context, goals habit_tracker.Goals, now time.Time) error {
-	_, err := gr.db.ExecContext(ctx, buildInsertGoalsQuery(goals, now))
+	if len(goals) == 0 {
+		return nil
+	}
+
+	err := goals.Validate()
+	if err != nil {
+		return err
+	}
+
+	query, args := buildInsertGoalsQuery(goals, now)
+	inserted, err := execInsert[insertedRow](ctx, gr.db, query, args, len(goals))
 	if err != nil {
-		return fmt.Errorf("[err:%w]", err)
+		return classifyError(goalsTable, err)
+	}
+
+	for i, row := range inserted {
+		goals[i].ID = row.ID
+		goals[i].CreatedAt = row.CreatedAt
+		goals[i].UpdatedAt = row.UpdatedAt
+		goals[i].Version = row.Version
 	}
 
 	return nil
 }
 
 func (gr *GoalRepository) UpdateGoals(ctx context.Context, goals habit_tracker.Goals, now time.Time) error {
-	_, err := gr.db.ExecContext(ctx, buildUpdateGoalsQuery(goals, now))
+	if len(goals) == 0 {
+		return nil
+	}
+
+	err := goals.Validate()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]uint64, len(goals))
+	for i, goal := range goals {
+		ids[i] = goal.ID
+	}
+
+	query, args := buildUpdateGoalsQuery(goals, now)
+	versions, err := execVersionedUpdate(ctx, gr.db, goalsTable, ids, query, args)
+	if err != nil {
+		return classifyError(goalsTable, err, ids...)
+	}
+
+	for i := range goals {
+		version, ok := versions[goals[i].ID]
+		if ok {
+			goals[i].Version = version
+		}
+	}
+
+	return nil
+}
+
+func (gr *GoalRepository) DeleteGoals(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execSoftDelete(ctx, gr.db, goalsTable, ids, now)
 	if err != nil {
-		return fmt.Errorf("[err:%w]", err)
+		return classifyError(goalsTable, err, ids...)
 	}
 
 	return nil
 }
 
-func buildInsertGoalsQuery(goals habit_tracker.Goals, now time.Time) string {
-	str := strings.Builder{}
-	nowStr := now.Format(time.RFC3339)
+func (gr *GoalRepository) RestoreGoals(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execRestore(ctx, gr.db, goalsTable, ids, now)
+	if err != nil {
+		return classifyError(goalsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (gr *GoalRepository) PurgeGoals(ctx context.Context, ids []uint64) error {
+	err := execPurge(ctx, gr.db, goalsTable, ids, purgeHabitGoalsQuery)
+	if err != nil {
+		return classifyError(goalsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (gr *GoalRepository) LinkHabits(ctx context.Context, habitGoals habit_tracker.HabitGoals) error {
+	if len(habitGoals) == 0 {
+		return nil
+	}
+
+	query, args := buildHabitGoalsQuery(linkHabitsQuery, habitGoals)
+	_, err := gr.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return classifyError(habitGoalsTable, err)
+	}
+
+	return nil
+}
+
+func (gr *GoalRepository) UnlinkHabits(ctx context.Context, habitGoals habit_tracker.HabitGoals) error {
+	if len(habitGoals) == 0 {
+		return nil
+	}
+
+	query, args := buildHabitGoalsQuery(unlinkHabitsQuery, habitGoals)
+	_, err := gr.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return classifyError(habitGoalsTable, err)
+	}
+
+	return nil
+}
+
+func (gr *GoalRepository) ListHabitIDsByGoalIDs(ctx context.Context,
+	goalIDs []uint64) (map[uint64][]uint64, error) {
+	habitIDs := make(map[uint64][]uint64)
+	if len(goalIDs) == 0 {
+		return habitIDs, nil
+	}
+
+	rows, err := gr.db.QueryContext(ctx, listHabitIDsByGoalIDsQuery, pq.Array(int64s(goalIDs)))
+	if err != nil {
+		return nil, classifyError(habitGoalsTable, err, goalIDs...)
+	}
+
+	habitGoals, err := scanRows[habit_tracker.HabitGoal](rows)
+	if err != nil {
+		return nil, classifyError(habitGoalsTable, err, goalIDs...)
+	}
+
+	for _, habitGoal := range habitGoals {
+		habitIDs[habitGoal.GoalID] = append(habitIDs[habitGoal.GoalID], habitGoal.HabitID)
+	}
+
+	return habitIDs, nil
+}
+
+func buildInsertGoalsQuery(goals habit_tracker.Goals, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(goals)*8)
 	for _, goal := range goals {
-		str.WriteString(fmt.Sprintf("('%s', '%s', '%s'), ", goal.Description, nowStr, nowStr))
+		args = append(
+			args,
+			goal.Description, goalTargetType(goal), goal.TargetValue,
+			goal.WindowStart, goal.WindowEnd, goal.Deadline, now, now,
+		)
 	}
 
-	return fmt.Sprintf(insertGoalsQuery, strings.TrimSuffix(str.String(), ", "))
+	return fmt.Sprintf(insertGoalsQuery, buildValues(len(goals), 8)), args
 }
 
-func buildUpdateGoalsQuery(goals habit_tracker.Goals, now time.Time) string {
-	str := strings.Builder{}
-	nowStr := now.Format(time.RFC3339)
+func buildUpdateGoalsQuery(goals habit_tracker.Goals, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(goals)*len(updateGoalsTypes))
 	for _, goal := range goals {
-		str.WriteString(
-			fmt.Sprintf(UpdateGoalsQuery, goal.Description, nowStr, goal.ID),
+		args = append(
+			args,
+			goal.ID, goal.Description, goalTargetType(goal), goal.TargetValue,
+			goal.WindowStart, goal.WindowEnd, goal.Deadline, now, goal.Version,
 		)
 	}
 
-	return str.String()
+	return fmt.Sprintf(UpdateGoalsQuery, buildTypedValues(len(goals), updateGoalsTypes)), args
+}
+
+func (gr *GoalRepository) GetGoalByID(ctx context.Context, id uint64) (habit_tracker.Goal, error) {
+	goal, err := selectByID[habit_tracker.Goal](ctx, gr.db, goalsTable, id)
+	if err != nil {
+		return goal, classifyError(goalsTable, err, id)
+	}
+
+	return goal, nil
+}
+
+func (gr *GoalRepository) GetGoalsByIDs(ctx context.Context, ids []uint64) (habit_tracker.Goals, error) {
+	goals, err := selectByIDs[habit_tracker.Goal](ctx, gr.db, goalsTable, ids)
+	if err != nil {
+		return nil, classifyError(goalsTable, err, ids...)
+	}
+
+	return goals, nil
+}
+
+func (gr *GoalRepository) ListGoals(ctx context.Context, filter habit_tracker.GoalFilter) (habit_tracker.Goals, error) {
+	goals, err := selectRows[habit_tracker.Goal](ctx, gr.db, goalsTable, buildGoalsWhere(filter), filter.Page)
+	if err != nil {
+		return nil, classifyError(goalsTable, err)
+	}
+
+	return goals, nil
+}
+
+func buildGoalsWhere(filter habit_tracker.GoalFilter) whereClause {
+	where := whereClause{}
+	if !filter.IncludeDeleted {
+		where.notDeleted()
+	}
+
+	return where
+}
+
+func buildHabitGoalsQuery(query string, habitGoals habit_tracker.HabitGoals) (string, []interface{}) {
+	args := make([]interface{}, 0, len(habitGoals)*len(habitGoalsTypes))
+	for _, habitGoal := range habitGoals {
+		args = append(args, habitGoal.HabitID, habitGoal.GoalID)
+	}
+
+	return fmt.Sprintf(query, buildTypedValues(len(habitGoals), habitGoalsTypes)), args
+}
+
+func goalTargetType(goal habit_tracker.Goal) string {
+	if goal.TargetType == "" {
+		return string(habit_tracker.GoalTargetCount)
+	}
+
+	return string(goal.TargetType)
 }
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql/driver"
 	"regexp"
 	"testing"
 	"time"
@@ -32,14 +33,26 @@ func TestGoalRepository_InsertGoals(t *testing.T) {
 INSERT
 	INTO
 	goals
-(description, created_at, updated_at)
-VALUES ('New goal', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z');`
+(description, target_type, target_value, window_start, window_end, deadline, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING
+	id, created_at, updated_at, version;`
+	queryArgs := []driver.Value{
+		"New goal",
+		"count",
+		float64(5),
+		nil,
+		nil,
+		nil,
+		time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+		time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+	}
 	tests := []test{
 		func() test {
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(insertedRows(1))
 
 			return test{
 				name: "Success",
@@ -53,6 +66,7 @@ VALUES ('New goal', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z');`
 					goals: habit_tracker.Goals{
 						{
 							Description: "New goal",
+							TargetValue: 5,
 						},
 					},
 					now: time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
@@ -64,7 +78,7 @@ VALUES ('New goal', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z');`
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(assert.AnError)
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
 
 			return test{
 				name: "ErrorExecContext",
@@ -78,6 +92,7 @@ VALUES ('New goal', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z');`
 					goals: habit_tracker.Goals{
 						{
 							Description: "New goal",
+							TargetValue: 5,
 						},
 					},
 					now: time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
@@ -85,6 +100,33 @@ VALUES ('New goal', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z');`
 				wantErr: assert.Error,
 			}
 		}(),
+		func() test {
+			db, _, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			return test{
+				name: "ErrorValidation",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					goals: habit_tracker.Goals{
+						{
+							Description: "New goal",
+							TargetType:  "foo",
+							TargetValue: 5,
+						},
+					},
+					now: time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrValidation, i...)
+				},
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(
@@ -98,6 +140,8 @@ VALUES ('New goal', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z');`
 }
 
 func TestGoalRepository_UpdateGoals(t *testing.T) {
+	deadline := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+
 	type fields struct {
 		db Drivers
 	}
@@ -115,18 +159,43 @@ func TestGoalRepository_UpdateGoals(t *testing.T) {
 
 	query := `
 UPDATE
-	goals
+	goals AS g
 SET
-	description = 'New goal',
-	updated_at = '2023-07-20T15:32:00Z'
+	description = v.description,
+	target_type = v.target_type,
+	target_value = v.target_value,
+	window_start = v.window_start,
+	window_end = v.window_end,
+	deadline = v.deadline,
+	updated_at = v.updated_at,
+	version = g.version + 1
+FROM
+	(VALUES ($1::INT, $2::TEXT, $3::VARCHAR, $4::DOUBLE PRECISION, $5::TIMESTAMP, $6::TIMESTAMP, $7::TIMESTAMP, $8::TIMESTAMP, $9::BIGINT)) AS v(id, description, target_type, target_value, window_start, window_end, deadline, updated_at, version)
 WHERE
-	id = 1;`
+	g.id = v.id
+	AND g.deleted_at IS NULL
+	AND g.version = v.version
+RETURNING
+	g.id, g.version;`
+	queryArgs := []driver.Value{
+		int64(1),
+		"New goal",
+		"percentage",
+		float64(80),
+		nil,
+		nil,
+		time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+		int64(1),
+	}
 	tests := []test{
 		func() test {
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(updatedRows(1))
+			mock.ExpectCommit()
 
 			return test{
 				name: "Success",
@@ -141,6 +210,10 @@ WHERE
 						{
 							ID:          1,
 							Description: "New goal",
+							TargetType:  habit_tracker.GoalTargetPercentage,
+							TargetValue: 80,
+							Deadline:    &deadline,
+							Version:     1,
 						},
 					},
 					now: time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
@@ -152,10 +225,12 @@ WHERE
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(assert.AnError)
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
+			mock.ExpectRollback()
 
 			return test{
-				name: "ErrorExecContext",
+				name: "ErrorQueryContext",
 				fields: fields{
 					db: &Postgres{
 						db: db,
@@ -167,6 +242,10 @@ WHERE
 						{
 							ID:          1,
 							Description: "New goal",
+							TargetType:  habit_tracker.GoalTargetPercentage,
+							TargetValue: 80,
+							Deadline:    &deadline,
+							Version:     1,
 						},
 					},
 					now: time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
@@ -174,6 +253,48 @@ WHERE
 				wantErr: assert.Error,
 			}
 		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(updatedRows())
+			mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	id
+FROM
+	goals
+WHERE
+	id = ANY($1)
+	AND deleted_at IS NULL;`)).WithArgs("{1}").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectRollback()
+
+			return test{
+				name: "NotFound",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					goals: habit_tracker.Goals{
+						{
+							ID:          1,
+							Description: "New goal",
+							TargetType:  habit_tracker.GoalTargetPercentage,
+							TargetValue: 80,
+							Deadline:    &deadline,
+							Version:     1,
+						},
+					},
+					now: time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.Equal(t, habit_tracker.NewNotFoundError("goals", 1), err, i...)
+				},
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(
@@ -194,9 +315,10 @@ func Test_buildInsertGoalsQuery(t *testing.T) {
 		now   time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			name: "Success",
@@ -212,29 +334,47 @@ func Test_buildInsertGoalsQuery(t *testing.T) {
 INSERT
 	INTO
 	goals
-(description, created_at, updated_at)
-VALUES ('New goal', '2023-07-20T15:32:00Z', '2023-07-20T15:32:00Z');`,
+(description, target_type, target_value, window_start, window_end, deadline, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING
+	id, created_at, updated_at, version;`,
+			wantArgs: []interface{}{
+				"New goal",
+				"count",
+				float64(0),
+				(*time.Time)(nil),
+				(*time.Time)(nil),
+				(*time.Time)(nil),
+				time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+				time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				assert.Equal(t, tt.want, buildInsertGoalsQuery(tt.args.goals, tt.args.now))
+				got, gotArgs := buildInsertGoalsQuery(tt.args.goals, tt.args.now)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
 			},
 		)
 	}
 }
 
 func Test_buildUpdateGoalsQuery(t *testing.T) {
+	deadline := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+
 	type args struct {
 		goals habit_tracker.Goals
 		now   time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			name: "Success",
@@ -243,26 +383,363 @@ func Test_buildUpdateGoalsQuery(t *testing.T) {
 					{
 						ID:          1,
 						Description: "New goal",
+						TargetType:  habit_tracker.GoalTargetPercentage,
+						TargetValue: 80,
+						Deadline:    &deadline,
 					},
 				},
 				now: time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
 			},
 			want: `
+UPDATE
+	goals AS g
+SET
+	description = v.description,
+	target_type = v.target_type,
+	target_value = v.target_value,
+	window_start = v.window_start,
+	window_end = v.window_end,
+	deadline = v.deadline,
+	updated_at = v.updated_at,
+	version = g.version + 1
+FROM
+	(VALUES ($1::INT, $2::TEXT, $3::VARCHAR, $4::DOUBLE PRECISION, $5::TIMESTAMP, $6::TIMESTAMP, $7::TIMESTAMP, $8::TIMESTAMP, $9::BIGINT)) AS v(id, description, target_type, target_value, window_start, window_end, deadline, updated_at, version)
+WHERE
+	g.id = v.id
+	AND g.deleted_at IS NULL
+	AND g.version = v.version
+RETURNING
+	g.id, g.version;`,
+			wantArgs: []interface{}{
+				uint64(1),
+				"New goal",
+				"percentage",
+				float64(80),
+				(*time.Time)(nil),
+				(*time.Time)(nil),
+				&deadline,
+				time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC),
+				uint64(0),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				got, gotArgs := buildUpdateGoalsQuery(tt.args.goals, tt.args.now)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
+			},
+		)
+	}
+}
+
+func TestGoalRepository_GetGoalByID(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx context.Context
+		id  uint64
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		want    habit_tracker.Goal
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+SELECT
+	"id", "description", "target_type", "target_value", "window_start", "window_end", "deadline", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	goals
+WHERE
+	id = $1
+	AND deleted_at IS NULL
+ORDER BY
+	id;`
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1).WillReturnRows(
+				sqlmock.NewRows([]string{"id", "description"}).AddRow(1, "New goal"),
+			)
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					id:  1,
+				},
+				want: habit_tracker.Goal{
+					ID:          1,
+					Description: "New goal",
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1).WillReturnError(assert.AnError)
+
+			return test{
+				name: "ErrorQueryContext",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					id:  1,
+				},
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				gr := NewGoalRepository(tt.fields.db)
+				got, err := gr.GetGoalByID(tt.args.ctx, tt.args.id)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestGoalRepository_ListGoals(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	"id", "description", "target_type", "target_value", "window_start", "window_end", "deadline", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	goals
+WHERE
+	deleted_at IS NULL
+ORDER BY
+	id
+LIMIT $1
+OFFSET $2;`)).WithArgs(2, 4).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "description"}).AddRow(5, "New goal").AddRow(6, "Other goal"),
+	)
+
+	gr := NewGoalRepository(&Postgres{db: db})
+	got, err := gr.ListGoals(
+		context.Background(),
+		habit_tracker.GoalFilter{
+			Page: habit_tracker.Page{
+				Limit:  2,
+				Offset: 4,
+			},
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, habit_tracker.Goals{{ID: 5, Description: "New goal"}, {ID: 6, Description: "Other goal"}}, got)
+}
+
+func TestGoalRepository_DeleteGoals(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
 UPDATE
 	goals
 SET
-	description = 'New goal',
-	updated_at = '2023-07-20T15:32:00Z'
+	deleted_at = $1,
+	updated_at = $1,
+	version = version + 1
+WHERE
+	id = ANY($2)
+	AND deleted_at IS NULL
+RETURNING
+	id;`)).WithArgs(now, "{1}").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	gr := NewGoalRepository(&Postgres{db: db})
+
+	assert.NoError(t, gr.DeleteGoals(context.Background(), []uint64{1}, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGoalRepository_PurgeGoals(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+DELETE
+FROM
+	habit_goals
 WHERE
-	id = 1;`,
+	goal_id = ANY($1);`)).WithArgs("{1}").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`
+DELETE
+FROM
+	goals
+WHERE
+	id = ANY($1)
+RETURNING
+	id;`)).WithArgs("{1}").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	gr := NewGoalRepository(&Postgres{db: db})
+
+	assert.NoError(t, gr.PurgeGoals(context.Background(), []uint64{1}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGoalRepository_LinkHabits(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx        context.Context
+		habitGoals habit_tracker.HabitGoals
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+INSERT
+	INTO
+	habit_goals
+(habit_id, goal_id)
+VALUES ($1::INT, $2::INT), ($3::INT, $4::INT)
+ON CONFLICT DO NOTHING;`
+	habitGoals := habit_tracker.HabitGoals{
+		{
+			HabitID: 1,
+			GoalID:  3,
+		},
+		{
+			HabitID: 2,
+			GoalID:  3,
 		},
 	}
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(1, 3, 2, 3).WillReturnResult(sqlmock.NewResult(0, 2))
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:        context.Background(),
+					habitGoals: habitGoals,
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(1, 3, 2, 3).WillReturnError(assert.AnError)
+
+			return test{
+				name: "ErrorExecContext",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:        context.Background(),
+					habitGoals: habitGoals,
+				},
+				wantErr: assert.Error,
+			}
+		}(),
+	}
 	for _, tt := range tests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				assert.Equal(t, tt.want, buildUpdateGoalsQuery(tt.args.goals, tt.args.now))
+				gr := NewGoalRepository(tt.fields.db)
+
+				tt.wantErr(t, gr.LinkHabits(tt.args.ctx, tt.args.habitGoals))
 			},
 		)
 	}
 }
+
+func TestGoalRepository_UnlinkHabits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta(`
+DELETE
+FROM
+	habit_goals AS hg
+USING
+	(VALUES ($1::INT, $2::INT)) AS v(habit_id, goal_id)
+WHERE
+	hg.habit_id = v.habit_id
+	AND hg.goal_id = v.goal_id;`)).WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	gr := NewGoalRepository(&Postgres{db: db})
+
+	assert.NoError(t, gr.UnlinkHabits(context.Background(), habit_tracker.HabitGoals{{HabitID: 1, GoalID: 3}}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGoalRepository_ListHabitIDsByGoalIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	hg.habit_id, hg.goal_id
+FROM
+	habit_goals AS hg
+	JOIN habits AS h ON h.id = hg.habit_id
+WHERE
+	hg.goal_id = ANY($1)
+	AND h.deleted_at IS NULL
+ORDER BY
+	hg.goal_id, hg.habit_id;`)).WithArgs("{3,4}").WillReturnRows(
+		sqlmock.NewRows([]string{"habit_id", "goal_id"}).AddRow(1, 3).AddRow(2, 3).AddRow(2, 4),
+	)
+
+	gr := NewGoalRepository(&Postgres{db: db})
+	got, err := gr.ListHabitIDsByGoalIDs(context.Background(), []uint64{3, 4})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint64][]uint64{3: {1, 2}, 4: {2}}, got)
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"habit-tracker"
 )
 
@@ -21,9 +23,27 @@ func NewHabitRepository(db Drivers) habit_tracker.HabitRepository {
 
 func (hr *HabitRepository) InsertHabits(ctx context.Context,
 	habits habit_tracker.Habits, now time.Time) error {
-	_, err := hr.db.ExecContext(ctx, buildInsertHabitsQuery(habits, now))
+	if len(habits) == 0 {
+		return nil
+	}
+
+	err := habits.Validate()
 	if err != nil {
-		return fmt.Errorf("[err:%w]", err)
+		return err
+	}
+
+	query, args := buildInsertHabitsQuery(habits, now)
+	inserted, err := execInsert[insertedHabitRow](ctx, hr.db, query, args, len(habits))
+	if err != nil {
+		return classifyError(habitsTable, err)
+	}
+
+	for i, row := range inserted {
+		habits[i].ID = row.ID
+		habits[i].CreatedAt = row.CreatedAt
+		habits[i].UpdatedAt = row.UpdatedAt
+		habits[i].Version = row.Version
+		habits[i].Status = row.Status
 	}
 
 	return nil
@@ -31,9 +51,21 @@ func (hr *HabitRepository) InsertHabits(ctx context.Context,
 
 func (hr *HabitRepository) InsertHabitCategories(ctx context.Context,
 	habitCategories habit_tracker.HabitCategories, now time.Time) error {
-	_, err := hr.db.ExecContext(ctx, buildInsertHabitCategoriesQuery(habitCategories, now))
+	if len(habitCategories) == 0 {
+		return nil
+	}
+
+	query, args := buildInsertHabitCategoriesQuery(habitCategories, now)
+	inserted, err := execInsert[insertedRow](ctx, hr.db, query, args, len(habitCategories))
 	if err != nil {
-		return fmt.Errorf("[err:%w]", err)
+		return classifyError(habitCategoriesTable, err)
+	}
+
+	for i, row := range inserted {
+		habitCategories[i].ID = row.ID
+		habitCategories[i].CreatedAt = row.CreatedAt
+		habitCategories[i].UpdatedAt = row.UpdatedAt
+		habitCategories[i].Version = row.Version
 	}
 
 	return nil
@@ -41,71 +73,591 @@ func (hr *HabitRepository) InsertHabitCategories(ctx context.Context,
 
 func (hr *HabitRepository) InsertHabitRecords(ctx context.Context,
 	habitRecords habit_tracker.HabitRecords, now time.Time) error {
-	_, err := hr.db.ExecContext(ctx, buildInsertHabitRecordsQuery(habitRecords, now))
+	if len(habitRecords) == 0 {
+		return nil
+	}
+
+	err := habitRecords.Validate()
 	if err != nil {
-		return fmt.Errorf("[err:%w]", err)
+		return err
+	}
+
+	query, args := buildInsertHabitRecordsQuery(habitRecords, now)
+	inserted, err := execInsert[insertedRow](ctx, hr.db, query, args, len(habitRecords))
+	if err != nil {
+		return classifyError(habitRecordsTable, err)
+	}
+
+	for i, row := range inserted {
+		habitRecords[i].ID = row.ID
+		habitRecords[i].CreatedAt = row.CreatedAt
+		habitRecords[i].UpdatedAt = row.UpdatedAt
+		habitRecords[i].Version = row.Version
 	}
 
 	return nil
 }
 
+func (hr *HabitRepository) LoadHabitRecords(ctx context.Context,
+	habitRecords <-chan habit_tracker.HabitRecord, now time.Time) (int64, error) {
+	var received int
+	loaded, err := execCopy(
+		ctx, hr.db, habitRecordsTable, copyHabitRecordsColumns, habitRecords,
+		func(record habit_tracker.HabitRecord) ([]interface{}, error) {
+			// Records are validated as received, as they can't all be held
+			// before the COPY starts.
+			err := habit_tracker.HabitRecords{record}.ValidateFrom(received)
+			if err != nil {
+				return nil, err
+			}
+
+			received++
+
+			return []interface{}{
+				record.HabitID, record.RecordDate.UTC(), record.Result, record.Value, record.Unit, record.Description, now, now,
+			}, nil
+		},
+	)
+	if err != nil {
+		return 0, classifyError(habitRecordsTable, err)
+	}
+
+	return loaded, nil
+}
+
+func (hr *HabitRepository) UpsertHabitRecords(ctx context.Context, habitRecords habit_tracker.HabitRecords,
+	mode habit_tracker.UpsertMode, now time.Time) ([]bool, error) {
+	if len(habitRecords) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := buildUpsertHabitRecordsQuery(habitRecords, mode, now)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := hr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, classifyError(habitRecordsTable, err)
+	}
+
+	upserted, err := scanRows[upsertedRow](rows)
+	if err != nil {
+		return nil, classifyError(habitRecordsTable, err)
+	}
+
+	if len(upserted) != len(habitRecords) {
+		return nil, fmt.Errorf(
+			"[%s][upserted:%d][expected:%d][err:unexpected returned rows]",
+			habitRecordsTable, len(upserted), len(habitRecords),
+		)
+	}
+
+	created := make([]bool, len(upserted))
+	for i, row := range upserted {
+		habitRecords[i].ID = row.ID
+		habitRecords[i].CreatedAt = row.CreatedAt
+		habitRecords[i].UpdatedAt = row.UpdatedAt
+		habitRecords[i].Version = row.Version
+		created[i] = row.Created
+	}
+
+	return created, nil
+}
+
 func (hr *HabitRepository) UpdateHabits(ctx context.Context,
-	habits habit_tracker.Habits, now time.Time) error {
-	return nil
+	habits habit_tracker.Habits, now time.Time) (int64, error) {
+	if len(habits) == 0 {
+		return 0, nil
+	}
+
+	err := habits.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]uint64, len(habits))
+	for i, habit := range habits {
+		ids[i] = habit.ID
+	}
+
+	query, args := buildUpdateHabitsQuery(habits, now)
+	versions, err := execVersionedUpdate(ctx, hr.db, habitsTable, ids, query, args)
+	if err != nil {
+		return 0, classifyError(habitsTable, err, ids...)
+	}
+
+	for i := range habits {
+		habits[i].Version = versions[habits[i].ID]
+	}
+
+	return int64(len(versions)), nil
 }
 
 func (hr *HabitRepository) UpdateHabitCategories(ctx context.Context,
-	habitCategories habit_tracker.HabitCategories, now time.Time) error {
-	return nil
+	habitCategories habit_tracker.HabitCategories, now time.Time) (int64, error) {
+	if len(habitCategories) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uint64, len(habitCategories))
+	for i, category := range habitCategories {
+		ids[i] = category.ID
+	}
+
+	query, args := buildUpdateHabitCategoriesQuery(habitCategories, now)
+	versions, err := execVersionedUpdate(ctx, hr.db, habitCategoriesTable, ids, query, args)
+	if err != nil {
+		return 0, classifyError(habitCategoriesTable, err, ids...)
+	}
+
+	for i := range habitCategories {
+		habitCategories[i].Version = versions[habitCategories[i].ID]
+	}
+
+	return int64(len(versions)), nil
 }
 
 func (hr *HabitRepository) UpdateHabitRecords(ctx context.Context,
-	habitRecords habit_tracker.HabitRecords, now time.Time) error {
+	habitRecords habit_tracker.HabitRecords, now time.Time) (int64, error) {
+	if len(habitRecords) == 0 {
+		return 0, nil
+	}
+
+	err := habitRecords.Validate()
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]uint64, len(habitRecords))
+	for i, record := range habitRecords {
+		ids[i] = record.ID
+	}
+
+	query, args := buildUpdateHabitRecordsQuery(habitRecords, now)
+	versions, err := execVersionedUpdate(ctx, hr.db, habitRecordsTable, ids, query, args)
+	if err != nil {
+		return 0, classifyError(habitRecordsTable, err, ids...)
+	}
+
+	for i := range habitRecords {
+		habitRecords[i].Version = versions[habitRecords[i].ID]
+	}
+
+	return int64(len(versions)), nil
+}
+
+func (hr *HabitRepository) GetHabitByID(ctx context.Context, id uint64) (habit_tracker.Habit, error) {
+	habit, err := selectByID[habit_tracker.Habit](ctx, hr.db, habitsTable, id)
+	if err != nil {
+		return habit, classifyError(habitsTable, err, id)
+	}
+
+	return habit, nil
+}
+
+func (hr *HabitRepository) GetHabitsByIDs(ctx context.Context, ids []uint64) (habit_tracker.Habits, error) {
+	habits, err := selectByIDs[habit_tracker.Habit](ctx, hr.db, habitsTable, ids)
+	if err != nil {
+		return nil, classifyError(habitsTable, err, ids...)
+	}
+
+	return habits, nil
+}
+
+func (hr *HabitRepository) ListHabits(ctx context.Context,
+	filter habit_tracker.HabitFilter) (habit_tracker.Habits, error) {
+	habits, err := selectRows[habit_tracker.Habit](ctx, hr.db, habitsTable, buildHabitsWhere(filter), filter.Page)
+	if err != nil {
+		return nil, classifyError(habitsTable, err)
+	}
+
+	return habits, nil
+}
+
+func (hr *HabitRepository) GetHabitCategoryByID(ctx context.Context,
+	id uint64) (habit_tracker.HabitCategory, error) {
+	category, err := selectByID[habit_tracker.HabitCategory](ctx, hr.db, habitCategoriesTable, id)
+	if err != nil {
+		return category, classifyError(habitCategoriesTable, err, id)
+	}
+
+	return category, nil
+}
+
+func (hr *HabitRepository) GetHabitCategoriesByIDs(ctx context.Context,
+	ids []uint64) (habit_tracker.HabitCategories, error) {
+	categories, err := selectByIDs[habit_tracker.HabitCategory](ctx, hr.db, habitCategoriesTable, ids)
+	if err != nil {
+		return nil, classifyError(habitCategoriesTable, err, ids...)
+	}
+
+	return categories, nil
+}
+
+func (hr *HabitRepository) ListHabitCategories(ctx context.Context,
+	filter habit_tracker.HabitCategoryFilter) (habit_tracker.HabitCategories, error) {
+	categories, err := selectRows[habit_tracker.HabitCategory](
+		ctx, hr.db, habitCategoriesTable, buildHabitCategoriesWhere(filter), filter.Page,
+	)
+	if err != nil {
+		return nil, classifyError(habitCategoriesTable, err)
+	}
+
+	return categories, nil
+}
+
+func (hr *HabitRepository) GetHabitRecordByID(ctx context.Context, id uint64) (habit_tracker.HabitRecord, error) {
+	record, err := selectByID[habit_tracker.HabitRecord](ctx, hr.db, habitRecordsTable, id)
+	if err != nil {
+		return record, classifyError(habitRecordsTable, err, id)
+	}
+
+	return record, nil
+}
+
+func (hr *HabitRepository) GetHabitRecordsByIDs(ctx context.Context,
+	ids []uint64) (habit_tracker.HabitRecords, error) {
+	records, err := selectByIDs[habit_tracker.HabitRecord](ctx, hr.db, habitRecordsTable, ids)
+	if err != nil {
+		return nil, classifyError(habitRecordsTable, err, ids...)
+	}
+
+	return records, nil
+}
+
+func (hr *HabitRepository) ListHabitRecords(ctx context.Context,
+	filter habit_tracker.HabitRecordFilter) (habit_tracker.HabitRecords, error) {
+	records, err := selectRows[habit_tracker.HabitRecord](
+		ctx, hr.db, habitRecordsTable, buildHabitRecordsWhere(filter), filter.Page,
+	)
+	if err != nil {
+		return nil, classifyError(habitRecordsTable, err)
+	}
+
+	return records, nil
+}
+
+func (hr *HabitRepository) DeleteHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execSoftDelete(ctx, hr.db, habitsTable, ids, now)
+	if err != nil {
+		return classifyError(habitsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) RestoreHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execRestore(ctx, hr.db, habitsTable, ids, now)
+	if err != nil {
+		return classifyError(habitsTable, err, ids...)
+	}
+
 	return nil
 }
 
-func buildInsertHabitsQuery(habits habit_tracker.Habits, now time.Time) string {
-	str := strings.Builder{}
-	nowStr := now.Format(time.RFC3339)
+func (hr *HabitRepository) PauseHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execStatusChange(ctx, hr.db, ids, pauseHabitsQuery, now)
+	if err != nil {
+		return classifyError(habitsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) ResumeHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execStatusChange(ctx, hr.db, ids, resumeHabitsQuery, now)
+	if err != nil {
+		return classifyError(habitsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) ArchiveHabits(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execStatusChange(ctx, hr.db, ids, archiveHabitsQuery, now)
+	if err != nil {
+		return classifyError(habitsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) ListHabitPauses(ctx context.Context,
+	habitIDs []uint64) (map[uint64]habit_tracker.HabitPauses, error) {
+	pauses := make(map[uint64]habit_tracker.HabitPauses)
+	if len(habitIDs) == 0 {
+		return pauses, nil
+	}
+
+	rows, err := hr.db.QueryContext(ctx, buildListHabitPausesQuery(), pq.Array(int64s(habitIDs)))
+	if err != nil {
+		return nil, classifyError(habitPausesTable, err, habitIDs...)
+	}
+
+	habitPauses, err := scanRows[habit_tracker.HabitPause](rows)
+	if err != nil {
+		return nil, classifyError(habitPausesTable, err, habitIDs...)
+	}
+
+	for _, pause := range habitPauses {
+		pauses[pause.HabitID] = append(pauses[pause.HabitID], pause)
+	}
+
+	return pauses, nil
+}
+
+func (hr *HabitRepository) PurgeHabits(ctx context.Context, ids []uint64) error {
+	err := execPurge(ctx, hr.db, habitsTable, ids, buildPurgeHabitDependentsQueries(purgeByHabitQuery)...)
+	if err != nil {
+		return classifyError(habitsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) DeleteHabitCategories(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execSoftDelete(ctx, hr.db, habitCategoriesTable, ids, now)
+	if err != nil {
+		return classifyError(habitCategoriesTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) RestoreHabitCategories(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execRestore(ctx, hr.db, habitCategoriesTable, ids, now)
+	if err != nil {
+		return classifyError(habitCategoriesTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) PurgeHabitCategories(ctx context.Context, ids []uint64) error {
+	err := execPurge(ctx, hr.db, habitCategoriesTable, ids, append(buildPurgeHabitDependentsQueries(purgeByCategoryQuery), purgeHabitsByCategoryQuery)...)
+	if err != nil {
+		return classifyError(habitCategoriesTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) DeleteHabitRecords(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execSoftDelete(ctx, hr.db, habitRecordsTable, ids, now)
+	if err != nil {
+		return classifyError(habitRecordsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) RestoreHabitRecords(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execRestore(ctx, hr.db, habitRecordsTable, ids, now)
+	if err != nil {
+		return classifyError(habitRecordsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (hr *HabitRepository) PurgeHabitRecords(ctx context.Context, ids []uint64) error {
+	err := execPurge(ctx, hr.db, habitRecordsTable, ids)
+	if err != nil {
+		return classifyError(habitRecordsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func buildInsertHabitsQuery(habits habit_tracker.Habits, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(habits)*10)
 	for _, habit := range habits {
-		str.WriteString(
-			fmt.Sprintf(
-				"(%d, '%s', '%s', '%s', '%s')", habit.CategoryID, habit.Name, habit.Description, nowStr, nowStr,
-			),
+		args = append(
+			args, habit.CategoryID, habit.Name, habit.Description, habit.Schedule, habit.TargetValue, habit.TargetUnit,
+			habit.TargetComparison, habit.TargetPeriod, now, now,
 		)
 	}
 
-	return fmt.Sprintf(insertHabitsQuery, strings.TrimSuffix(str.String(), ", "))
+	return fmt.Sprintf(insertHabitsQuery, buildValues(len(habits), 10)), args
 }
 
-func buildInsertHabitCategoriesQuery(categories habit_tracker.HabitCategories, now time.Time) string {
-	str := strings.Builder{}
-	nowStr := now.Format(time.RFC3339)
+func buildInsertHabitCategoriesQuery(categories habit_tracker.HabitCategories, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(categories)*3)
 	for _, category := range categories {
-		str.WriteString(
-			fmt.Sprintf("('%s', '%s', '%s')", category.CategoryName, nowStr, nowStr),
+		args = append(args, category.CategoryName, now, now)
+	}
+
+	return fmt.Sprintf(insertHabitCategoriesQuery, buildValues(len(categories), 3)), args
+}
+
+func buildInsertHabitRecordsQuery(records habit_tracker.HabitRecords, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(records)*8)
+	for _, record := range records {
+		args = append(
+			args, record.HabitID, record.RecordDate.UTC(), record.Result, record.Value, record.Unit, record.Description, now, now,
+		)
+	}
+
+	return fmt.Sprintf(insertHabitRecordsQuery, buildValues(len(records), 8)), args
+}
+
+// buildUpsertHabitRecordsQuery rejects the batches with two records of a
+// habit on the same day, as ON CONFLICT can't update a row twice.
+func buildUpsertHabitRecordsQuery(records habit_tracker.HabitRecords, mode habit_tracker.UpsertMode,
+	now time.Time) (string, []interface{}, error) {
+	set, ok := upsertHabitRecordsSets[mode]
+	if !ok {
+		return "", nil, &habit_tracker.Error{
+			Kind:   habit_tracker.ErrValidation,
+			Entity: habitRecordsTable,
+			Err:    fmt.Errorf("unknown upsert mode %d", mode),
+		}
+	}
+
+	validate := records.Validate
+	if mode == habit_tracker.UpsertMerge {
+		validate = records.ValidateMerge
+	}
+
+	err := validate()
+	if err != nil {
+		return "", nil, err
+	}
+
+	days := make(map[recordDay]bool, len(records))
+	args := make([]interface{}, 0, len(records)*8)
+	for _, record := range records {
+		day := dayOf(record)
+		if days[day] {
+			return "", nil, &habit_tracker.Error{
+				Kind:   habit_tracker.ErrValidation,
+				Entity: habitRecordsTable,
+				Err:    fmt.Errorf("two records of habit %d on %s", day.habitID, day.date),
+			}
+		}
+		days[day] = true
+
+		args = append(
+			args, record.HabitID, record.RecordDate.UTC(), record.Result, record.Value, record.Unit, record.Description, now, now,
 		)
 	}
 
-	return fmt.Sprintf(insertHabitCategoriesQuery, strings.TrimSuffix(str.String(), ", "))
+	return fmt.Sprintf(upsertHabitRecordsQuery, buildValues(len(records), 8), set), args, nil
+}
+
+// recordDay identifies a live habit record, which is unique by habit and
+// calendar day in UTC, as stored.
+type recordDay struct {
+	habitID uint64
+	date    string
+}
+
+func dayOf(record habit_tracker.HabitRecord) recordDay {
+	return recordDay{
+		habitID: record.HabitID,
+		date:    record.RecordDate.UTC().Format(time.DateOnly),
+	}
+}
+
+func buildUpdateHabitsQuery(habits habit_tracker.Habits, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(habits)*len(updateHabitsTypes))
+	for _, habit := range habits {
+		args = append(
+			args, habit.ID, habit.CategoryID, habit.Name, habit.Description, habit.Schedule, habit.TargetValue,
+			habit.TargetUnit, habit.TargetComparison, habit.TargetPeriod, now, habit.Version,
+		)
+	}
+
+	return fmt.Sprintf(updateHabitsQuery, buildTypedValues(len(habits), updateHabitsTypes)), args
+}
+
+func buildUpdateHabitCategoriesQuery(categories habit_tracker.HabitCategories,
+	now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(categories)*len(updateHabitCategoriesTypes))
+	for _, category := range categories {
+		args = append(args, category.ID, category.CategoryName, now, category.Version)
+	}
+
+	return fmt.Sprintf(updateHabitCategoriesQuery, buildTypedValues(len(categories), updateHabitCategoriesTypes)), args
 }
 
-func buildInsertHabitRecordsQuery(records habit_tracker.HabitRecords, now time.Time) string {
-	str := strings.Builder{}
-	nowStr := now.Format(time.RFC3339)
+func buildUpdateHabitRecordsQuery(records habit_tracker.HabitRecords, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(records)*len(updateHabitRecordsTypes))
 	for _, record := range records {
-		str.WriteString(
-			fmt.Sprintf(
-				"(%d, '%s', '%s', '%s', '%s', '%s')",
-				record.HabitID,
-				record.RecordDate.Format(time.RFC3339),
-				record.Result,
-				record.Description,
-				nowStr,
-				nowStr,
-			),
+		args = append(
+			args, record.ID, record.HabitID, record.RecordDate.UTC(), record.Result, record.Value, record.Unit, record.Description,
+			now, record.Version,
 		)
 	}
 
-	return fmt.Sprintf(insertHabitRecordsQuery, strings.TrimSuffix(str.String(), ", "))
+	return fmt.Sprintf(updateHabitRecordsQuery, buildTypedValues(len(records), updateHabitRecordsTypes)), args
+}
+
+func buildHabitsWhere(filter habit_tracker.HabitFilter) whereClause {
+	where := whereClause{}
+	if filter.CategoryID > 0 {
+		where.add("category_id = $%d", filter.CategoryID)
+	}
+
+	if filter.TagID > 0 {
+		where.add("id IN (SELECT habit_id FROM habit_tags WHERE tag_id = $%d)", filter.TagID)
+	}
+
+	where.addPrefix("name", filter.NamePrefix)
+
+	if !filter.IncludeDeleted {
+		where.notDeleted()
+	}
+
+	return where
+}
+
+func buildHabitCategoriesWhere(filter habit_tracker.HabitCategoryFilter) whereClause {
+	where := whereClause{}
+	where.addPrefix("category_name", filter.NamePrefix)
+
+	if !filter.IncludeDeleted {
+		where.notDeleted()
+	}
+
+	return where
+}
+
+func buildHabitRecordsWhere(filter habit_tracker.HabitRecordFilter) whereClause {
+	where := whereClause{}
+	if filter.HabitID > 0 {
+		where.add("habit_id = $%d", filter.HabitID)
+	}
+
+	// record_date has no time zone: the bounds are compared in UTC, as the
+	// records are stored.
+	if !filter.From.IsZero() {
+		where.add("record_date >= $%d", filter.From.UTC())
+	}
+
+	if !filter.To.IsZero() {
+		where.add("record_date < $%d", filter.To.UTC())
+	}
+
+	if !filter.IncludeDeleted {
+		where.notDeleted()
+	}
+
+	return where
+}
+
+func buildListHabitPausesQuery() string {
+	return fmt.Sprintf(
+		listHabitPausesQuery, strings.Join(quoteColumns(columnsOf[habit_tracker.HabitPause]()), ", "),
+	)
+}
+
+func buildPurgeHabitDependentsQueries(query string) []string {
+	queries := make([]string, len(habitDependentTables))
+	for i, table := range habitDependentTables {
+		queries[i] = fmt.Sprintf(query, table)
+	}
+
+	return queries
 }
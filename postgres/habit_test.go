@@ -2,11 +2,13 @@ package postgres
 
 import (
 	"context"
+	"database/sql/driver"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 
 	"habit-tracker"
@@ -32,15 +34,29 @@ func TestHabitRepository_InsertHabits(t *testing.T) {
 INSERT
 	INTO
 	habits
-(category_id, "name", description, created_at, updated_at)
-VALUES (1, 'Exercise', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12:00:00Z');`
+(category_id, "name", description, schedule, target_value, target_unit, target_comparison, target_period, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING
+	id, created_at, updated_at, version, status;`
+	queryArgs := []driver.Value{
+		int64(1),
+		"Exercise",
+		"New description",
+		`{"kind":"weekdays","weekdays":[1,4]}`,
+		float64(30),
+		"pages",
+		"at_least",
+		"day",
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+	}
 
 	tests := []test{
 		func() test {
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(insertedHabitRows(1))
 
 			return test{
 				name: "Success",
@@ -56,6 +72,14 @@ VALUES (1, 'Exercise', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12
 							CategoryID:  1,
 							Name:        "Exercise",
 							Description: "New description",
+							Schedule: habit_tracker.Schedule{
+								Kind:     habit_tracker.ScheduleWeekdays,
+								Weekdays: []time.Weekday{time.Monday, time.Thursday},
+							},
+							TargetValue:      30,
+							TargetUnit:       "pages",
+							TargetComparison: habit_tracker.TargetAtLeast,
+							TargetPeriod:     habit_tracker.TargetPerDay,
 						},
 					},
 					now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
@@ -67,7 +91,7 @@ VALUES (1, 'Exercise', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(assert.AnError)
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
 
 			return test{
 				name: "ErrorExecContext",
@@ -83,6 +107,14 @@ VALUES (1, 'Exercise', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12
 							CategoryID:  1,
 							Name:        "Exercise",
 							Description: "New description",
+							Schedule: habit_tracker.Schedule{
+								Kind:     habit_tracker.ScheduleWeekdays,
+								Weekdays: []time.Weekday{time.Monday, time.Thursday},
+							},
+							TargetValue:      30,
+							TargetUnit:       "pages",
+							TargetComparison: habit_tracker.TargetAtLeast,
+							TargetPeriod:     habit_tracker.TargetPerDay,
 						},
 					},
 					now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
@@ -90,6 +122,61 @@ VALUES (1, 'Exercise', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12
 				wantErr: assert.Error,
 			}
 		}(),
+		func() test {
+			db, _, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			return test{
+				name: "ErrorSchedule",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					habits: habit_tracker.Habits{
+						{
+							CategoryID: 1,
+							Name:       "Exercise",
+							Schedule:   habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 8},
+						},
+					},
+					now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrValidation, i...)
+				},
+			}
+		}(),
+		func() test {
+			db, _, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			return test{
+				name: "ErrorTarget",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					habits: habit_tracker.Habits{
+						{
+							CategoryID:       1,
+							Name:             "Read",
+							TargetValue:      30,
+							TargetComparison: "about",
+						},
+					},
+					now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrValidation, i...)
+				},
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(
@@ -124,14 +211,21 @@ INSERT
 	INTO
 	habit_categories
 (category_name, created_at, updated_at)
-VALUES ('Health', '2023-07-30T12:00:00Z', '2023-07-30T12:00:00Z');`
+VALUES ($1, $2, $3)
+RETURNING
+	id, created_at, updated_at, version;`
+	queryArgs := []driver.Value{
+		"Health",
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+	}
 
 	tests := []test{
 		func() test {
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(insertedRows(1))
 
 			return test{
 				name: "Success",
@@ -156,7 +250,7 @@ VALUES ('Health', '2023-07-30T12:00:00Z', '2023-07-30T12:00:00Z');`
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(assert.AnError)
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
 
 			return test{
 				name: "ErrorExecContext",
@@ -211,15 +305,27 @@ func TestHabitRepository_InsertHabitRecords(t *testing.T) {
 INSERT
 	INTO
 	habit_records
-(habit_id, record_date, "result", description, created_at, updated_at)
-VALUES (1, '2023-07-30T12:00:00Z', 'Success', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12:00:00Z');`
+(habit_id, record_date, "result", value, unit, description, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING
+	id, created_at, updated_at, version;`
+	queryArgs := []driver.Value{
+		int64(1),
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+		"done",
+		nil,
+		"",
+		"New description",
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+	}
 
 	tests := []test{
 		func() test {
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(insertedRows(1))
 
 			return test{
 				name: "Success",
@@ -234,7 +340,7 @@ VALUES (1, '2023-07-30T12:00:00Z', 'Success', 'New description', '2023-07-30T12:
 						{
 							HabitID:     1,
 							RecordDate:  time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
-							Result:      "Success",
+							Result:      habit_tracker.ResultDone,
 							Description: "New description",
 						},
 					},
@@ -247,7 +353,7 @@ VALUES (1, '2023-07-30T12:00:00Z', 'Success', 'New description', '2023-07-30T12:
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(assert.AnError)
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
 
 			return test{
 				name: "ErrorExecContext",
@@ -262,7 +368,7 @@ VALUES (1, '2023-07-30T12:00:00Z', 'Success', 'New description', '2023-07-30T12:
 						{
 							HabitID:     1,
 							RecordDate:  time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
-							Result:      "Success",
+							Result:      habit_tracker.ResultDone,
 							Description: "New description",
 						},
 					},
@@ -271,6 +377,34 @@ VALUES (1, '2023-07-30T12:00:00Z', 'Success', 'New description', '2023-07-30T12:
 				wantErr: assert.Error,
 			}
 		}(),
+		func() test {
+			db, _, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			return test{
+				name: "ErrorValidation",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					habitRecords: habit_tracker.HabitRecords{
+						{
+							HabitID:    1,
+							RecordDate: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+							Result:     "8",
+							Unit:       "glasses",
+						},
+					},
+					now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrValidation, i...)
+				},
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(
@@ -291,9 +425,10 @@ func Test_buildInsertHabitsQuery(t *testing.T) {
 		now  time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			name: "Success",
@@ -311,15 +446,80 @@ func Test_buildInsertHabitsQuery(t *testing.T) {
 INSERT
 	INTO
 	habits
-(category_id, "name", description, created_at, updated_at)
-VALUES (1, 'Exercise', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12:00:00Z');`,
+(category_id, "name", description, schedule, target_value, target_unit, target_comparison, target_period, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING
+	id, created_at, updated_at, version, status;`,
+			wantArgs: []interface{}{
+				uint64(1),
+				"Exercise",
+				"New description",
+				habit_tracker.Schedule{},
+				float64(0),
+				"",
+				habit_tracker.TargetComparison(""),
+				habit_tracker.TargetPeriod(""),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "MultipleRows",
+			args: args{
+				tags: habit_tracker.Habits{
+					{
+						CategoryID:  1,
+						Name:        "Mom's run",
+						Description: "Run with mom",
+					},
+					{
+						CategoryID:  2,
+						Name:        "Read",
+						Description: "Read 10 pages",
+					},
+				},
+				now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+			want: `
+INSERT
+	INTO
+	habits
+(category_id, "name", description, schedule, target_value, target_unit, target_comparison, target_period, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10), ($11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+RETURNING
+	id, created_at, updated_at, version, status;`,
+			wantArgs: []interface{}{
+				uint64(1),
+				"Mom's run",
+				"Run with mom",
+				habit_tracker.Schedule{},
+				float64(0),
+				"",
+				habit_tracker.TargetComparison(""),
+				habit_tracker.TargetPeriod(""),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				uint64(2),
+				"Read",
+				"Read 10 pages",
+				habit_tracker.Schedule{},
+				float64(0),
+				"",
+				habit_tracker.TargetComparison(""),
+				habit_tracker.TargetPeriod(""),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				assert.Equal(t, tt.want, buildInsertHabitsQuery(tt.args.tags, tt.args.now))
+				got, gotArgs := buildInsertHabitsQuery(tt.args.tags, tt.args.now)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
 			},
 		)
 	}
@@ -331,9 +531,10 @@ func Test_buildInsertHabitCategoriesQuery(t *testing.T) {
 		now        time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			name: "Success",
@@ -350,14 +551,54 @@ INSERT
 	INTO
 	habit_categories
 (category_name, created_at, updated_at)
-VALUES ('Health', '2023-07-30T12:00:00Z', '2023-07-30T12:00:00Z');`,
+VALUES ($1, $2, $3)
+RETURNING
+	id, created_at, updated_at, version;`,
+			wantArgs: []interface{}{
+				"Health",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "MultipleRows",
+			args: args{
+				categories: habit_tracker.HabitCategories{
+					{
+						CategoryName: "Health",
+					},
+					{
+						CategoryName: "Mind's care",
+					},
+				},
+				now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+			want: `
+INSERT
+	INTO
+	habit_categories
+(category_name, created_at, updated_at)
+VALUES ($1, $2, $3), ($4, $5, $6)
+RETURNING
+	id, created_at, updated_at, version;`,
+			wantArgs: []interface{}{
+				"Health",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				"Mind's care",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				assert.Equal(t, tt.want, buildInsertHabitCategoriesQuery(tt.args.categories, tt.args.now))
+				got, gotArgs := buildInsertHabitCategoriesQuery(tt.args.categories, tt.args.now)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
 			},
 		)
 	}
@@ -369,9 +610,10 @@ func Test_buildInsertHabitRecordsQuery(t *testing.T) {
 		now     time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			name: "Success",
@@ -380,7 +622,7 @@ func Test_buildInsertHabitRecordsQuery(t *testing.T) {
 					{
 						HabitID:     1,
 						RecordDate:  time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
-						Result:      "Success",
+						Result:      habit_tracker.ResultDone,
 						Description: "New description",
 					},
 				},
@@ -390,16 +632,1612 @@ func Test_buildInsertHabitRecordsQuery(t *testing.T) {
 INSERT
 	INTO
 	habit_records
-(habit_id, record_date, "result", description, created_at, updated_at)
-VALUES (1, '2023-07-30T12:00:00Z', 'Success', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12:00:00Z');`,
+(habit_id, record_date, "result", value, unit, description, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING
+	id, created_at, updated_at, version;`,
+			wantArgs: []interface{}{
+				uint64(1),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				habit_tracker.ResultDone,
+				(*float64)(nil),
+				"",
+				"New description",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "MultipleRows",
+			args: args{
+				records: habit_tracker.HabitRecords{
+					{
+						HabitID:     1,
+						RecordDate:  time.Date(2023, 7, 29, 12, 0, 0, 0, time.UTC),
+						Result:      habit_tracker.ResultDone,
+						Description: "Didn't stop",
+					},
+					{
+						HabitID:     1,
+						RecordDate:  time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+						Result:      habit_tracker.ResultMissed,
+						Description: "Too tired",
+					},
+				},
+				now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+			want: `
+INSERT
+	INTO
+	habit_records
+(habit_id, record_date, "result", value, unit, description, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8), ($9, $10, $11, $12, $13, $14, $15, $16)
+RETURNING
+	id, created_at, updated_at, version;`,
+			wantArgs: []interface{}{
+				uint64(1),
+				time.Date(2023, 7, 29, 12, 0, 0, 0, time.UTC),
+				habit_tracker.ResultDone,
+				(*float64)(nil),
+				"",
+				"Didn't stop",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				uint64(1),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				habit_tracker.ResultMissed,
+				(*float64)(nil),
+				"",
+				"Too tired",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				got, gotArgs := buildInsertHabitRecordsQuery(tt.args.records, tt.args.now)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
+			},
+		)
+	}
+}
+
+func Test_buildUpsertHabitRecordsQuery(t *testing.T) {
+	type args struct {
+		records habit_tracker.HabitRecords
+		mode    habit_tracker.UpsertMode
+		now     time.Time
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "SuccessReplace",
+			args: args{
+				records: habit_tracker.HabitRecords{
+					{
+						HabitID:     1,
+						RecordDate:  time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+						Result:      habit_tracker.ResultDone,
+						Description: "New description",
+					},
+				},
+				mode: habit_tracker.UpsertReplace,
+				now:  time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+			want: `
+INSERT
+	INTO
+	habit_records
+(habit_id, record_date, "result", value, unit, description, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (habit_id, (record_date::date)) WHERE deleted_at IS NULL
+DO UPDATE
+SET
+	record_date = EXCLUDED.record_date,
+	"result" = EXCLUDED."result",
+	value = EXCLUDED.value,
+	unit = EXCLUDED.unit,
+	description = EXCLUDED.description,
+	updated_at = EXCLUDED.updated_at,
+	version = habit_records.version + 1
+RETURNING
+	id, created_at, updated_at, version, (xmax = 0) AS created;`,
+			wantArgs: []interface{}{
+				uint64(1),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				habit_tracker.ResultDone,
+				(*float64)(nil),
+				"",
+				"New description",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "SuccessMerge",
+			args: args{
+				records: habit_tracker.HabitRecords{
+					{
+						HabitID:    1,
+						RecordDate: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+						Result:     habit_tracker.ResultDone,
+					},
+				},
+				mode: habit_tracker.UpsertMerge,
+				now:  time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+			want: `
+INSERT
+	INTO
+	habit_records
+(habit_id, record_date, "result", value, unit, description, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (habit_id, (record_date::date)) WHERE deleted_at IS NULL
+DO UPDATE
+SET
+	"result" = COALESCE(NULLIF(EXCLUDED."result", ''), habit_records."result"),
+	value = COALESCE(EXCLUDED.value, habit_records.value),
+	unit = COALESCE(NULLIF(EXCLUDED.unit, ''), habit_records.unit),
+	description = COALESCE(NULLIF(EXCLUDED.description, ''), habit_records.description),
+	updated_at = EXCLUDED.updated_at,
+	version = habit_records.version + 1
+RETURNING
+	id, created_at, updated_at, version, (xmax = 0) AS created;`,
+			wantArgs: []interface{}{
+				uint64(1),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				habit_tracker.ResultDone,
+				(*float64)(nil),
+				"",
+				"",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "ErrorSameDay",
+			args: args{
+				records: habit_tracker.HabitRecords{
+					{HabitID: 1, RecordDate: time.Date(2023, 7, 30, 8, 0, 0, 0, time.UTC)},
+					{HabitID: 1, RecordDate: time.Date(2023, 7, 30, 20, 0, 0, 0, time.UTC)},
+				},
+				mode: habit_tracker.UpsertReplace,
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, habit_tracker.ErrValidation, i...)
+			},
+		},
+		{
+			name: "ErrorUnknownMode",
+			args: args{
+				records: habit_tracker.HabitRecords{{HabitID: 1}},
+				mode:    habit_tracker.UpsertMode(9),
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, habit_tracker.ErrValidation, i...)
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				assert.Equal(t, tt.want, buildInsertHabitRecordsQuery(tt.args.records, tt.args.now))
+				got, gotArgs, err := buildUpsertHabitRecordsQuery(tt.args.records, tt.args.mode, tt.args.now)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
 			},
 		)
 	}
 }
+
+func TestHabitRepository_UpsertHabitRecords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+	records := habit_tracker.HabitRecords{
+		{HabitID: 1, RecordDate: time.Date(2023, 7, 29, 12, 0, 0, 0, time.UTC), Result: "done"},
+		{HabitID: 1, RecordDate: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC), Result: "done"},
+	}
+	createdAt := time.Date(2023, 7, 29, 12, 0, 1, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`ON CONFLICT (habit_id, (record_date::date)) WHERE deleted_at IS NULL`)).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "created_at", "updated_at", "created"}).
+				AddRow(4, createdAt, insertedAt, false).
+				AddRow(5, insertedAt, insertedAt, true),
+		)
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	created, err := hr.UpsertHabitRecords(context.Background(), records, habit_tracker.UpsertReplace, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, created)
+	assert.Equal(t, uint64(4), records[0].ID)
+	assert.Equal(t, createdAt, records[0].CreatedAt)
+	assert.Equal(t, insertedAt, records[0].UpdatedAt)
+	assert.Equal(t, uint64(5), records[1].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHabitRepository_GetHabitByID(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx context.Context
+		id  uint64
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		want    habit_tracker.Habit
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+SELECT
+	"id", "category_id", "name", "description", "schedule", "target_value", "target_unit", "target_comparison", "target_period", "status", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	habits
+WHERE
+	id = $1
+	AND deleted_at IS NULL
+ORDER BY
+	id;`
+	columns := []string{"id", "category_id", "name", "description", "schedule"}
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1).WillReturnRows(
+				sqlmock.NewRows(columns).AddRow(1, 2, "Exercise", "New description", []byte(`{"kind":"times_per_week","times":3}`)),
+			)
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					id:  1,
+				},
+				want: habit_tracker.Habit{
+					ID:          1,
+					CategoryID:  2,
+					Name:        "Exercise",
+					Description: "New description",
+					Schedule:    habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 3},
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1).WillReturnRows(sqlmock.NewRows(columns))
+
+			return test{
+				name: "NotFound",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					id:  1,
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrNotFound, i...)
+				},
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1).WillReturnError(assert.AnError)
+
+			return test{
+				name: "ErrorQueryContext",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					id:  1,
+				},
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				hr := NewHabitRepository(tt.fields.db)
+				got, err := hr.GetHabitByID(tt.args.ctx, tt.args.id)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestHabitRepository_GetHabitsByIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	"id", "category_id", "name", "description", "schedule", "target_value", "target_unit", "target_comparison", "target_period", "status", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	habits
+WHERE
+	id = ANY($1)
+	AND deleted_at IS NULL
+ORDER BY
+	id;`)).WithArgs("{1,2}").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "category_id", "name", "description"}).
+			AddRow(1, 2, "Exercise", "New description").
+			AddRow(2, 2, "Read", "Read 10 pages"),
+	)
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	got, err := hr.GetHabitsByIDs(context.Background(), []uint64{1, 2})
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		habit_tracker.Habits{
+			{ID: 1, CategoryID: 2, Name: "Exercise", Description: "New description"},
+			{ID: 2, CategoryID: 2, Name: "Read", Description: "Read 10 pages"},
+		},
+		got,
+	)
+}
+
+func TestHabitRepository_ListHabits(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx    context.Context
+		filter habit_tracker.HabitFilter
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		want    habit_tracker.Habits
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+SELECT
+	"id", "category_id", "name", "description", "schedule", "target_value", "target_unit", "target_comparison", "target_period", "status", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	habits
+WHERE
+	category_id = $1
+	AND "name" LIKE $2
+	AND deleted_at IS NULL
+	AND id > $3
+ORDER BY
+	id
+LIMIT $4;`
+	filter := habit_tracker.HabitFilter{
+		CategoryID: 2,
+		NamePrefix: "Ex",
+		Page: habit_tracker.Page{
+			Limit: 10,
+			After: 5,
+		},
+	}
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(2, "Ex%", 5, 10).WillReturnRows(
+				sqlmock.NewRows([]string{"id", "category_id", "name", "description"}).
+					AddRow(6, 2, "Exercise", "New description"),
+			)
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:    context.Background(),
+					filter: filter,
+				},
+				want: habit_tracker.Habits{
+					{
+						ID:          6,
+						CategoryID:  2,
+						Name:        "Exercise",
+						Description: "New description",
+					},
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(2, "Ex%", 5, 10).WillReturnError(assert.AnError)
+
+			return test{
+				name: "ErrorQueryContext",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:    context.Background(),
+					filter: filter,
+				},
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				hr := NewHabitRepository(tt.fields.db)
+				got, err := hr.ListHabits(tt.args.ctx, tt.args.filter)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestHabitRepository_ListHabitCategories(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	"id", "category_name", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	habit_categories
+WHERE
+	"category_name" LIKE $1
+	AND deleted_at IS NULL
+ORDER BY
+	id
+OFFSET $2;`)).WithArgs(`100\%%`, 20).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "category_name"}).AddRow(1, "100% Health"),
+	)
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	got, err := hr.ListHabitCategories(
+		context.Background(),
+		habit_tracker.HabitCategoryFilter{
+			NamePrefix: "100%",
+			Page: habit_tracker.Page{
+				Offset: 20,
+			},
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, habit_tracker.HabitCategories{{ID: 1, CategoryName: "100% Health"}}, got)
+}
+
+func TestHabitRepository_ListHabitRecords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	from := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	"id", "habit_id", "record_date", "result", "value", "unit", "description", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	habit_records
+WHERE
+	habit_id = $1
+	AND record_date >= $2
+	AND record_date < $3
+	AND deleted_at IS NULL
+ORDER BY
+	id;`)).WithArgs(1, from, to).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "habit_id", "record_date", "result", "description"}).
+			AddRow(1, 1, time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC), "done", nil),
+	)
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	got, err := hr.ListHabitRecords(
+		context.Background(),
+		habit_tracker.HabitRecordFilter{
+			HabitID: 1,
+			From:    from.In(time.FixedZone("CEST", 2*60*60)),
+			To:      to,
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		habit_tracker.HabitRecords{
+			{
+				ID:         1,
+				HabitID:    1,
+				RecordDate: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				Result:     habit_tracker.ResultDone,
+			},
+		},
+		got,
+	)
+}
+
+func TestHabitRepository_UpdateHabits(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx    context.Context
+		habits habit_tracker.Habits
+		now    time.Time
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		want    int64
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+UPDATE
+	habits AS h
+SET
+	category_id = v.category_id,
+	"name" = v.name,
+	description = v.description,
+	schedule = v.schedule,
+	target_value = v.target_value,
+	target_unit = v.target_unit,
+	target_comparison = v.target_comparison,
+	target_period = v.target_period,
+	updated_at = v.updated_at,
+	version = h.version + 1
+FROM
+	(VALUES ($1::INT, $2::INT, $3::VARCHAR, $4::TEXT, $5::JSONB, $6::DOUBLE PRECISION, $7::VARCHAR, $8::VARCHAR, $9::VARCHAR, $10::TIMESTAMP, $11::BIGINT), ($12::INT, $13::INT, $14::VARCHAR, $15::TEXT, $16::JSONB, $17::DOUBLE PRECISION, $18::VARCHAR, $19::VARCHAR, $20::VARCHAR, $21::TIMESTAMP, $22::BIGINT)) AS v(id, category_id, name, description, schedule, target_value, target_unit, target_comparison, target_period, updated_at, version)
+WHERE
+	h.id = v.id
+	AND h.deleted_at IS NULL
+	AND h.version = v.version
+RETURNING
+	h.id, h.version;`
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+	queryArgs := []driver.Value{
+		int64(1), int64(2), "Exercise", "New description", "{}", float64(0), "", "", "", now, int64(1),
+		int64(3), int64(2), "Mom's run", "Run with mom", `{"kind":"every_n_days","interval":2,"start_date":"2023-07-01"}`,
+		float64(2), "km", "at_least", "week", now, int64(1),
+	}
+	habits := habit_tracker.Habits{
+		{
+			ID:          1,
+			CategoryID:  2,
+			Name:        "Exercise",
+			Description: "New description",
+			Version:     1,
+		},
+		{
+			ID:          3,
+			CategoryID:  2,
+			Name:        "Mom's run",
+			Description: "Run with mom",
+			Schedule: habit_tracker.Schedule{
+				Kind:      habit_tracker.ScheduleEveryNDays,
+				Interval:  2,
+				StartDate: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC),
+			},
+			TargetValue:      2,
+			TargetUnit:       "km",
+			TargetComparison: habit_tracker.TargetAtLeast,
+			TargetPeriod:     habit_tracker.TargetPerWeek,
+			Version:          1,
+		},
+	}
+	liveIDs := `
+SELECT
+	id
+FROM
+	habits
+WHERE
+	id = ANY($1)
+	AND deleted_at IS NULL;`
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(updatedRows(1, 3))
+			mock.ExpectCommit()
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:    context.Background(),
+					habits: habits,
+					now:    now,
+				},
+				want:    2,
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(updatedRows(1))
+			mock.ExpectQuery(regexp.QuoteMeta(liveIDs)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectRollback()
+
+			return test{
+				name: "NotFound",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:    context.Background(),
+					habits: habits,
+					now:    now,
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					var repositoryErr *habit_tracker.Error
+
+					return assert.ErrorIs(t, err, habit_tracker.ErrNotFound, i...) &&
+						assert.ErrorAs(t, err, &repositoryErr, i...) &&
+						assert.Equal(t, []uint64{3}, repositoryErr.IDs, i...)
+				},
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(updatedRows(1))
+			mock.ExpectQuery(regexp.QuoteMeta(liveIDs)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			mock.ExpectRollback()
+
+			return test{
+				name: "Stale",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:    context.Background(),
+					habits: habits,
+					now:    now,
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					var repositoryErr *habit_tracker.Error
+
+					return assert.ErrorIs(t, err, habit_tracker.ErrConflict, i...) &&
+						assert.ErrorAs(t, err, &repositoryErr, i...) &&
+						assert.Equal(t, []uint64{3}, repositoryErr.IDs, i...)
+				},
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
+			mock.ExpectRollback()
+
+			return test{
+				name: "ErrorQueryContext",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:    context.Background(),
+					habits: habits,
+					now:    now,
+				},
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				hr := NewHabitRepository(tt.fields.db)
+				got, err := hr.UpdateHabits(tt.args.ctx, append(habit_tracker.Habits(nil), tt.args.habits...), tt.args.now)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestHabitRepository_UpdateHabitCategories(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+UPDATE
+	habit_categories AS c
+SET
+	category_name = v.category_name,
+	updated_at = v.updated_at,
+	version = c.version + 1
+FROM
+	(VALUES ($1::INT, $2::VARCHAR, $3::TIMESTAMP, $4::BIGINT)) AS v(id, category_name, updated_at, version)
+WHERE
+	c.id = v.id
+	AND c.deleted_at IS NULL
+	AND c.version = v.version
+RETURNING
+	c.id, c.version;`)).WithArgs(1, "Health", now, 1).WillReturnRows(updatedRows(1))
+	mock.ExpectCommit()
+
+	categories := habit_tracker.HabitCategories{{ID: 1, CategoryName: "Health", Version: 1}}
+	hr := NewHabitRepository(&Postgres{db: db})
+	got, err := hr.UpdateHabitCategories(
+		context.Background(), categories, now,
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+	assert.Equal(t, uint64(2), categories[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHabitRepository_UpdateHabitRecords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+	recordDate := time.Date(2023, 7, 29, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+UPDATE
+	habit_records AS r
+SET
+	habit_id = v.habit_id,
+	record_date = v.record_date,
+	"result" = v.result,
+	value = v.value,
+	unit = v.unit,
+	description = v.description,
+	updated_at = v.updated_at,
+	version = r.version + 1
+FROM
+	(VALUES ($1::INT, $2::INT, $3::TIMESTAMP, $4::VARCHAR, $5::DOUBLE PRECISION, $6::VARCHAR, $7::TEXT, $8::TIMESTAMP, $9::BIGINT)) AS v(id, habit_id, record_date, result, value, unit, description, updated_at, version)
+WHERE
+	r.id = v.id
+	AND r.deleted_at IS NULL
+	AND r.version = v.version
+RETURNING
+	r.id, r.version;`)).WithArgs(1, 2, recordDate, "done", nil, "", "Didn't stop", now, 1).WillReturnRows(updatedRows())
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	id
+FROM
+	habit_records
+WHERE
+	id = ANY($1)
+	AND deleted_at IS NULL;`)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	got, err := hr.UpdateHabitRecords(
+		context.Background(),
+		habit_tracker.HabitRecords{
+			{
+				ID:          1,
+				HabitID:     2,
+				RecordDate:  recordDate,
+				Result:      habit_tracker.ResultDone,
+				Description: "Didn't stop",
+				Version:     1,
+			},
+		},
+		now,
+	)
+
+	assert.ErrorIs(t, err, habit_tracker.ErrNotFound)
+	assert.Equal(t, int64(0), got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHabitRepository_DeleteHabits(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx context.Context
+		ids []uint64
+		now time.Time
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+UPDATE
+	habits
+SET
+	deleted_at = $1,
+	updated_at = $1,
+	version = version + 1
+WHERE
+	id = ANY($2)
+	AND deleted_at IS NULL
+RETURNING
+	id;`
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(now, "{1,2}").WillReturnRows(
+				sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2),
+			)
+			mock.ExpectCommit()
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					ids: []uint64{1, 2},
+					now: now,
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(now, "{1,2}").WillReturnRows(
+				sqlmock.NewRows([]string{"id"}).AddRow(1),
+			)
+			mock.ExpectRollback()
+
+			return test{
+				name: "NotFound",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					ids: []uint64{1, 2},
+					now: now,
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrNotFound, i...)
+				},
+			}
+		}(),
+		{
+			name: "Empty",
+			args: args{
+				ctx: context.Background(),
+				now: now,
+			},
+			wantErr: assert.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				hr := NewHabitRepository(tt.fields.db)
+
+				tt.wantErr(t, hr.DeleteHabits(tt.args.ctx, tt.args.ids, tt.args.now))
+			},
+		)
+	}
+}
+
+func TestHabitRepository_PauseHabits(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx context.Context
+		ids []uint64
+		now time.Time
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+WITH paused AS (
+	UPDATE
+		habits
+	SET
+		status = 'paused',
+		updated_at = $1,
+		version = version + 1
+	WHERE
+		id = ANY($2)
+		AND deleted_at IS NULL
+		AND status = 'active'
+	RETURNING
+		id
+)
+INSERT
+	INTO
+	habit_pauses
+(habit_id, paused_at)
+SELECT
+	id, $1
+FROM
+	paused
+RETURNING
+	habit_id;`
+	liveIDs := `
+SELECT
+	id
+FROM
+	habits
+WHERE
+	id = ANY($1)
+	AND deleted_at IS NULL;`
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(now, "{1,2}").WillReturnRows(
+				sqlmock.NewRows([]string{"habit_id"}).AddRow(1).AddRow(2),
+			)
+			mock.ExpectCommit()
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					ids: []uint64{1, 2},
+					now: now,
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(now, "{1,2,3}").WillReturnRows(
+				sqlmock.NewRows([]string{"habit_id"}).AddRow(1),
+			)
+			mock.ExpectQuery(regexp.QuoteMeta(liveIDs)).WithArgs("{2,3}").WillReturnRows(
+				sqlmock.NewRows([]string{"id"}).AddRow(2),
+			)
+			mock.ExpectRollback()
+
+			return test{
+				name: "Conflict",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					ids: []uint64{1, 2, 3},
+					now: now,
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					var classified *habit_tracker.Error
+
+					return assert.ErrorIs(t, err, habit_tracker.ErrConflict, i...) &&
+						assert.ErrorAs(t, err, &classified, i...) &&
+						assert.Equal(t, []uint64{2}, classified.IDs, i...)
+				},
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(now, "{1,2}").WillReturnRows(
+				sqlmock.NewRows([]string{"habit_id"}).AddRow(1),
+			)
+			mock.ExpectQuery(regexp.QuoteMeta(liveIDs)).WithArgs("{2}").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectRollback()
+
+			return test{
+				name: "NotFound",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					ids: []uint64{1, 2},
+					now: now,
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrNotFound, i...)
+				},
+			}
+		}(),
+		{
+			name: "Empty",
+			args: args{
+				ctx: context.Background(),
+				now: now,
+			},
+			wantErr: assert.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				hr := NewHabitRepository(tt.fields.db)
+
+				tt.wantErr(t, hr.PauseHabits(tt.args.ctx, tt.args.ids, tt.args.now))
+			},
+		)
+	}
+}
+
+func TestHabitRepository_ResumeHabits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+WITH resumed AS (
+	UPDATE
+		habits
+	SET
+		status = 'active',
+		updated_at = $1,
+		version = version + 1
+	WHERE
+		id = ANY($2)
+		AND deleted_at IS NULL
+		AND status IN ('paused', 'archived')
+	RETURNING
+		id
+)
+UPDATE
+	habit_pauses AS p
+SET
+	resumed_at = $1
+FROM
+	resumed AS r
+WHERE
+	p.habit_id = r.id
+	AND p.resumed_at IS NULL
+RETURNING
+	p.habit_id;`)).WithArgs(now, "{1}").WillReturnRows(sqlmock.NewRows([]string{"habit_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	hr := NewHabitRepository(&Postgres{db: db})
+
+	assert.NoError(t, hr.ResumeHabits(context.Background(), []uint64{1}, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHabitRepository_ArchiveHabits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+WITH archived AS (
+	UPDATE
+		habits
+	SET
+		status = 'archived',
+		updated_at = $1,
+		version = version + 1
+	WHERE
+		id = ANY($2)
+		AND deleted_at IS NULL
+		AND status IN ('active', 'paused')
+	RETURNING
+		id
+), opened AS (
+	INSERT
+		INTO
+		habit_pauses
+	(habit_id, paused_at)
+	SELECT
+		a.id, $1
+	FROM
+		archived AS a
+	WHERE
+		NOT EXISTS (SELECT 1 FROM habit_pauses AS p WHERE p.habit_id = a.id AND p.resumed_at IS NULL)
+)
+SELECT
+	id
+FROM
+	archived;`)).WithArgs(now, "{1,2}").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+
+	hr := NewHabitRepository(&Postgres{db: db})
+
+	assert.NoError(t, hr.ArchiveHabits(context.Background(), []uint64{1, 2}, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHabitRepository_ListHabitPauses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	pausedAt := time.Date(2023, 7, 1, 8, 0, 0, 0, time.UTC)
+	resumedAt := time.Date(2023, 7, 8, 20, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	"habit_id", "paused_at", "resumed_at"
+FROM
+	habit_pauses
+WHERE
+	habit_id = ANY($1)
+ORDER BY
+	habit_id, paused_at, id;`)).WithArgs("{1,2}").WillReturnRows(
+		sqlmock.NewRows([]string{"habit_id", "paused_at", "resumed_at"}).
+			AddRow(1, pausedAt, resumedAt).
+			AddRow(1, resumedAt.AddDate(0, 0, 7), nil),
+	)
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	got, err := hr.ListHabitPauses(context.Background(), []uint64{1, 2})
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		map[uint64]habit_tracker.HabitPauses{
+			1: {
+				{HabitID: 1, PausedAt: pausedAt, ResumedAt: &resumedAt},
+				{HabitID: 1, PausedAt: resumedAt.AddDate(0, 0, 7)},
+			},
+		},
+		got,
+	)
+}
+
+func TestHabitRepository_RestoreHabitRecords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+UPDATE
+	habit_records
+SET
+	deleted_at = NULL,
+	updated_at = $1,
+	version = version + 1
+WHERE
+	id = ANY($2)
+	AND deleted_at IS NOT NULL
+RETURNING
+	id;`)).WithArgs(now, "{3}").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectCommit()
+
+	hr := NewHabitRepository(&Postgres{db: db})
+
+	assert.NoError(t, hr.RestoreHabitRecords(context.Background(), []uint64{3}, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHabitRepository_PurgeHabits(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx context.Context
+		ids []uint64
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	cascade := func(mock sqlmock.Sqlmock) {
+		for _, table := range []string{"events", "habit_records", "habit_tags", "habit_goals", "habit_pauses"} {
+			mock.ExpectExec(regexp.QuoteMeta(`
+DELETE
+FROM
+	` + table + `
+WHERE
+	habit_id = ANY($1);`)).WithArgs("{1}").WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+	}
+	query := `
+DELETE
+FROM
+	habits
+WHERE
+	id = ANY($1)
+RETURNING
+	id;`
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			cascade(mock)
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("{1}").WillReturnRows(
+				sqlmock.NewRows([]string{"id"}).AddRow(1),
+			)
+			mock.ExpectCommit()
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					ids: []uint64{1},
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			cascade(mock)
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("{1}").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectRollback()
+
+			return test{
+				name: "NotFound",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					ids: []uint64{1},
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrNotFound, i...)
+				},
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(`
+DELETE
+FROM
+	events`)).WithArgs("{1}").WillReturnError(assert.AnError)
+			mock.ExpectRollback()
+
+			return test{
+				name: "ErrorCascade",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					ids: []uint64{1},
+				},
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				hr := NewHabitRepository(tt.fields.db)
+
+				tt.wantErr(t, hr.PurgeHabits(tt.args.ctx, tt.args.ids))
+			},
+		)
+	}
+}
+
+func TestHabitRepository_PurgeHabitCategories(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectBegin()
+	for _, table := range []string{"events", "habit_records", "habit_tags", "habit_goals", "habit_pauses"} {
+		mock.ExpectExec(regexp.QuoteMeta(`
+DELETE
+FROM
+	` + table + `
+WHERE
+	habit_id IN (
+		SELECT
+			id
+		FROM
+			habits
+		WHERE
+			category_id = ANY($1)
+	);`)).WithArgs("{4}").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec(regexp.QuoteMeta(`
+DELETE
+FROM
+	habits
+WHERE
+	category_id = ANY($1);`)).WithArgs("{4}").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta(`
+DELETE
+FROM
+	habit_categories
+WHERE
+	id = ANY($1)
+RETURNING
+	id;`)).WithArgs("{4}").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectCommit()
+
+	hr := NewHabitRepository(&Postgres{db: db})
+
+	assert.NoError(t, hr.PurgeHabitCategories(context.Background(), []uint64{4}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHabitRepository_ListHabits_ByTag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	"id", "category_id", "name", "description", "schedule", "target_value", "target_unit", "target_comparison", "target_period", "status", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	habits
+WHERE
+	id IN (SELECT habit_id FROM habit_tags WHERE tag_id = $1)
+	AND deleted_at IS NULL
+ORDER BY
+	id;`)).WithArgs(5).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "category_id", "name", "description"}).AddRow(1, 2, "Exercise", "New description"),
+	)
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	got, err := hr.ListHabits(context.Background(), habit_tracker.HabitFilter{TagID: 5})
+
+	assert.NoError(t, err)
+	assert.Equal(t, habit_tracker.Habits{{ID: 1, CategoryID: 2, Name: "Exercise", Description: "New description"}}, got)
+}
+
+func TestHabitRepository_InsertHabits_FillsGeneratedValues(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+INSERT
+	INTO
+	habits
+(category_id, "name", description, schedule, target_value, target_unit, target_comparison, target_period, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10), ($11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+RETURNING
+	id, created_at, updated_at, version, status;`)).WillReturnRows(insertedHabitRows(7, 8))
+
+	habits := habit_tracker.Habits{
+		{CategoryID: 1, Name: "Exercise"},
+		{CategoryID: 1, Name: "Read"},
+	}
+	hr := NewHabitRepository(&Postgres{db: db})
+
+	assert.NoError(t, hr.InsertHabits(context.Background(), habits, now))
+	assert.Equal(
+		t,
+		habit_tracker.Habits{
+			{
+				ID:         7,
+				CategoryID: 1,
+				Name:       "Exercise",
+				Status:     habit_tracker.HabitActive,
+				CreatedAt:  insertedAt,
+				UpdatedAt:  insertedAt,
+				Version:    1,
+			},
+			{
+				ID:         8,
+				CategoryID: 1,
+				Name:       "Read",
+				Status:     habit_tracker.HabitActive,
+				CreatedAt:  insertedAt,
+				UpdatedAt:  insertedAt,
+				Version:    1,
+			},
+		},
+		habits,
+	)
+}
+
+func TestHabitRepository_InsertHabits_ForeignKeyViolation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT`)).WillReturnError(
+		&pq.Error{
+			Code:   "23503",
+			Detail: `Key (category_id)=(9) is not present in table "habit_categories".`,
+		},
+	)
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	err = hr.InsertHabits(context.Background(), habit_tracker.Habits{{CategoryID: 9, Name: "Read"}}, time.Now())
+
+	var classified *habit_tracker.Error
+	assert.ErrorIs(t, err, habit_tracker.ErrForeignKeyViolation)
+	assert.ErrorAs(t, err, &classified)
+	assert.Equal(t, "habit_categories", classified.Entity)
+	assert.Equal(t, []uint64{9}, classified.IDs)
+}
+
+func TestHabitRepository_LoadHabitRecords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC)
+	recordDate := time.Date(2023, 7, 19, 0, 0, 0, 0, time.UTC)
+	copyIn := `COPY "habit_records" ("habit_id", "record_date", "result", "value", "unit", "description", "created_at", "updated_at") FROM STDIN`
+
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare(regexp.QuoteMeta(copyIn))
+	prepare.ExpectExec().WithArgs(int64(1), recordDate, "done", nil, "", "", now, now).WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs(int64(9), recordDate, "done", nil, "", "", now, now).WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs().WillReturnError(
+		&pq.Error{
+			Code:   "23503",
+			Detail: `Key (habit_id)=(9) is not present in table "habits".`,
+		},
+	)
+	mock.ExpectRollback()
+
+	records := make(chan habit_tracker.HabitRecord, 2)
+	records <- habit_tracker.HabitRecord{HabitID: 1, RecordDate: recordDate, Result: "done"}
+	records <- habit_tracker.HabitRecord{HabitID: 9, RecordDate: recordDate, Result: "done"}
+	close(records)
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	loaded, err := hr.LoadHabitRecords(context.Background(), records, now)
+
+	var classified *habit_tracker.Error
+	assert.ErrorIs(t, err, habit_tracker.ErrForeignKeyViolation)
+	assert.ErrorAs(t, err, &classified)
+	assert.Equal(t, "habits", classified.Entity)
+	assert.Equal(t, []uint64{9}, classified.IDs)
+	assert.Zero(t, loaded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHabitRepository_LoadHabitRecords_Validation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	now := time.Date(2023, 7, 20, 15, 32, 0, 0, time.UTC)
+	recordDate := time.Date(2023, 7, 19, 0, 0, 0, 0, time.UTC)
+	copyIn := `COPY "habit_records" ("habit_id", "record_date", "result", "value", "unit", "description", "created_at", "updated_at") FROM STDIN`
+
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare(regexp.QuoteMeta(copyIn))
+	prepare.ExpectExec().WithArgs(int64(1), recordDate, "done", nil, "", "", now, now).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	records := make(chan habit_tracker.HabitRecord, 2)
+	records <- habit_tracker.HabitRecord{HabitID: 1, RecordDate: recordDate, Result: habit_tracker.ResultDone}
+	records <- habit_tracker.HabitRecord{HabitID: 1, RecordDate: recordDate, Result: habit_tracker.ResultDone, Unit: "km"}
+	close(records)
+
+	hr := NewHabitRepository(&Postgres{db: db})
+	loaded, err := hr.LoadHabitRecords(context.Background(), records, now)
+
+	if assert.ErrorIs(t, err, habit_tracker.ErrValidation) {
+		assert.Contains(t, err.Error(), "[habit_record:1]")
+	}
+	assert.Zero(t, loaded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"habit-tracker"
+)
+
+// ErrClosed is returned by the operations started after Close.
+var ErrClosed = fmt.Errorf("postgres is closed: %w", habit_tracker.ErrUnavailable)
+
+// defaultHealthTimeout bounds Health when its context has no deadline.
+const defaultHealthTimeout = 5 * time.Second
+
+type Health struct {
+	Latency   time.Duration
+	CheckedAt time.Time
+}
+
+// Stats is a snapshot of the connection pool.
+type Stats struct {
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"wait_duration"`
+	MaxIdleClosed      int64         `json:"max_idle_closed"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+}
+
+// Close stops accepting operations, waits for the ones in flight to finish,
+// queries until their rows are closed, and closes the pool. When ctx is done
+// before they finish, the pool is closed anyway, which still waits for the
+// queries running on the server, and the context error is returned.
+func (p *Postgres) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+
+		return ErrClosed
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.inFlight.Wait()
+		close(drained)
+	}()
+
+	var drainErr error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		drainErr = fmt.Errorf("[postgres][close][drain][err:%w]", ctx.Err())
+	}
+
+	err := p.db.Close()
+	if err != nil {
+		return fmt.Errorf("[postgres][close][err:%w]", err)
+	}
+
+	return drainErr
+}
+
+// Health pings the database, within defaultHealthTimeout unless ctx has a
+// deadline already, and reports how long it took.
+func (p *Postgres) Health(ctx context.Context) (Health, error) {
+	err := p.acquire()
+	if err != nil {
+		return Health{}, err
+	}
+	defer p.release()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, defaultHealthTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err = p.db.PingContext(ctx)
+	health := Health{
+		Latency:   time.Since(start),
+		CheckedAt: start,
+	}
+	if err != nil {
+		return health, classifyError("postgres", err)
+	}
+
+	return health, nil
+}
+
+func (p *Postgres) Stats() Stats {
+	stats := p.db.Stats()
+
+	return Stats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration,
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
+
+// acquire registers an operation in flight, failing once p is closed. Every
+// successful call must be followed by a call to release.
+func (p *Postgres) acquire() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	p.inFlight.Add(1)
+
+	return nil
+}
+
+func (p *Postgres) release() {
+	p.inFlight.Done()
+}
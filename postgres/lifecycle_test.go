@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func TestPostgres_Close(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	p := &Postgres{
+		db: db,
+	}
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM test;`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	transaction := make(chan error)
+	go func() {
+		transaction <- p.DoTransaction(
+			context.Background(), func(tx *sql.Tx) error {
+				close(started)
+				<-finish
+
+				_, err := tx.ExecContext(context.Background(), `DELETE FROM test;`)
+
+				return err
+			},
+		)
+	}()
+	<-started
+
+	closed := make(chan error)
+	go func() {
+		closed <- p.Close(context.Background())
+	}()
+
+	assert.Eventually(
+		t, func() bool {
+			_, err := p.ExecContext(context.Background(), `DELETE FROM test;`)
+
+			return assert.ObjectsAreEqual(ErrClosed, err)
+		}, time.Second, time.Millisecond,
+	)
+	assert.ErrorIs(t, ErrClosed, habit_tracker.ErrUnavailable)
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the transaction in flight finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(finish)
+	assert.NoError(t, <-transaction)
+	assert.NoError(t, <-closed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.ErrorIs(t, p.Close(context.Background()), ErrClosed)
+}
+
+func TestPostgres_Close_OpenRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	p := &Postgres{
+		db: db,
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM test;`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectClose()
+
+	rows, err := p.QueryContext(context.Background(), `SELECT id FROM test;`)
+	assert.NoError(t, err)
+
+	closed := make(chan error)
+	go func() {
+		closed <- p.Close(context.Background())
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the rows were closed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	assert.NoError(t, rows.Close())
+	assert.NoError(t, rows.Close())
+	assert.NoError(t, <-closed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_Close_DrainTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	p := &Postgres{
+		db: db,
+	}
+	mock.ExpectClose()
+
+	assert.NoError(t, p.acquire())
+	defer p.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, p.Close(ctx), context.DeadlineExceeded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_Health(t *testing.T) {
+	type test struct {
+		name    string
+		ctx     context.Context
+		db      *sql.DB
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			assert.NoError(t, err)
+
+			mock.ExpectPing().WillDelayFor(5 * time.Millisecond)
+
+			return test{
+				name:    "Success",
+				ctx:     context.Background(),
+				db:      db,
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			assert.NoError(t, err)
+
+			mock.ExpectPing().WillReturnError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED})
+
+			return test{
+				name: "ErrorUnreachable",
+				ctx:  context.Background(),
+				db:   db,
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrUnavailable)
+				},
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			assert.NoError(t, err)
+
+			mock.ExpectPing().WillDelayFor(time.Second)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			t.Cleanup(cancel)
+
+			return test{
+				name:    "ErrorDeadline",
+				ctx:     ctx,
+				db:      db,
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				p := &Postgres{
+					db: tt.db,
+				}
+
+				got, err := p.Health(tt.ctx)
+
+				tt.wantErr(t, err)
+				assert.False(t, got.CheckedAt.IsZero())
+				if err == nil {
+					assert.GreaterOrEqual(t, got.Latency, 5*time.Millisecond)
+				}
+			},
+		)
+	}
+}
+
+func TestPostgres_Stats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(3)
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM test;`)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	p := &Postgres{
+		db: db,
+	}
+	_, err = p.ExecContext(context.Background(), `DELETE FROM test;`)
+	assert.NoError(t, err)
+
+	got := p.Stats()
+	assert.Equal(t, 3, got.MaxOpenConnections)
+	assert.Equal(t, 1, got.OpenConnections)
+	assert.Equal(t, 0, got.InUse)
+	assert.Equal(t, 1, got.Idle)
+}
@@ -8,7 +8,17 @@ import (
 type ExecStmt func(*sql.Tx) error
 
 type Drivers interface {
-	QueryContext(ctx context.Context, sql string) (*sql.Rows, error)
-	ExecContext(ctx context.Context, query string) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	DoTransaction(ctx context.Context, fnStmt ExecStmt) error
 }
+
+// Rows is the result set of Drivers.QueryContext, as read from *sql.Rows. It
+// must be closed, Postgres.Close waiting for it.
+type Rows interface {
+	Next() bool
+	Scan(dest ...interface{}) error
+	Columns() ([]string, error)
+	Err() error
+	Close() error
+}
@@ -1,5 +1,30 @@
 package postgres
 
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"habit-tracker"
+)
+
+// Tables
+const (
+	eventsTable          = "events"
+	goalsTable           = "goals"
+	tagsTable            = "tags"
+	habitsTable          = "habits"
+	habitCategoriesTable = "habit_categories"
+	habitRecordsTable    = "habit_records"
+	habitTagsTable       = "habit_tags"
+	habitGoalsTable      = "habit_goals"
+	habitPausesTable     = "habit_pauses"
+)
+
 // Inserts
 const (
 	insertEventsQuery = `
@@ -7,67 +32,942 @@ INSERT
 	INTO
 	events
 (habit_id, subject, start_at, end_at, created_at, updated_at)
-VALUES %s;`
+VALUES %s
+RETURNING
+	id, created_at, updated_at, version;`
 	insertGoalsQuery = `
 INSERT
 	INTO
 	goals
-(description, created_at, updated_at)
-VALUES %s;`
+(description, target_type, target_value, window_start, window_end, deadline, created_at, updated_at)
+VALUES %s
+RETURNING
+	id, created_at, updated_at, version;`
 	insertTagsQuery = `
 INSERT
 	INTO
 	tags
 (name, description, created_at, updated_at)
-VALUES %s;`
+VALUES %s
+RETURNING
+	id, created_at, updated_at, version;`
 	insertHabitsQuery = `
 INSERT
 	INTO
 	habits
-(category_id, "name", description, created_at, updated_at)
-VALUES %s;`
+(category_id, "name", description, schedule, target_value, target_unit, target_comparison, target_period, created_at, updated_at)
+VALUES %s
+RETURNING
+	id, created_at, updated_at, version, status;`
 	insertHabitCategoriesQuery = `
 INSERT
 	INTO
 	habit_categories
 (category_name, created_at, updated_at)
-VALUES %s;`
+VALUES %s
+RETURNING
+	id, created_at, updated_at, version;`
 	insertHabitRecordsQuery = `
 INSERT
 	INTO
 	habit_records
-(habit_id, record_date, "result", description, created_at, updated_at)
-VALUES %s;`
+(habit_id, record_date, "result", value, unit, description, created_at, updated_at)
+VALUES %s
+RETURNING
+	id, created_at, updated_at, version;`
 )
 
+// Upserts
+const (
+	upsertHabitRecordsQuery = `
+INSERT
+	INTO
+	habit_records
+(habit_id, record_date, "result", value, unit, description, created_at, updated_at)
+VALUES %s
+ON CONFLICT (habit_id, (record_date::date)) WHERE deleted_at IS NULL
+DO UPDATE
+SET
+	%s,
+	updated_at = EXCLUDED.updated_at,
+	version = habit_records.version + 1
+RETURNING
+	id, created_at, updated_at, version, (xmax = 0) AS created;`
+)
+
+// upsertHabitRecordsSets are the SET clauses of each habit_tracker.UpsertMode.
+var upsertHabitRecordsSets = map[habit_tracker.UpsertMode]string{
+	habit_tracker.UpsertReplace: `record_date = EXCLUDED.record_date,
+	"result" = EXCLUDED."result",
+	value = EXCLUDED.value,
+	unit = EXCLUDED.unit,
+	description = EXCLUDED.description`,
+	habit_tracker.UpsertMerge: `"result" = COALESCE(NULLIF(EXCLUDED."result", ''), habit_records."result"),
+	value = COALESCE(EXCLUDED.value, habit_records.value),
+	unit = COALESCE(NULLIF(EXCLUDED.unit, ''), habit_records.unit),
+	description = COALESCE(NULLIF(EXCLUDED.description, ''), habit_records.description)`,
+}
+
 // Updates
 const (
 	UpdateEventsQuery = `
 UPDATE
-	events
+	events AS e
 SET
-	habit_id = %d,
-	subject = '%s',
-	start_at = '%s',
-	end_at = '%s',
-	updated_at = '%s'
+	habit_id = v.habit_id,
+	subject = v.subject,
+	start_at = v.start_at,
+	end_at = v.end_at,
+	updated_at = v.updated_at,
+	version = e.version + 1
+FROM
+	(VALUES %s) AS v(id, habit_id, subject, start_at, end_at, updated_at, version)
 WHERE
-	id = %d;`
+	e.id = v.id
+	AND e.deleted_at IS NULL
+	AND e.version = v.version
+RETURNING
+	e.id, e.version;`
 	UpdateGoalsQuery = `
 UPDATE
-	goals
+	goals AS g
+SET
+	description = v.description,
+	target_type = v.target_type,
+	target_value = v.target_value,
+	window_start = v.window_start,
+	window_end = v.window_end,
+	deadline = v.deadline,
+	updated_at = v.updated_at,
+	version = g.version + 1
+FROM
+	(VALUES %s) AS v(id, description, target_type, target_value, window_start, window_end, deadline, updated_at, version)
+WHERE
+	g.id = v.id
+	AND g.deleted_at IS NULL
+	AND g.version = v.version
+RETURNING
+	g.id, g.version;`
+	updateHabitsQuery = `
+UPDATE
+	habits AS h
+SET
+	category_id = v.category_id,
+	"name" = v.name,
+	description = v.description,
+	schedule = v.schedule,
+	target_value = v.target_value,
+	target_unit = v.target_unit,
+	target_comparison = v.target_comparison,
+	target_period = v.target_period,
+	updated_at = v.updated_at,
+	version = h.version + 1
+FROM
+	(VALUES %s) AS v(id, category_id, name, description, schedule, target_value, target_unit, target_comparison, target_period, updated_at, version)
+WHERE
+	h.id = v.id
+	AND h.deleted_at IS NULL
+	AND h.version = v.version
+RETURNING
+	h.id, h.version;`
+	updateHabitCategoriesQuery = `
+UPDATE
+	habit_categories AS c
+SET
+	category_name = v.category_name,
+	updated_at = v.updated_at,
+	version = c.version + 1
+FROM
+	(VALUES %s) AS v(id, category_name, updated_at, version)
+WHERE
+	c.id = v.id
+	AND c.deleted_at IS NULL
+	AND c.version = v.version
+RETURNING
+	c.id, c.version;`
+	updateHabitRecordsQuery = `
+UPDATE
+	habit_records AS r
 SET
-	description = '%s',
-	updated_at = '%s'
+	habit_id = v.habit_id,
+	record_date = v.record_date,
+	"result" = v.result,
+	value = v.value,
+	unit = v.unit,
+	description = v.description,
+	updated_at = v.updated_at,
+	version = r.version + 1
+FROM
+	(VALUES %s) AS v(id, habit_id, record_date, result, value, unit, description, updated_at, version)
 WHERE
-	id = %d;`
+	r.id = v.id
+	AND r.deleted_at IS NULL
+	AND r.version = v.version
+RETURNING
+	r.id, r.version;`
 	UpdateTagsQuery = `
 UPDATE
-	tags
+	tags AS t
+SET
+	"name" = v.name,
+	description = v.description,
+	updated_at = v.updated_at,
+	version = t.version + 1
+FROM
+	(VALUES %s) AS v(id, name, description, updated_at, version)
+WHERE
+	t.id = v.id
+	AND t.deleted_at IS NULL
+	AND t.version = v.version
+RETURNING
+	t.id, t.version;`
+)
+
+// Habit tags
+const (
+	attachTagsQuery = `
+INSERT
+	INTO
+	habit_tags
+(habit_id, tag_id)
+VALUES %s
+ON CONFLICT DO NOTHING;`
+	detachTagsQuery = `
+DELETE
+FROM
+	habit_tags AS ht
+USING
+	(VALUES %s) AS v(habit_id, tag_id)
+WHERE
+	ht.habit_id = v.habit_id
+	AND ht.tag_id = v.tag_id;`
+	deleteHabitTagsQuery = `
+DELETE
+FROM
+	habit_tags
+WHERE
+	habit_id = $1;`
+	insertHabitTagsQuery = `
+INSERT
+	INTO
+	habit_tags
+(habit_id, tag_id)
+SELECT
+	$1,
+	UNNEST($2::INT[])
+ON CONFLICT DO NOTHING;`
+	listTagsByHabitIDsQuery = `
+SELECT
+	ht.habit_id, %s
+FROM
+	habit_tags AS ht
+	JOIN tags AS t ON t.id = ht.tag_id
+WHERE
+	ht.habit_id = ANY($1)
+	AND t.deleted_at IS NULL
+ORDER BY
+	ht.habit_id, t.id;`
+)
+
+// Habit statuses. Each statement changes the status of the habits given as $2
+// at $1, returning their ids, and opens or closes their pause accordingly.
+const (
+	pauseHabitsQuery = `
+WITH paused AS (
+	UPDATE
+		habits
+	SET
+		status = 'paused',
+		updated_at = $1,
+		version = version + 1
+	WHERE
+		id = ANY($2)
+		AND deleted_at IS NULL
+		AND status = 'active'
+	RETURNING
+		id
+)
+INSERT
+	INTO
+	habit_pauses
+(habit_id, paused_at)
+SELECT
+	id, $1
+FROM
+	paused
+RETURNING
+	habit_id;`
+	resumeHabitsQuery = `
+WITH resumed AS (
+	UPDATE
+		habits
+	SET
+		status = 'active',
+		updated_at = $1,
+		version = version + 1
+	WHERE
+		id = ANY($2)
+		AND deleted_at IS NULL
+		AND status IN ('paused', 'archived')
+	RETURNING
+		id
+)
+UPDATE
+	habit_pauses AS p
+SET
+	resumed_at = $1
+FROM
+	resumed AS r
+WHERE
+	p.habit_id = r.id
+	AND p.resumed_at IS NULL
+RETURNING
+	p.habit_id;`
+	archiveHabitsQuery = `
+WITH archived AS (
+	UPDATE
+		habits
+	SET
+		status = 'archived',
+		updated_at = $1,
+		version = version + 1
+	WHERE
+		id = ANY($2)
+		AND deleted_at IS NULL
+		AND status IN ('active', 'paused')
+	RETURNING
+		id
+), opened AS (
+	INSERT
+		INTO
+		habit_pauses
+	(habit_id, paused_at)
+	SELECT
+		a.id, $1
+	FROM
+		archived AS a
+	WHERE
+		NOT EXISTS (SELECT 1 FROM habit_pauses AS p WHERE p.habit_id = a.id AND p.resumed_at IS NULL)
+)
+SELECT
+	id
+FROM
+	archived;`
+	listHabitPausesQuery = `
+SELECT
+	%s
+FROM
+	habit_pauses
+WHERE
+	habit_id = ANY($1)
+ORDER BY
+	habit_id, paused_at, id;`
+)
+
+// Habit goals
+const (
+	linkHabitsQuery = `
+INSERT
+	INTO
+	habit_goals
+(habit_id, goal_id)
+VALUES %s
+ON CONFLICT DO NOTHING;`
+	unlinkHabitsQuery = `
+DELETE
+FROM
+	habit_goals AS hg
+USING
+	(VALUES %s) AS v(habit_id, goal_id)
+WHERE
+	hg.habit_id = v.habit_id
+	AND hg.goal_id = v.goal_id;`
+	listHabitIDsByGoalIDsQuery = `
+SELECT
+	hg.habit_id, hg.goal_id
+FROM
+	habit_goals AS hg
+	JOIN habits AS h ON h.id = hg.habit_id
+WHERE
+	hg.goal_id = ANY($1)
+	AND h.deleted_at IS NULL
+ORDER BY
+	hg.goal_id, hg.habit_id;`
+)
+
+// Deletes
+const (
+	softDeleteQuery = `
+UPDATE
+	%s
+SET
+	deleted_at = $1,
+	updated_at = $1,
+	version = version + 1
+WHERE
+	id = ANY($2)
+	AND deleted_at IS NULL
+RETURNING
+	id;`
+	restoreQuery = `
+UPDATE
+	%s
 SET
-	"name" = '%s',
-	description = '%s',
-	updated_at = '%s'
+	deleted_at = NULL,
+	updated_at = $1,
+	version = version + 1
+WHERE
+	id = ANY($2)
+	AND deleted_at IS NOT NULL
+RETURNING
+	id;`
+	liveIDsQuery = `
+SELECT
+	id
+FROM
+	%s
+WHERE
+	id = ANY($1)
+	AND deleted_at IS NULL;`
+	purgeQuery = `
+DELETE
+FROM
+	%s
+WHERE
+	id = ANY($1)
+RETURNING
+	id;`
+	purgeByHabitQuery = `
+DELETE
+FROM
+	%s
+WHERE
+	habit_id = ANY($1);`
+	purgeByCategoryQuery = `
+DELETE
+FROM
+	%s
 WHERE
-	id = %d;`
+	habit_id IN (
+		SELECT
+			id
+		FROM
+			habits
+		WHERE
+			category_id = ANY($1)
+	);`
+	purgeHabitsByCategoryQuery = `
+DELETE
+FROM
+	habits
+WHERE
+	category_id = ANY($1);`
+	purgeHabitTagsQuery = `
+DELETE
+FROM
+	habit_tags
+WHERE
+	tag_id = ANY($1);`
+	purgeHabitGoalsQuery = `
+DELETE
+FROM
+	habit_goals
+WHERE
+	goal_id = ANY($1);`
 )
+
+// Tables holding a habit_id that must be purged along with the habit.
+var habitDependentTables = []string{eventsTable, habitRecordsTable, habitTagsTable, habitGoalsTable, habitPausesTable}
+
+// Column types of the VALUES lists used by the batched updates. Postgres can't
+// infer the type of a bare placeholder inside VALUES, so every one is cast.
+var (
+	updateEventsTypes = []string{"INT", "INT", "TEXT", "TIMESTAMP", "TIMESTAMP", "TIMESTAMP", "BIGINT"}
+	updateGoalsTypes  = []string{
+		"INT", "TEXT", "VARCHAR", "DOUBLE PRECISION", "TIMESTAMP", "TIMESTAMP", "TIMESTAMP", "TIMESTAMP", "BIGINT",
+	}
+	updateTagsTypes = []string{"INT", "VARCHAR", "TEXT", "TIMESTAMP", "BIGINT"}
+
+	updateHabitsTypes = []string{
+		"INT", "INT", "VARCHAR", "TEXT", "JSONB", "DOUBLE PRECISION", "VARCHAR", "VARCHAR", "VARCHAR", "TIMESTAMP", "BIGINT",
+	}
+	updateHabitCategoriesTypes = []string{"INT", "VARCHAR", "TIMESTAMP", "BIGINT"}
+	updateHabitRecordsTypes    = []string{
+		"INT", "INT", "TIMESTAMP", "VARCHAR", "DOUBLE PRECISION", "VARCHAR", "TEXT", "TIMESTAMP", "BIGINT",
+	}
+
+	habitTagsTypes  = []string{"INT", "INT"}
+	habitGoalsTypes = []string{"INT", "INT"}
+)
+
+// Columns loaded by the COPY bulk loads.
+var (
+	copyEventsColumns       = []string{"habit_id", "subject", "start_at", "end_at", "created_at", "updated_at"}
+	copyHabitRecordsColumns = []string{
+		"habit_id", "record_date", "result", "value", "unit", "description", "created_at", "updated_at",
+	}
+)
+
+// buildValues returns the placeholders of a multi-row VALUES list, e.g.
+// "($1, $2), ($3, $4)" for two rows of two columns.
+func buildValues(rows, columns int) string {
+	return buildTypedValues(rows, make([]string, columns))
+}
+
+// buildTypedValues works like buildValues but casts each column to the given
+// type, e.g. "($1::INT, $2::TEXT)". An empty type leaves the column uncast.
+func buildTypedValues(rows int, types []string) string {
+	str := strings.Builder{}
+	placeholder := 1
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			str.WriteString(", ")
+		}
+
+		str.WriteString("(")
+		for column, columnType := range types {
+			if column > 0 {
+				str.WriteString(", ")
+			}
+
+			str.WriteString(fmt.Sprintf("$%d", placeholder))
+			if columnType != "" {
+				str.WriteString("::" + columnType)
+			}
+
+			placeholder++
+		}
+		str.WriteString(")")
+	}
+
+	return str.String()
+}
+
+// whereClause collects the conditions of a SELECT. Each condition holds a
+// single %d verb that is replaced by the number of its placeholder.
+type whereClause struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (w *whereClause) add(condition string, arg interface{}) {
+	w.args = append(w.args, arg)
+	w.conditions = append(w.conditions, fmt.Sprintf(condition, len(w.args)))
+}
+
+func (w *whereClause) notDeleted() {
+	w.conditions = append(w.conditions, "deleted_at IS NULL")
+}
+
+func (w *whereClause) addPrefix(column, prefix string) {
+	if prefix == "" {
+		return
+	}
+
+	w.add(pq.QuoteIdentifier(column)+" LIKE $%d", likeEscaper.Replace(prefix)+"%")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func buildSelectQuery(table string, columns []string, where whereClause,
+	page habit_tracker.Page) (string, []interface{}) {
+	if page.After > 0 {
+		where.add("id > $%d", page.After)
+	}
+
+	str := strings.Builder{}
+	str.WriteString(
+		fmt.Sprintf("\nSELECT\n\t%s\nFROM\n\t%s", strings.Join(quoteColumns(columns), ", "), table),
+	)
+
+	for i, condition := range where.conditions {
+		if i == 0 {
+			str.WriteString("\nWHERE\n\t" + condition)
+		} else {
+			str.WriteString("\n\tAND " + condition)
+		}
+	}
+
+	str.WriteString("\nORDER BY\n\tid")
+
+	args := where.args
+	if page.Limit > 0 {
+		args = append(args, page.Limit)
+		str.WriteString(fmt.Sprintf("\nLIMIT $%d", len(args)))
+	}
+
+	if page.Offset > 0 {
+		args = append(args, page.Offset)
+		str.WriteString(fmt.Sprintf("\nOFFSET $%d", len(args)))
+	}
+
+	str.WriteString(";")
+
+	return str.String(), args
+}
+
+func selectRows[T any](ctx context.Context, db Drivers, table string, where whereClause,
+	page habit_tracker.Page) ([]T, error) {
+	query, args := buildSelectQuery(table, columnsOf[T](), where, page)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRows[T](rows)
+}
+
+func selectByID[T any](ctx context.Context, db Drivers, table string, id uint64) (T, error) {
+	where := whereClause{}
+	where.add("id = $%d", id)
+	where.notDeleted()
+
+	var item T
+
+	items, err := selectRows[T](ctx, db, table, where, habit_tracker.Page{})
+	if err != nil {
+		return item, err
+	}
+
+	if len(items) == 0 {
+		return item, habit_tracker.NewNotFoundError(table, id)
+	}
+
+	return items[0], nil
+}
+
+func selectByIDs[T any](ctx context.Context, db Drivers, table string, ids []uint64) ([]T, error) {
+	if len(ids) == 0 {
+		return make([]T, 0), nil
+	}
+
+	where := whereClause{}
+	where.add("id = ANY($%d)", pq.Array(int64s(ids)))
+	where.notDeleted()
+
+	return selectRows[T](ctx, db, table, where, habit_tracker.Page{})
+}
+
+// execUpdate runs a batched UPDATE returning the ids of the updated rows. It
+// runs in a transaction that is rolled back when any of ids matched no row.
+func execUpdate(ctx context.Context, db Drivers, table string, ids []uint64,
+	query string, args []interface{}) (int64, error) {
+	var matched int64
+
+	err := db.DoTransaction(
+		ctx, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+
+			updated, err := scanIDs(rows)
+			if err != nil {
+				return err
+			}
+
+			missing := missingIDs(ids, updated)
+			if len(missing) > 0 {
+				return habit_tracker.NewNotFoundError(table, missing...)
+			}
+
+			matched = int64(len(updated))
+
+			return nil
+		},
+	)
+
+	return matched, err
+}
+
+// versionedRow holds the version a row was updated to.
+type versionedRow struct {
+	ID      uint64 `sql:"id"`
+	Version uint64 `sql:"version"`
+}
+
+// execVersionedUpdate runs a batched UPDATE of the rows still at the version
+// given for each, returning the version they were updated to by id. It runs
+// in a transaction that is rolled back with a conflict error listing the rows
+// whose version changed, or else with a not found error when any of ids
+// matched no row.
+func execVersionedUpdate(ctx context.Context, db Drivers, table string, ids []uint64,
+	query string, args []interface{}) (map[uint64]uint64, error) {
+	var versions map[uint64]uint64
+
+	err := db.DoTransaction(
+		ctx, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+
+			updated, err := scanRows[versionedRow](rows)
+			if err != nil {
+				return err
+			}
+
+			versions = make(map[uint64]uint64, len(updated))
+			updatedIDs := make([]uint64, len(updated))
+			for i, row := range updated {
+				versions[row.ID] = row.Version
+				updatedIDs[i] = row.ID
+			}
+
+			missing := missingIDs(ids, updatedIDs)
+			if len(missing) == 0 {
+				return nil
+			}
+
+			rows, err = tx.QueryContext(ctx, fmt.Sprintf(liveIDsQuery, table), pq.Array(int64s(missing)))
+			if err != nil {
+				return err
+			}
+
+			stale, err := scanIDs(rows)
+			if err != nil {
+				return err
+			}
+
+			if len(stale) > 0 {
+				return habit_tracker.NewConflictError(table, stale...)
+			}
+
+			return habit_tracker.NewNotFoundError(table, missing...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// execSoftDelete marks the rows as deleted, failing with a not found error
+// when any of them doesn't exist or is already deleted.
+func execSoftDelete(ctx context.Context, db Drivers, table string, ids []uint64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := execUpdate(
+		ctx, db, table, ids, fmt.Sprintf(softDeleteQuery, table), []interface{}{now, pq.Array(int64s(ids))},
+	)
+
+	return err
+}
+
+// execRestore undoes execSoftDelete, failing with a not found error when any
+// of the rows doesn't exist or isn't deleted.
+func execRestore(ctx context.Context, db Drivers, table string, ids []uint64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := execUpdate(
+		ctx, db, table, ids, fmt.Sprintf(restoreQuery, table), []interface{}{now, pq.Array(int64s(ids))},
+	)
+
+	return err
+}
+
+// execStatusChange runs one of the habit status queries in a transaction that
+// is rolled back with a conflict error listing the live habits it didn't
+// change, as they were in another status, or else with a not found error.
+func execStatusChange(ctx context.Context, db Drivers, ids []uint64, query string, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return db.DoTransaction(
+		ctx, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, query, now, pq.Array(int64s(ids)))
+			if err != nil {
+				return err
+			}
+
+			changed, err := scanIDs(rows)
+			if err != nil {
+				return err
+			}
+
+			missing := missingIDs(ids, changed)
+			if len(missing) == 0 {
+				return nil
+			}
+
+			rows, err = tx.QueryContext(ctx, fmt.Sprintf(liveIDsQuery, habitsTable), pq.Array(int64s(missing)))
+			if err != nil {
+				return err
+			}
+
+			live, err := scanIDs(rows)
+			if err != nil {
+				return err
+			}
+
+			if len(live) > 0 {
+				return habit_tracker.NewConflictError(habitsTable, live...)
+			}
+
+			return habit_tracker.NewNotFoundError(habitsTable, missing...)
+		},
+	)
+}
+
+// execPurge deletes the rows for good after running the cascade statements,
+// all in one transaction. Every statement receives the ids as $1.
+func execPurge(ctx context.Context, db Drivers, table string, ids []uint64, cascade ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	arg := pq.Array(int64s(ids))
+
+	return db.DoTransaction(
+		ctx, func(tx *sql.Tx) error {
+			for _, query := range cascade {
+				_, err := tx.ExecContext(ctx, query, arg)
+				if err != nil {
+					return err
+				}
+			}
+
+			rows, err := tx.QueryContext(ctx, fmt.Sprintf(purgeQuery, table), arg)
+			if err != nil {
+				return err
+			}
+
+			purged, err := scanIDs(rows)
+			if err != nil {
+				return err
+			}
+
+			missing := missingIDs(ids, purged)
+			if len(missing) > 0 {
+				return habit_tracker.NewNotFoundError(table, missing...)
+			}
+
+			return nil
+		},
+	)
+}
+
+// insertedRow holds the values generated by Postgres for an inserted row.
+type insertedRow struct {
+	ID        uint64    `sql:"id"`
+	CreatedAt time.Time `sql:"created_at"`
+	UpdatedAt time.Time `sql:"updated_at"`
+	Version   uint64    `sql:"version"`
+}
+
+// insertedHabitRow adds the status Postgres defaults a habit to.
+type insertedHabitRow struct {
+	insertedRow
+	Status habit_tracker.HabitStatus `sql:"status"`
+}
+
+// upsertedRow holds the values generated by Postgres for an upserted row, xmax
+// being 0 only for the ones inserted.
+type upsertedRow struct {
+	ID        uint64    `sql:"id"`
+	CreatedAt time.Time `sql:"created_at"`
+	UpdatedAt time.Time `sql:"updated_at"`
+	Version   uint64    `sql:"version"`
+	Created   bool      `sql:"created"`
+}
+
+// execInsert runs an INSERT returning the generated values of its rows into
+// T, which Postgres yields in the order of the VALUES list.
+func execInsert[T any](ctx context.Context, db Drivers, query string, args []interface{},
+	count int) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	inserted, err := scanRows[T](rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(inserted) != count {
+		return nil, fmt.Errorf("[inserted:%d][expected:%d][err:unexpected returned rows]", len(inserted), count)
+	}
+
+	return inserted, nil
+}
+
+// copyChunkSize is the number of rows sent by each COPY of execCopy.
+var copyChunkSize int64 = 5000
+
+// execCopy loads the rows received from rows until it's closed with COPY, in
+// chunks of copyChunkSize, and returns how many were loaded. values returns the
+// column values of a row, or an error failing the load before it is sent. The
+// chunks run in a single transaction, so that nothing is left of a failed load,
+// which is never retried as rows can't be received again.
+func execCopy[T any](ctx context.Context, db Drivers, table string, columns []string, rows <-chan T,
+	values func(T) ([]interface{}, error)) (int64, error) {
+	var loaded int64
+	err := db.DoTransaction(
+		withoutRetry(ctx), func(tx *sql.Tx) error {
+			for {
+				copied, err := copyChunk(ctx, tx, table, columns, rows, values)
+				loaded += copied
+				if err != nil || copied < copyChunkSize {
+					return err
+				}
+			}
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return loaded, nil
+}
+
+// copyChunk sends up to copyChunkSize rows in one COPY, fewer meaning that
+// rows is closed.
+func copyChunk[T any](ctx context.Context, tx *sql.Tx, table string, columns []string, rows <-chan T,
+	values func(T) ([]interface{}, error)) (int64, error) {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var copied int64
+	for copied < copyChunkSize {
+		var row T
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return copied, ctx.Err()
+		case row, ok = <-rows:
+		}
+
+		if !ok {
+			break
+		}
+
+		args, err := values(row)
+		if err != nil {
+			return copied, err
+		}
+
+		_, err = stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return copied, err
+		}
+
+		copied++
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return copied, err
+	}
+
+	return copied, nil
+}
@@ -0,0 +1,393 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func Test_buildValues(t *testing.T) {
+	type args struct {
+		rows    int
+		columns int
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "SingleRow",
+			args: args{
+				rows:    1,
+				columns: 3,
+			},
+			want: "($1, $2, $3)",
+		},
+		{
+			name: "MultipleRows",
+			args: args{
+				rows:    3,
+				columns: 2,
+			},
+			want: "($1, $2), ($3, $4), ($5, $6)",
+		},
+		{
+			name: "Empty",
+			args: args{
+				rows:    0,
+				columns: 2,
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				assert.Equal(t, tt.want, buildValues(tt.args.rows, tt.args.columns))
+			},
+		)
+	}
+}
+
+func Test_buildTypedValues(t *testing.T) {
+	type args struct {
+		rows  int
+		types []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "Success",
+			args: args{
+				rows:  2,
+				types: []string{"INT", "TEXT"},
+			},
+			want: "($1::INT, $2::TEXT), ($3::INT, $4::TEXT)",
+		},
+		{
+			name: "Uncast",
+			args: args{
+				rows:  1,
+				types: []string{"INT", ""},
+			},
+			want: "($1::INT, $2)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				assert.Equal(t, tt.want, buildTypedValues(tt.args.rows, tt.args.types))
+			},
+		)
+	}
+}
+
+func Test_buildSelectQuery(t *testing.T) {
+	type args struct {
+		table   string
+		columns []string
+		where   whereClause
+		page    habit_tracker.Page
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name: "NoConditions",
+			args: args{
+				table:   "goals",
+				columns: []string{"id", "description"},
+			},
+			want: `
+SELECT
+	"id", "description"
+FROM
+	goals
+ORDER BY
+	id;`,
+		},
+		{
+			name: "ConditionsAndPage",
+			args: args{
+				table:   "tags",
+				columns: []string{"id", "name"},
+				where: func() whereClause {
+					where := whereClause{}
+					where.addPrefix("name", "a_b")
+
+					return where
+				}(),
+				page: habit_tracker.Page{
+					Limit:  10,
+					Offset: 5,
+					After:  3,
+				},
+			},
+			want: `
+SELECT
+	"id", "name"
+FROM
+	tags
+WHERE
+	"name" LIKE $1
+	AND id > $2
+ORDER BY
+	id
+LIMIT $3
+OFFSET $4;`,
+			wantArgs: []interface{}{`a\_b%`, uint64(3), uint64(10), uint64(5)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				got, gotArgs := buildSelectQuery(tt.args.table, tt.args.columns, tt.args.where, tt.args.page)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
+			},
+		)
+	}
+}
+
+// insertedRows returns the rows of an INSERT ... RETURNING generating ids.
+func insertedRows(ids ...int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "version"})
+	for _, id := range ids {
+		rows.AddRow(id, insertedAt, insertedAt, 1)
+	}
+
+	return rows
+}
+
+// insertedHabitRows adds the default status to insertedRows.
+func insertedHabitRows(ids ...int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "version", "status"})
+	for _, id := range ids {
+		rows.AddRow(id, insertedAt, insertedAt, 1, "active")
+	}
+
+	return rows
+}
+
+var insertedAt = time.Date(2023, 7, 30, 12, 0, 1, 0, time.UTC)
+
+// updatedRows returns the rows of a versioned UPDATE ... RETURNING, every row
+// being bumped to version 2.
+func updatedRows(ids ...int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "version"})
+	for _, id := range ids {
+		rows.AddRow(id, 2)
+	}
+
+	return rows
+}
+
+func Test_execInsert(t *testing.T) {
+	type args struct {
+		count int
+	}
+	type test struct {
+		name    string
+		db      Drivers
+		args    args
+		want    []insertedRow
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `INSERT INTO tags (name) VALUES ($1), ($2) RETURNING id, created_at, updated_at, version;`
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("a", "b").WillReturnRows(insertedRows(4, 5))
+
+			return test{
+				name: "Success",
+				db: &Postgres{
+					db: db,
+				},
+				args: args{
+					count: 2,
+				},
+				want: []insertedRow{
+					{ID: 4, CreatedAt: insertedAt, UpdatedAt: insertedAt, Version: 1},
+					{ID: 5, CreatedAt: insertedAt, UpdatedAt: insertedAt, Version: 1},
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("a", "b").WillReturnRows(insertedRows(4))
+
+			return test{
+				name: "ErrorReturnedRows",
+				db: &Postgres{
+					db: db,
+				},
+				args: args{
+					count: 2,
+				},
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				got, err := execInsert[insertedRow](context.Background(), tt.db, query, []interface{}{"a", "b"}, tt.args.count)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func Test_execCopy(t *testing.T) {
+	type test struct {
+		name    string
+		db      Drivers
+		ctx     context.Context
+		rows    []string
+		mock    sqlmock.Sqlmock
+		want    int64
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	chunkSize := copyChunkSize
+	copyChunkSize = 2
+	defer func() {
+		copyChunkSize = chunkSize
+	}()
+
+	copyIn := `COPY "tags" ("name") FROM STDIN`
+	conflict := &pq.Error{Code: serializationFailure}
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			prepare := mock.ExpectPrepare(regexp.QuoteMeta(copyIn))
+			prepare.ExpectExec().WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 0))
+			prepare.ExpectExec().WithArgs("b").WillReturnResult(sqlmock.NewResult(0, 0))
+			prepare.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 2))
+			prepare = mock.ExpectPrepare(regexp.QuoteMeta(copyIn))
+			prepare.ExpectExec().WithArgs("c").WillReturnResult(sqlmock.NewResult(0, 0))
+			prepare.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			return test{
+				name: "SuccessChunked",
+				db: &Postgres{
+					db:    db,
+					retry: RetryPolicy{MaxAttempts: 3},
+				},
+				ctx:     context.Background(),
+				rows:    []string{"a", "b", "c"},
+				mock:    mock,
+				want:    3,
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			prepare := mock.ExpectPrepare(regexp.QuoteMeta(copyIn))
+			prepare.ExpectExec().WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 0))
+			prepare.ExpectExec().WithArgs("b").WillReturnResult(sqlmock.NewResult(0, 0))
+			prepare.ExpectExec().WithArgs().WillReturnError(conflict)
+			mock.ExpectRollback()
+
+			return test{
+				name: "ErrorRolledBackWithoutRetry",
+				db: &Postgres{
+					db:    db,
+					retry: RetryPolicy{MaxAttempts: 3},
+				},
+				ctx:  context.Background(),
+				rows: []string{"a", "b"},
+				mock: mock,
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, conflict, i...)
+				},
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			prepare := mock.ExpectPrepare(regexp.QuoteMeta(copyIn))
+			prepare.ExpectExec().WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			t.Cleanup(cancel)
+
+			return test{
+				name: "ErrorCanceled",
+				db: &Postgres{
+					db: db,
+				},
+				ctx:  ctx,
+				rows: []string{"a"},
+				mock: mock,
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, context.DeadlineExceeded, i...)
+				},
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				rows := make(chan string, len(tt.rows))
+				for _, row := range tt.rows {
+					rows <- row
+				}
+				if _, ok := tt.ctx.Deadline(); !ok {
+					close(rows)
+				}
+
+				got, err := execCopy(
+					tt.ctx, tt.db, tagsTable, []string{"name"}, rows, func(name string) ([]interface{}, error) {
+						return []interface{}{name}, nil
+					},
+				)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+				assert.NoError(t, tt.mock.ExpectationsWereMet())
+			},
+		)
+	}
+}
@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+
+	"habit-tracker/logger"
+)
+
+// RetryPolicy makes Postgres retry the operations failing with a transient
+// error: a serialization failure, a deadlock, or a connection refused or
+// reset. A failed DoTransaction runs its whole closure again, in a new
+// transaction. The zero value never retries.
+//
+// Retries are opt-in: DefaultRetryPolicy only sets the backoff, so that
+// raising MaxAttempts is enough to enable them.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt, so 1 or less never retries.
+	MaxAttempts int
+	// InitialBackoff is the delay after the first attempt, multiplied by
+	// Multiplier after each of the next ones, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction of each delay picked at random, from 0 to 1, so
+	// that clients failing together don't retry together.
+	Jitter float64
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    1,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+}
+
+func (r RetryPolicy) Validate() error {
+	if r.MaxAttempts < 0 || r.InitialBackoff < 0 || r.MaxBackoff < 0 {
+		return errors.New("retry attempts and backoffs can't be negative")
+	}
+
+	if r.Multiplier != 0 && r.Multiplier < 1 {
+		return errors.New("retry multiplier can't be lower than 1")
+	}
+
+	if r.Jitter < 0 || r.Jitter > 1 {
+		return errors.New("retry jitter must be between 0 and 1")
+	}
+
+	return nil
+}
+
+// backoff returns the delay after the given attempt, counted from 1, for a
+// random value in [0, 1).
+func (r RetryPolicy) backoff(attempt int, random float64) time.Duration {
+	multiplier := r.Multiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+
+	delay := float64(r.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if r.MaxBackoff > 0 && delay > float64(r.MaxBackoff) {
+		delay = float64(r.MaxBackoff)
+	}
+
+	return time.Duration(delay * (1 - r.Jitter*random))
+}
+
+// commitError marks a failed COMMIT, after which the transaction may or may
+// not have been applied, so that it isn't retried.
+type commitError struct {
+	err error
+}
+
+func (e *commitError) Error() string {
+	return fmt.Sprintf("[commit][err:%s]", e.err)
+}
+
+func (e *commitError) Unwrap() error {
+	return e.err
+}
+
+// isTransient reports whether running the operation again may succeed.
+func isTransient(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected
+	}
+
+	var commitErr *commitError
+	if errors.As(err, &commitErr) {
+		return false
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// noRetryKey marks a context whose operations must not be retried.
+type noRetryKey struct{}
+
+// withoutRetry disables the retries of the operations run with the returned
+// context, for the ones that can't run twice.
+func withoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// withRetry runs fn following the retry policy of p, logging each retry. When
+// ctx is done while waiting, the last error is returned along with ctx's.
+func (p *Postgres) withRetry(ctx context.Context, operation string, fn func() error) error {
+	if ctx.Value(noRetryKey{}) != nil {
+		return fn()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.retry.MaxAttempts || !isTransient(err) {
+			return err
+		}
+
+		delay := p.retry.backoff(attempt, rand.Float64())
+		logger.GetLogger(ctx).Warnf(
+			"[postgres][%s][retry][attempt:%d/%d][delay:%s][err:%s]",
+			operation, attempt, p.retry.MaxAttempts, delay, err.Error(),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
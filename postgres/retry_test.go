@@ -0,0 +1,312 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	type args struct {
+		attempt int
+		random  float64
+	}
+	type test struct {
+		name   string
+		policy RetryPolicy
+		args   args
+		want   time.Duration
+	}
+
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+
+	tests := []test{
+		{
+			name:   "FirstAttempt",
+			policy: policy,
+			args:   args{attempt: 1},
+			want:   100 * time.Millisecond,
+		},
+		{
+			name:   "Exponential",
+			policy: policy,
+			args:   args{attempt: 3},
+			want:   400 * time.Millisecond,
+		},
+		{
+			name:   "CappedByMaxBackoff",
+			policy: policy,
+			args:   args{attempt: 10},
+			want:   time.Second,
+		},
+		{
+			name:   "Jitter",
+			policy: policy,
+			args:   args{attempt: 2, random: 0.5},
+			want:   150 * time.Millisecond,
+		},
+		{
+			name:   "ConstantWithoutMultiplier",
+			policy: RetryPolicy{InitialBackoff: 100 * time.Millisecond},
+			args:   args{attempt: 4},
+			want:   100 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.want, tt.policy.backoff(tt.args.attempt, tt.args.random))
+			},
+		)
+	}
+}
+
+func Test_isTransient(t *testing.T) {
+	type test struct {
+		name string
+		err  error
+		want bool
+	}
+
+	tests := []test{
+		{name: "SerializationFailure", err: &pq.Error{Code: serializationFailure}, want: true},
+		{name: "Deadlock", err: &pq.Error{Code: deadlockDetected}, want: true},
+		{name: "ConnectionRefused", err: syscall.ECONNREFUSED, want: true},
+		{name: "ConnectionReset", err: syscall.ECONNRESET, want: true},
+		{name: "UniqueViolation", err: &pq.Error{Code: uniqueViolation}, want: false},
+		{name: "Other", err: errors.New("boom"), want: false},
+		{name: "CommitConnectionReset", err: &commitError{err: syscall.ECONNRESET}, want: false},
+		{name: "CommitSerializationFailure", err: &commitError{err: &pq.Error{Code: serializationFailure}}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.want, isTransient(tt.err))
+			},
+		)
+	}
+}
+
+func TestPostgres_DoTransaction_Retry(t *testing.T) {
+	type test struct {
+		name         string
+		retry        RetryPolicy
+		mock         sqlmock.Sqlmock
+		db           *sql.DB
+		wantAttempts int
+		wantErr      assert.ErrorAssertionFunc
+	}
+
+	del := `DELETE FROM test;`
+	retry := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	serialization := &pq.Error{Code: serializationFailure}
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(del)).WillReturnError(serialization)
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(del)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			return test{
+				name:         "RetriedUntilSuccess",
+				retry:        retry,
+				mock:         mock,
+				db:           db,
+				wantAttempts: 2,
+				wantErr:      assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			for i := 0; i < 3; i++ {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(del)).WillReturnError(serialization)
+				mock.ExpectRollback()
+			}
+
+			return test{
+				name:         "GivesUpAfterMaxAttempts",
+				retry:        retry,
+				mock:         mock,
+				db:           db,
+				wantAttempts: 3,
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, serialization, i...)
+				},
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(del)).WillReturnError(serialization)
+			mock.ExpectRollback()
+
+			return test{
+				name:         "DisabledByDefault",
+				mock:         mock,
+				db:           db,
+				wantAttempts: 1,
+				wantErr:      assert.Error,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(del)).WillReturnError(&pq.Error{Code: uniqueViolation})
+			mock.ExpectRollback()
+
+			return test{
+				name:         "NotTransient",
+				retry:        retry,
+				mock:         mock,
+				db:           db,
+				wantAttempts: 1,
+				wantErr:      assert.Error,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(del)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit().WillReturnError(syscall.ECONNRESET)
+
+			return test{
+				name:         "CommitOutcomeUnknown",
+				retry:        retry,
+				mock:         mock,
+				db:           db,
+				wantAttempts: 1,
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, syscall.ECONNRESET, i...)
+				},
+			}
+		}(),
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				p := &Postgres{
+					db:    tt.db,
+					retry: tt.retry,
+				}
+
+				attempts := 0
+				err := p.DoTransaction(
+					context.Background(), func(tx *sql.Tx) error {
+						attempts++
+						_, err := tx.ExecContext(context.Background(), del)
+
+						return err
+					},
+				)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.wantAttempts, attempts)
+				assert.NoError(t, tt.mock.ExpectationsWereMet())
+			},
+		)
+	}
+}
+
+func TestPostgres_ExecContext_Retry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	p := &Postgres{
+		db:    db,
+		retry: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	}
+
+	del := `DELETE FROM test;`
+	mock.ExpectExec(regexp.QuoteMeta(del)).WillReturnError(syscall.ECONNREFUSED)
+	mock.ExpectExec(regexp.QuoteMeta(del)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	result, err := p.ExecContext(context.Background(), del)
+	assert.NoError(t, err)
+
+	affected, err := result.RowsAffected()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_QueryContext_RetryCanceled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	p := &Postgres{
+		db:    db,
+		retry: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM test;`)).WillReturnError(syscall.ECONNRESET)
+
+	_, err = p.QueryContext(ctx, `SELECT id FROM test;`)
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_WithinTransaction_Retry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	p := &Postgres{
+		db:    db,
+		retry: RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	}
+
+	del := `DELETE FROM test;`
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(del)).WillReturnError(&pq.Error{Code: deadlockDetected})
+		mock.ExpectRollback()
+	}
+
+	attempts := 0
+	err = p.WithinTransaction(
+		context.Background(), func(ctx context.Context) error {
+			attempts++
+			_, err := p.ExecContext(ctx, del)
+
+			return err
+		},
+	)
+
+	assert.Error(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/lib/pq"
+)
+
+// taggedFields returns the columns named by the `sql` tags of t, in field
+// order, with the index of each field. Embedded structs are flattened.
+func taggedFields(t reflect.Type) ([]string, [][]int) {
+	columns := make([]string, 0, t.NumField())
+	indexes := make([][]int, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			embeddedColumns, embeddedIndexes := taggedFields(field.Type)
+			for j := range embeddedIndexes {
+				embeddedIndexes[j] = append([]int{i}, embeddedIndexes[j]...)
+			}
+
+			columns = append(columns, embeddedColumns...)
+			indexes = append(indexes, embeddedIndexes...)
+
+			continue
+		}
+
+		column, ok := field.Tag.Lookup("sql")
+		if !ok || column == "-" {
+			continue
+		}
+
+		columns = append(columns, column)
+		indexes = append(indexes, field.Index)
+	}
+
+	return columns, indexes
+}
+
+func columnsOf[T any]() []string {
+	var item T
+
+	columns, _ := taggedFields(reflect.TypeOf(item))
+
+	return columns
+}
+
+// scanRows reads every row into a T, matching result columns to the `sql`
+// tags of its fields. NULL strings are read as empty strings.
+func scanRows[T any](rows Rows) ([]T, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var item T
+
+	taggedColumns, indexes := taggedFields(reflect.TypeOf(item))
+	fields := make(map[string][]int, len(taggedColumns))
+	for i, column := range taggedColumns {
+		fields[column] = indexes[i]
+	}
+
+	items := make([]T, 0)
+	for rows.Next() {
+		var (
+			item         T
+			stringFields = make(map[int]reflect.Value)
+			dest         = make([]interface{}, len(columns))
+		)
+
+		value := reflect.ValueOf(&item).Elem()
+		for i, column := range columns {
+			index, ok := fields[column]
+			if !ok {
+				return nil, fmt.Errorf("[column:%s][err:no field tagged]", column)
+			}
+
+			field := value.FieldByIndex(index)
+			if field.Kind() == reflect.String {
+				stringFields[i] = field
+				dest[i] = &sql.NullString{}
+
+				continue
+			}
+
+			dest[i] = field.Addr().Interface()
+		}
+
+		err = rows.Scan(dest...)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, field := range stringFields {
+			field.SetString(dest[i].(*sql.NullString).String)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func quoteColumns(columns []string) []string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(column)
+	}
+
+	return quoted
+}
+
+// qualifyColumns quotes the columns and prefixes them with a table alias.
+func qualifyColumns(alias string, columns []string) []string {
+	qualified := quoteColumns(columns)
+	for i, column := range qualified {
+		qualified[i] = alias + "." + column
+	}
+
+	return qualified
+}
+
+func int64s(ids []uint64) []int64 {
+	values := make([]int64, len(ids))
+	for i, id := range ids {
+		values[i] = int64(id)
+	}
+
+	return values
+}
+
+func scanIDs(rows Rows) ([]uint64, error) {
+	defer rows.Close()
+
+	ids := make([]uint64, 0)
+	for rows.Next() {
+		var id uint64
+
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// missingIDs returns the ids of want that are not in got, without duplicates.
+func missingIDs(want, got []uint64) []uint64 {
+	found := make(map[uint64]bool, len(got))
+	for _, id := range got {
+		found[id] = true
+	}
+
+	missing := make([]uint64, 0)
+	for _, id := range want {
+		if !found[id] {
+			missing = append(missing, id)
+			found[id] = true
+		}
+	}
+
+	return missing
+}
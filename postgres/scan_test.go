@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func Test_columnsOf(t *testing.T) {
+	assert.Equal(t, []string{"id", "category_id", "name", "description", "schedule", "target_value", "target_unit", "target_comparison", "target_period", "status", "created_at", "updated_at", "deleted_at", "version"}, columnsOf[habit_tracker.Habit]())
+	assert.Equal(t, []string{"id", "category_name", "created_at", "updated_at", "deleted_at", "version"}, columnsOf[habit_tracker.HabitCategory]())
+}
+
+func Test_scanRows(t *testing.T) {
+	type test struct {
+		name    string
+		columns []string
+		rows    [][]driver.Value
+		want    habit_tracker.HabitCategories
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	tests := []test{
+		{
+			name:    "Success",
+			columns: []string{"id", "category_name"},
+			rows:    [][]driver.Value{{1, "Health"}, {2, nil}},
+			want:    habit_tracker.HabitCategories{{ID: 1, CategoryName: "Health"}, {ID: 2}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "ErrorUnknownColumn",
+			columns: []string{"id", "unknown"},
+			rows:    [][]driver.Value{{1, "Health"}},
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				db, mock, err := sqlmock.New()
+				assert.NoError(t, err)
+
+				rows := sqlmock.NewRows(tt.columns)
+				for _, row := range tt.rows {
+					rows.AddRow(row...)
+				}
+				mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+				sqlRows, err := db.Query("SELECT")
+				assert.NoError(t, err)
+
+				got, err := scanRows[habit_tracker.HabitCategory](sqlRows)
+
+				tt.wantErr(t, err)
+				if tt.want != nil {
+					assert.Equal(t, tt.want, habit_tracker.HabitCategories(got))
+				}
+			},
+		)
+	}
+}
+
+func Test_missingIDs(t *testing.T) {
+	type args struct {
+		want []uint64
+		got  []uint64
+	}
+	tests := []struct {
+		name string
+		args args
+		want []uint64
+	}{
+		{
+			name: "NoneMissing",
+			args: args{
+				want: []uint64{1, 2},
+				got:  []uint64{2, 1},
+			},
+			want: []uint64{},
+		},
+		{
+			name: "Missing",
+			args: args{
+				want: []uint64{1, 2, 3, 3},
+				got:  []uint64{2},
+			},
+			want: []uint64{1, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				assert.Equal(t, tt.want, missingIDs(tt.args.want, tt.args.got))
+			},
+		)
+	}
+}
@@ -2,10 +2,13 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"habit-tracker"
 )
 
@@ -20,43 +23,228 @@ func NewTagRepository(db Drivers) habit_tracker.TagRepository {
 }
 
 func (tr *TagRepository) InsertTags(ctx context.Context, tags habit_tracker.Tags, now time.Time) error {
-	_, err := tr.db.ExecContext(ctx, buildInsertTagsQuery(tags, now))
+	if len(tags) == 0 {
+		return nil
+	}
+
+	query, args := buildInsertTagsQuery(tags, now)
+	inserted, err := execInsert[insertedRow](ctx, tr.db, query, args, len(tags))
 	if err != nil {
-		return fmt.Errorf("[err:%w]", err)
+		return classifyError(tagsTable, err)
+	}
+
+	for i, row := range inserted {
+		tags[i].ID = row.ID
+		tags[i].CreatedAt = row.CreatedAt
+		tags[i].UpdatedAt = row.UpdatedAt
+		tags[i].Version = row.Version
 	}
 
 	return nil
 }
 
 func (tr *TagRepository) UpdateTags(ctx context.Context, tags habit_tracker.Tags, now time.Time) error {
-	_, err := tr.db.ExecContext(ctx, buildUpdateTagsQuery(tags, now))
+	if len(tags) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+
+	query, args := buildUpdateTagsQuery(tags, now)
+	versions, err := execVersionedUpdate(ctx, tr.db, tagsTable, ids, query, args)
+	if err != nil {
+		return classifyError(tagsTable, err, ids...)
+	}
+
+	for i := range tags {
+		version, ok := versions[tags[i].ID]
+		if ok {
+			tags[i].Version = version
+		}
+	}
+
+	return nil
+}
+
+func (tr *TagRepository) DeleteTags(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execSoftDelete(ctx, tr.db, tagsTable, ids, now)
+	if err != nil {
+		return classifyError(tagsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (tr *TagRepository) RestoreTags(ctx context.Context, ids []uint64, now time.Time) error {
+	err := execRestore(ctx, tr.db, tagsTable, ids, now)
+	if err != nil {
+		return classifyError(tagsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (tr *TagRepository) PurgeTags(ctx context.Context, ids []uint64) error {
+	err := execPurge(ctx, tr.db, tagsTable, ids, purgeHabitTagsQuery)
+	if err != nil {
+		return classifyError(tagsTable, err, ids...)
+	}
+
+	return nil
+}
+
+func (tr *TagRepository) AttachTags(ctx context.Context, habitTags habit_tracker.HabitTags) error {
+	if len(habitTags) == 0 {
+		return nil
+	}
+
+	query, args := buildHabitTagsQuery(attachTagsQuery, habitTags)
+	_, err := tr.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return classifyError(habitTagsTable, err)
+	}
+
+	return nil
+}
+
+func (tr *TagRepository) DetachTags(ctx context.Context, habitTags habit_tracker.HabitTags) error {
+	if len(habitTags) == 0 {
+		return nil
+	}
+
+	query, args := buildHabitTagsQuery(detachTagsQuery, habitTags)
+	_, err := tr.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return classifyError(habitTagsTable, err)
+	}
+
+	return nil
+}
+
+func (tr *TagRepository) ReplaceHabitTags(ctx context.Context, habitID uint64, tagIDs []uint64) error {
+	err := tr.db.DoTransaction(
+		ctx, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, deleteHabitTagsQuery, habitID)
+			if err != nil {
+				return err
+			}
+
+			if len(tagIDs) == 0 {
+				return nil
+			}
+
+			_, err = tx.ExecContext(ctx, insertHabitTagsQuery, habitID, pq.Array(int64s(tagIDs)))
+
+			return err
+		},
+	)
 	if err != nil {
-		return fmt.Errorf("[err:%w]", err)
+		return classifyError(habitTagsTable, err, habitID)
 	}
 
 	return nil
 }
 
-func buildInsertTagsQuery(tags habit_tracker.Tags, now time.Time) string {
-	str := strings.Builder{}
-	nowStr := now.Format(time.RFC3339)
+func (tr *TagRepository) ListTagsByHabitIDs(ctx context.Context,
+	habitIDs []uint64) (map[uint64]habit_tracker.Tags, error) {
+	tags := make(map[uint64]habit_tracker.Tags)
+	if len(habitIDs) == 0 {
+		return tags, nil
+	}
+
+	rows, err := tr.db.QueryContext(ctx, buildListTagsByHabitIDsQuery(), pq.Array(int64s(habitIDs)))
+	if err != nil {
+		return nil, classifyError(habitTagsTable, err, habitIDs...)
+	}
+
+	habitTags, err := scanRows[habitTag](rows)
+	if err != nil {
+		return nil, classifyError(habitTagsTable, err, habitIDs...)
+	}
+
+	for _, habitTag := range habitTags {
+		tags[habitTag.HabitID] = append(tags[habitTag.HabitID], habitTag.Tag)
+	}
+
+	return tags, nil
+}
+
+func buildInsertTagsQuery(tags habit_tracker.Tags, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(tags)*4)
 	for _, tag := range tags {
-		str.WriteString(
-			fmt.Sprintf("('%s', '%s', '%s', '%s')", tag.Name, tag.Description, nowStr, nowStr),
-		)
+		args = append(args, tag.Name, tag.Description, now, now)
 	}
 
-	return fmt.Sprintf(insertTagsQuery, strings.TrimSuffix(str.String(), ", "))
+	return fmt.Sprintf(insertTagsQuery, buildValues(len(tags), 4)), args
 }
 
-func buildUpdateTagsQuery(tags habit_tracker.Tags, now time.Time) string {
-	str := strings.Builder{}
-	nowStr := now.Format(time.RFC3339)
+func buildUpdateTagsQuery(tags habit_tracker.Tags, now time.Time) (string, []interface{}) {
+	args := make([]interface{}, 0, len(tags)*len(updateTagsTypes))
 	for _, tag := range tags {
-		str.WriteString(
-			fmt.Sprintf(UpdateTagsQuery, tag.Name, tag.Description, nowStr, tag.ID),
-		)
+		args = append(args, tag.ID, tag.Name, tag.Description, now, tag.Version)
+	}
+
+	return fmt.Sprintf(UpdateTagsQuery, buildTypedValues(len(tags), updateTagsTypes)), args
+}
+
+func (tr *TagRepository) GetTagByID(ctx context.Context, id uint64) (habit_tracker.Tag, error) {
+	tag, err := selectByID[habit_tracker.Tag](ctx, tr.db, tagsTable, id)
+	if err != nil {
+		return tag, classifyError(tagsTable, err, id)
 	}
 
-	return str.String()
+	return tag, nil
+}
+
+func (tr *TagRepository) GetTagsByIDs(ctx context.Context, ids []uint64) (habit_tracker.Tags, error) {
+	tags, err := selectByIDs[habit_tracker.Tag](ctx, tr.db, tagsTable, ids)
+	if err != nil {
+		return nil, classifyError(tagsTable, err, ids...)
+	}
+
+	return tags, nil
+}
+
+func (tr *TagRepository) ListTags(ctx context.Context, filter habit_tracker.TagFilter) (habit_tracker.Tags, error) {
+	tags, err := selectRows[habit_tracker.Tag](ctx, tr.db, tagsTable, buildTagsWhere(filter), filter.Page)
+	if err != nil {
+		return nil, classifyError(tagsTable, err)
+	}
+
+	return tags, nil
+}
+
+func buildTagsWhere(filter habit_tracker.TagFilter) whereClause {
+	where := whereClause{}
+	where.addPrefix("name", filter.NamePrefix)
+
+	if !filter.IncludeDeleted {
+		where.notDeleted()
+	}
+
+	return where
+}
+
+// habitTag is a tag read along with the habit it's attached to.
+type habitTag struct {
+	HabitID uint64 `sql:"habit_id"`
+	habit_tracker.Tag
+}
+
+func buildHabitTagsQuery(query string, habitTags habit_tracker.HabitTags) (string, []interface{}) {
+	args := make([]interface{}, 0, len(habitTags)*len(habitTagsTypes))
+	for _, habitTag := range habitTags {
+		args = append(args, habitTag.HabitID, habitTag.TagID)
+	}
+
+	return fmt.Sprintf(query, buildTypedValues(len(habitTags), habitTagsTypes)), args
+}
+
+func buildListTagsByHabitIDsQuery() string {
+	return fmt.Sprintf(
+		listTagsByHabitIDsQuery, strings.Join(qualifyColumns("t", columnsOf[habit_tracker.Tag]()), ", "),
+	)
 }
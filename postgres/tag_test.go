@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql/driver"
 	"regexp"
 	"testing"
 	"time"
@@ -33,14 +34,22 @@ INSERT
 	INTO
 	tags
 (name, description, created_at, updated_at)
-VALUES ('Health', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12:00:00Z');`
+VALUES ($1, $2, $3, $4)
+RETURNING
+	id, created_at, updated_at, version;`
+	queryArgs := []driver.Value{
+		"Health",
+		"New description",
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+	}
 
 	tests := []test{
 		func() test {
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(insertedRows(1))
 
 			return test{
 				name: "Success",
@@ -66,7 +75,7 @@ VALUES ('Health', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12:00:0
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(assert.AnError)
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
 
 			return test{
 				name: "ErrorExecContext",
@@ -119,19 +128,43 @@ func TestTagRepository_UpdateTags(t *testing.T) {
 
 	query := `
 UPDATE
-	tags
+	tags AS t
 SET
-	"name" = 'Health',
-	description = 'New description',
-	updated_at = '2023-07-30T12:00:00Z'
+	"name" = v.name,
+	description = v.description,
+	updated_at = v.updated_at,
+	version = t.version + 1
+FROM
+	(VALUES ($1::INT, $2::VARCHAR, $3::TEXT, $4::TIMESTAMP, $5::BIGINT)) AS v(id, name, description, updated_at, version)
 WHERE
-	id = 1;`
+	t.id = v.id
+	AND t.deleted_at IS NULL
+	AND t.version = v.version
+RETURNING
+	t.id, t.version;`
+	liveIDs := `
+SELECT
+	id
+FROM
+	tags
+WHERE
+	id = ANY($1)
+	AND deleted_at IS NULL;`
+	queryArgs := []driver.Value{
+		int64(1),
+		"Health",
+		"New description",
+		time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+		int64(1),
+	}
 	tests := []test{
 		func() test {
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(updatedRows(1))
+			mock.ExpectCommit()
 
 			return test{
 				name: "Success",
@@ -147,6 +180,7 @@ WHERE
 							ID:          1,
 							Name:        "Health",
 							Description: "New description",
+							Version:     1,
 						},
 					},
 					now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
@@ -158,7 +192,9 @@ WHERE
 			db, mock, err := sqlmock.New()
 			assert.NoError(t, err)
 
-			mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(assert.AnError)
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnError(assert.AnError)
+			mock.ExpectRollback()
 
 			return test{
 				name: "Success",
@@ -174,6 +210,7 @@ WHERE
 							ID:          1,
 							Name:        "Health",
 							Description: "New description",
+							Version:     1,
 						},
 					},
 					now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
@@ -181,6 +218,43 @@ WHERE
 				wantErr: assert.Error,
 			}
 		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(queryArgs...).WillReturnRows(updatedRows())
+			mock.ExpectQuery(regexp.QuoteMeta(liveIDs)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectRollback()
+
+			return test{
+				name: "ErrorStale",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					tags: habit_tracker.Tags{
+						{
+							ID:          1,
+							Name:        "Health",
+							Description: "New description",
+							Version:     1,
+						},
+					},
+					now: time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					var classified *habit_tracker.Error
+
+					return assert.ErrorIs(t, err, habit_tracker.ErrConflict, i...) &&
+						assert.ErrorAs(t, err, &classified, i...) &&
+						assert.Equal(t, []uint64{1}, classified.IDs, i...)
+				},
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(
@@ -201,9 +275,10 @@ func Test_buildInsertTagsQuery(t *testing.T) {
 		now  time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			name: "Success",
@@ -222,14 +297,25 @@ INSERT
 	INTO
 	tags
 (name, description, created_at, updated_at)
-VALUES ('Health', 'New description', '2023-07-30T12:00:00Z', '2023-07-30T12:00:00Z');`,
+VALUES ($1, $2, $3, $4)
+RETURNING
+	id, created_at, updated_at, version;`,
+			wantArgs: []interface{}{
+				"Health",
+				"New description",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				assert.Equal(t, tt.want, buildInsertTagsQuery(tt.args.tags, tt.args.now))
+				got, gotArgs := buildInsertTagsQuery(tt.args.tags, tt.args.now)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
 			},
 		)
 	}
@@ -241,9 +327,10 @@ func Test_buildUpdateTagsQuery(t *testing.T) {
 		now  time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			name: "Success",
@@ -259,21 +346,487 @@ func Test_buildUpdateTagsQuery(t *testing.T) {
 			},
 			want: `
 UPDATE
-	tags
+	tags AS t
 SET
-	"name" = 'Health',
-	description = 'New description',
-	updated_at = '2023-07-30T12:00:00Z'
+	"name" = v.name,
+	description = v.description,
+	updated_at = v.updated_at,
+	version = t.version + 1
+FROM
+	(VALUES ($1::INT, $2::VARCHAR, $3::TEXT, $4::TIMESTAMP, $5::BIGINT)) AS v(id, name, description, updated_at, version)
 WHERE
-	id = 1;`,
+	t.id = v.id
+	AND t.deleted_at IS NULL
+	AND t.version = v.version
+RETURNING
+	t.id, t.version;`,
+			wantArgs: []interface{}{
+				uint64(1),
+				"Health",
+				"New description",
+				time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC),
+				uint64(0),
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				assert.Equal(t, tt.want, buildUpdateTagsQuery(tt.args.tags, tt.args.now))
+				got, gotArgs := buildUpdateTagsQuery(tt.args.tags, tt.args.now)
+
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantArgs, gotArgs)
+			},
+		)
+	}
+}
+
+func TestTagRepository_GetTagByID(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx context.Context
+		id  uint64
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		want    habit_tracker.Tag
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+SELECT
+	"id", "name", "description", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	tags
+WHERE
+	id = $1
+	AND deleted_at IS NULL
+ORDER BY
+	id;`
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1).WillReturnRows(
+				sqlmock.NewRows([]string{"id", "name", "description"}).AddRow(1, "Health", "New description"),
+			)
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					id:  1,
+				},
+				want: habit_tracker.Tag{
+					ID:          1,
+					Name:        "Health",
+					Description: "New description",
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1).WillReturnRows(
+				sqlmock.NewRows([]string{"id", "name", "description"}),
+			)
+
+			return test{
+				name: "NotFound",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx: context.Background(),
+					id:  1,
+				},
+				wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, habit_tracker.ErrNotFound, i...)
+				},
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				tr := NewTagRepository(tt.fields.db)
+				got, err := tr.GetTagByID(tt.args.ctx, tt.args.id)
+
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
 			},
 		)
 	}
 }
+
+func TestTagRepository_ListTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	"id", "name", "description", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	tags
+WHERE
+	"name" LIKE $1
+	AND deleted_at IS NULL
+ORDER BY
+	id
+LIMIT $2;`)).WithArgs(`He%`, 1).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "description"}).AddRow(1, "Health", "New description"),
+	)
+
+	tr := NewTagRepository(&Postgres{db: db})
+	got, err := tr.ListTags(
+		context.Background(),
+		habit_tracker.TagFilter{
+			NamePrefix: "He",
+			Page: habit_tracker.Page{
+				Limit: 1,
+			},
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, habit_tracker.Tags{{ID: 1, Name: "Health", Description: "New description"}}, got)
+}
+
+func TestTagRepository_PurgeTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`
+DELETE
+FROM
+	habit_tags
+WHERE
+	tag_id = ANY($1);`)).WithArgs("{1}").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(regexp.QuoteMeta(`
+DELETE
+FROM
+	tags
+WHERE
+	id = ANY($1)
+RETURNING
+	id;`)).WithArgs("{1}").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	tr := NewTagRepository(&Postgres{db: db})
+
+	assert.NoError(t, tr.PurgeTags(context.Background(), []uint64{1}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_ListTags_IncludeDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	deletedAt := time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	"id", "name", "description", "created_at", "updated_at", "deleted_at", "version"
+FROM
+	tags
+ORDER BY
+	id;`)).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "description", "deleted_at"}).
+			AddRow(1, "Health", "New description", nil).
+			AddRow(2, "Old", "Old description", deletedAt),
+	)
+
+	tr := NewTagRepository(&Postgres{db: db})
+	got, err := tr.ListTags(context.Background(), habit_tracker.TagFilter{IncludeDeleted: true})
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		habit_tracker.Tags{
+			{ID: 1, Name: "Health", Description: "New description"},
+			{ID: 2, Name: "Old", Description: "Old description", DeletedAt: &deletedAt},
+		},
+		got,
+	)
+}
+
+func TestTagRepository_AttachTags(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx       context.Context
+		habitTags habit_tracker.HabitTags
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	query := `
+INSERT
+	INTO
+	habit_tags
+(habit_id, tag_id)
+VALUES ($1::INT, $2::INT), ($3::INT, $4::INT)
+ON CONFLICT DO NOTHING;`
+	habitTags := habit_tracker.HabitTags{
+		{
+			HabitID: 1,
+			TagID:   2,
+		},
+		{
+			HabitID: 1,
+			TagID:   3,
+		},
+	}
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(1, 2, 1, 3).WillReturnResult(sqlmock.NewResult(0, 2))
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:       context.Background(),
+					habitTags: habitTags,
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(1, 2, 1, 3).WillReturnError(assert.AnError)
+
+			return test{
+				name: "ErrorExecContext",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:       context.Background(),
+					habitTags: habitTags,
+				},
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				tr := NewTagRepository(tt.fields.db)
+
+				tt.wantErr(t, tr.AttachTags(tt.args.ctx, tt.args.habitTags))
+			},
+		)
+	}
+}
+
+func TestTagRepository_DetachTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta(`
+DELETE
+FROM
+	habit_tags AS ht
+USING
+	(VALUES ($1::INT, $2::INT)) AS v(habit_id, tag_id)
+WHERE
+	ht.habit_id = v.habit_id
+	AND ht.tag_id = v.tag_id;`)).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tr := NewTagRepository(&Postgres{db: db})
+
+	assert.NoError(t, tr.DetachTags(context.Background(), habit_tracker.HabitTags{{HabitID: 1, TagID: 2}}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_ReplaceHabitTags(t *testing.T) {
+	type fields struct {
+		db Drivers
+	}
+	type args struct {
+		ctx     context.Context
+		habitID uint64
+		tagIDs  []uint64
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	deleteQuery := `
+DELETE
+FROM
+	habit_tags
+WHERE
+	habit_id = $1;`
+	insertQuery := `
+INSERT
+	INTO
+	habit_tags
+(habit_id, tag_id)
+SELECT
+	$1,
+	UNNEST($2::INT[])
+ON CONFLICT DO NOTHING;`
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(insertQuery)).WithArgs(1, "{2,3}").WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectCommit()
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:     context.Background(),
+					habitID: 1,
+					tagIDs:  []uint64{2, 3},
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			return test{
+				name: "ClearTags",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:     context.Background(),
+					habitID: 1,
+				},
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta(insertQuery)).WithArgs(1, "{2,3}").WillReturnError(assert.AnError)
+			mock.ExpectRollback()
+
+			return test{
+				name: "ErrorInsert",
+				fields: fields{
+					db: &Postgres{
+						db: db,
+					},
+				},
+				args: args{
+					ctx:     context.Background(),
+					habitID: 1,
+					tagIDs:  []uint64{2, 3},
+				},
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				tr := NewTagRepository(tt.fields.db)
+
+				tt.wantErr(t, tr.ReplaceHabitTags(tt.args.ctx, tt.args.habitID, tt.args.tagIDs))
+			},
+		)
+	}
+}
+
+func TestTagRepository_ListTagsByHabitIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+SELECT
+	ht.habit_id, t."id", t."name", t."description", t."created_at", t."updated_at", t."deleted_at", t."version"
+FROM
+	habit_tags AS ht
+	JOIN tags AS t ON t.id = ht.tag_id
+WHERE
+	ht.habit_id = ANY($1)
+	AND t.deleted_at IS NULL
+ORDER BY
+	ht.habit_id, t.id;`)).WithArgs("{1,2}").WillReturnRows(
+		sqlmock.NewRows([]string{"habit_id", "id", "name", "description", "deleted_at"}).
+			AddRow(1, 5, "Health", "New description", nil).
+			AddRow(1, 6, "Morning", "Before work", nil).
+			AddRow(2, 5, "Health", "New description", nil),
+	)
+
+	tr := NewTagRepository(&Postgres{db: db})
+	got, err := tr.ListTagsByHabitIDs(context.Background(), []uint64{1, 2})
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		map[uint64]habit_tracker.Tags{
+			1: {
+				{ID: 5, Name: "Health", Description: "New description"},
+				{ID: 6, Name: "Morning", Description: "Before work"},
+			},
+			2: {
+				{ID: 5, Name: "Health", Description: "New description"},
+			},
+		},
+		got,
+	)
+}
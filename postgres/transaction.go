@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type txKey struct{}
+
+// ambientTx is the transaction carried by the context of a unit of work.
+// depth counts the savepoints open above it.
+type ambientTx struct {
+	tx    *sql.Tx
+	depth int
+}
+
+func txFromContext(ctx context.Context) (*ambientTx, bool) {
+	atx, ok := ctx.Value(txKey{}).(*ambientTx)
+
+	return atx, ok
+}
+
+// WithinTransaction runs fn in a transaction carried by its context. Every
+// repository built on p and called with that context joins it. When ctx
+// already carries a transaction, fn runs inside a savepoint instead, so a
+// failure only rolls back the work done by fn.
+func (p *Postgres) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	atx, ok := txFromContext(ctx)
+	if ok {
+		return atx.savepoint(
+			ctx,
+			func(tx *sql.Tx) error {
+				return fn(context.WithValue(ctx, txKey{}, &ambientTx{tx: tx, depth: atx.depth + 1}))
+			},
+		)
+	}
+
+	return p.DoTransaction(
+		ctx,
+		func(tx *sql.Tx) error {
+			return fn(context.WithValue(ctx, txKey{}, &ambientTx{tx: tx}))
+		},
+	)
+}
+
+// savepoint runs fnStmt between a SAVEPOINT and its RELEASE, rolling back to
+// the savepoint when fnStmt fails or panics.
+func (atx *ambientTx) savepoint(ctx context.Context, fnStmt ExecStmt) error {
+	name := fmt.Sprintf("sp_%d", atx.depth+1)
+
+	_, err := atx.tx.ExecContext(ctx, "SAVEPOINT "+name)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_, _ = atx.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(r)
+		}
+	}()
+
+	err = fnStmt(atx.tx)
+	if err != nil {
+		_, rollbackError := atx.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		if rollbackError != nil {
+			return rollbackError
+		}
+
+		return err
+	}
+
+	_, err = atx.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+
+	return err
+}
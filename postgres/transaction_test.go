@@ -0,0 +1,230 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func TestPostgres_WithinTransaction(t *testing.T) {
+	type fields struct {
+		db *sql.DB
+	}
+	type args struct {
+		fn func(p *Postgres) func(ctx context.Context) error
+	}
+	type test struct {
+		name    string
+		fields  fields
+		args    args
+		mock    sqlmock.Sqlmock
+		wantErr assert.ErrorAssertionFunc
+	}
+
+	insert := `INSERT INTO test (name) VALUES ($1);`
+
+	tests := []test{
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(insert)).WithArgs("a").WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM test;`)).WillReturnRows(
+				sqlmock.NewRows([]string{"id"}).AddRow(1),
+			)
+			mock.ExpectCommit()
+
+			return test{
+				name: "Success",
+				fields: fields{
+					db: db,
+				},
+				args: args{
+					fn: func(p *Postgres) func(ctx context.Context) error {
+						return func(ctx context.Context) error {
+							_, err := p.ExecContext(ctx, insert, "a")
+							if err != nil {
+								return err
+							}
+
+							rows, err := p.QueryContext(ctx, `SELECT id FROM test;`)
+							if err != nil {
+								return err
+							}
+
+							return rows.Close()
+						}
+					},
+				},
+				mock:    mock,
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(insert)).WithArgs("a").WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(regexp.QuoteMeta(insert)).WithArgs("b").WillReturnError(assert.AnError)
+			mock.ExpectRollback()
+
+			return test{
+				name: "ErrorRollsBack",
+				fields: fields{
+					db: db,
+				},
+				args: args{
+					fn: func(p *Postgres) func(ctx context.Context) error {
+						return func(ctx context.Context) error {
+							_, err := p.ExecContext(ctx, insert, "a")
+							if err != nil {
+								return err
+							}
+
+							_, err = p.ExecContext(ctx, insert, "b")
+
+							return err
+						}
+					},
+				},
+				mock:    mock,
+				wantErr: assert.Error,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT sp_1`)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(insert)).WithArgs("a").WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT sp_2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(insert)).WithArgs("b").WillReturnError(assert.AnError)
+			mock.ExpectExec(regexp.QuoteMeta(`ROLLBACK TO SAVEPOINT sp_2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(`RELEASE SAVEPOINT sp_1`)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+
+			return test{
+				name: "NestedErrorRollsBackToSavepoint",
+				fields: fields{
+					db: db,
+				},
+				args: args{
+					fn: func(p *Postgres) func(ctx context.Context) error {
+						return func(ctx context.Context) error {
+							return p.WithinTransaction(
+								ctx,
+								func(ctx context.Context) error {
+									_, err := p.ExecContext(ctx, insert, "a")
+									if err != nil {
+										return err
+									}
+
+									err = p.WithinTransaction(
+										ctx,
+										func(ctx context.Context) error {
+											_, err := p.ExecContext(ctx, insert, "b")
+
+											return err
+										},
+									)
+									assert.ErrorIs(t, err, assert.AnError)
+
+									return nil
+								},
+							)
+						}
+					},
+				},
+				mock:    mock,
+				wantErr: assert.NoError,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`
+INSERT
+	INTO
+	habit_categories
+(category_name, created_at, updated_at)
+VALUES ($1, $2, $3)
+RETURNING
+	id, created_at, updated_at, version;`)).WillReturnRows(insertedRows(1))
+			mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT sp_1`)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(deleteHabitTagsQuery)).WithArgs(1).WillReturnError(assert.AnError)
+			mock.ExpectExec(regexp.QuoteMeta(`ROLLBACK TO SAVEPOINT sp_1`)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			return test{
+				name: "RepositoriesJoinTransaction",
+				fields: fields{
+					db: db,
+				},
+				args: args{
+					fn: func(p *Postgres) func(ctx context.Context) error {
+						return func(ctx context.Context) error {
+							err := NewHabitRepository(p).InsertHabitCategories(
+								ctx,
+								habit_tracker.HabitCategories{{CategoryName: "Health"}},
+								insertedAt,
+							)
+							if err != nil {
+								return err
+							}
+
+							return NewTagRepository(p).ReplaceHabitTags(ctx, 1, []uint64{2})
+						}
+					},
+				},
+				mock:    mock,
+				wantErr: assert.Error,
+			}
+		}(),
+		func() test {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			mock.ExpectBegin().WillReturnError(assert.AnError)
+
+			return test{
+				name: "ErrorBeginTx",
+				fields: fields{
+					db: db,
+				},
+				args: args{
+					fn: func(p *Postgres) func(ctx context.Context) error {
+						return func(ctx context.Context) error {
+							return nil
+						}
+					},
+				},
+				mock:    mock,
+				wantErr: assert.Error,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				p := &Postgres{
+					db: tt.fields.db,
+				}
+
+				tt.wantErr(t, p.WithinTransaction(context.Background(), tt.args.fn(p)))
+				assert.NoError(t, tt.mock.ExpectationsWereMet())
+			},
+		)
+	}
+}
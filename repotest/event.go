@@ -0,0 +1,150 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"habit-tracker"
+)
+
+func TestEventRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run(
+		"InsertThenRead", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			events := habit_tracker.Events{
+				{HabitID: habit.ID, Subject: "Run", StartAt: now, EndAt: later},
+				{HabitID: habit.ID, Subject: "Swim", StartAt: later, EndAt: later.Add(time.Hour)},
+			}
+			require.NoError(t, repos.Events.InsertEvents(ctx, events, now))
+			for _, inserted := range events {
+				assertGenerated(t, inserted.ID, inserted.CreatedAt, inserted.UpdatedAt)
+			}
+
+			got, err := repos.Events.GetEventByID(ctx, events[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, habit.ID, got.HabitID)
+			assert.Equal(t, "Run", got.Subject)
+			assert.True(t, now.Equal(got.StartAt))
+			assert.True(t, later.Equal(got.EndAt))
+
+			listed, err := repos.Events.ListEvents(ctx, habit_tracker.EventFilter{From: later, To: later.Add(time.Minute)})
+			require.NoError(t, err)
+			require.Len(t, listed, 1, "events ending at From don't overlap the window")
+			assert.Equal(t, events[1].ID, listed[0].ID)
+		},
+	)
+
+	t.Run(
+		"TimeZones", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			events := habit_tracker.Events{
+				{HabitID: habit.ID, Subject: "Run", StartAt: now.In(elsewhere), EndAt: later.In(elsewhere)},
+			}
+			require.NoError(t, repos.Events.InsertEvents(ctx, events, now))
+
+			got, err := repos.Events.GetEventByID(ctx, events[0].ID)
+			require.NoError(t, err)
+			assert.True(t, now.Equal(got.StartAt))
+			assert.True(t, later.Equal(got.EndAt))
+
+			listed, err := repos.Events.ListEvents(
+				ctx, habit_tracker.EventFilter{From: later.Add(-time.Minute).In(elsewhere), To: later.In(elsewhere)},
+			)
+			require.NoError(t, err)
+			require.Len(t, listed, 1)
+			assert.Equal(t, events[0].ID, listed[0].ID)
+
+			listed, err = repos.Events.ListEvents(ctx, habit_tracker.EventFilter{From: later.In(elsewhere)})
+			require.NoError(t, err)
+			assert.Empty(t, listed)
+		},
+	)
+
+	t.Run(
+		"UpdateBumpsUpdatedAt", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			events := habit_tracker.Events{{HabitID: habit.ID, Subject: "Run", StartAt: now, EndAt: later}}
+			require.NoError(t, repos.Events.InsertEvents(ctx, events, now))
+
+			events[0].Subject = "Walk"
+			require.NoError(t, repos.Events.UpdateEvents(ctx, events, later))
+
+			got, err := repos.Events.GetEventByID(ctx, events[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Walk", got.Subject)
+			assertUpdated(t, got.CreatedAt, got.UpdatedAt)
+		},
+	)
+
+	t.Run(
+		"UnknownIDs", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			_, err := repos.Events.GetEventByID(ctx, unknownID)
+			assertNotFound(t, err)
+			assertNotFound(t, repos.Events.DeleteEvents(ctx, []uint64{unknownID}, later))
+			assertNotFound(t, repos.Events.RestoreEvents(ctx, []uint64{unknownID}, later))
+			assertNotFound(t, repos.Events.PurgeEvents(ctx, []uint64{unknownID}))
+
+			events := habit_tracker.Events{{HabitID: habit.ID, Subject: "Run", StartAt: now, EndAt: later}}
+			require.NoError(t, repos.Events.InsertEvents(ctx, events, now))
+			unknown := habit_tracker.Event{ID: unknownID, HabitID: habit.ID, Subject: "Swim", StartAt: now, EndAt: later}
+			assertNotFound(t, repos.Events.UpdateEvents(ctx, append(events, unknown), later))
+
+			require.NoError(t, repos.Events.DeleteEvents(ctx, []uint64{events[0].ID}, now))
+			assertNotFound(t, repos.Events.UpdateEvents(ctx, events, later))
+		},
+	)
+
+	t.Run(
+		"ForeignKeys", func(t *testing.T) {
+			repos := factory(t)
+
+			assertForeignKey(
+				t, repos.Events.InsertEvents(
+					ctx, habit_tracker.Events{{HabitID: unknownID, Subject: "Run", StartAt: now, EndAt: later}}, now,
+				),
+			)
+		},
+	)
+	t.Run(
+		"BulkLoad", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			loaded, err := repos.Events.LoadEvents(
+				ctx, feed(
+					habit_tracker.Event{HabitID: habit.ID, Subject: "Run", StartAt: now, EndAt: later},
+					habit_tracker.Event{HabitID: habit.ID, Subject: "Swim", StartAt: now, EndAt: later},
+				), now,
+			)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), loaded)
+
+			_, err = repos.Events.LoadEvents(
+				ctx, feed(
+					habit_tracker.Event{HabitID: habit.ID, Subject: "Walk", StartAt: now, EndAt: later},
+					habit_tracker.Event{HabitID: unknownID, Subject: "Ride", StartAt: now, EndAt: later},
+				), now,
+			)
+			assertForeignKey(t, err)
+
+			listed, err := repos.Events.ListEvents(ctx, habit_tracker.EventFilter{HabitID: habit.ID})
+			require.NoError(t, err)
+			assert.Len(t, listed, 2, "a failed load must not leave any row")
+		},
+	)
+}
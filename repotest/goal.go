@@ -0,0 +1,170 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"habit-tracker"
+)
+
+func TestGoalRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run(
+		"InsertThenRead", func(t *testing.T) {
+			repos := factory(t)
+
+			deadline := later
+			goals := habit_tracker.Goals{
+				{
+					Description: "Run 20 times",
+					TargetType:  habit_tracker.GoalTargetCount,
+					TargetValue: 20,
+					WindowStart: &now,
+					Deadline:    &deadline,
+				},
+			}
+			require.NoError(t, repos.Goals.InsertGoals(ctx, goals, now))
+			assertGenerated(t, goals[0].ID, goals[0].CreatedAt, goals[0].UpdatedAt)
+
+			got, err := repos.Goals.GetGoalByID(ctx, goals[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Run 20 times", got.Description)
+			assert.Equal(t, habit_tracker.GoalTargetCount, got.TargetType)
+			assert.Equal(t, float64(20), got.TargetValue)
+			require.NotNil(t, got.WindowStart)
+			assert.True(t, now.Equal(*got.WindowStart))
+			assert.Nil(t, got.WindowEnd)
+			require.NotNil(t, got.Deadline)
+			assert.True(t, later.Equal(*got.Deadline))
+		},
+	)
+
+	t.Run(
+		"UpdateBumpsUpdatedAt", func(t *testing.T) {
+			repos := factory(t)
+
+			goals := habit_tracker.Goals{
+				{Description: "Run", TargetType: habit_tracker.GoalTargetCount, TargetValue: 10},
+			}
+			require.NoError(t, repos.Goals.InsertGoals(ctx, goals, now))
+
+			goals[0].TargetType = habit_tracker.GoalTargetPercentage
+			goals[0].TargetValue = 80
+			require.NoError(t, repos.Goals.UpdateGoals(ctx, goals, later))
+
+			got, err := repos.Goals.GetGoalByID(ctx, goals[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, habit_tracker.GoalTargetPercentage, got.TargetType)
+			assert.Equal(t, float64(80), got.TargetValue)
+			assertUpdated(t, got.CreatedAt, got.UpdatedAt)
+			assert.Equal(t, uint64(2), got.Version)
+		},
+	)
+
+	t.Run(
+		"StaleVersion", func(t *testing.T) {
+			repos := factory(t)
+
+			goals := habit_tracker.Goals{{Description: "Run", TargetValue: 10}}
+			require.NoError(t, repos.Goals.InsertGoals(ctx, goals, now))
+			assert.Equal(t, uint64(1), goals[0].Version)
+
+			device := append(habit_tracker.Goals(nil), goals...)
+
+			goals[0].Description = "Run daily"
+			require.NoError(t, repos.Goals.UpdateGoals(ctx, goals, later))
+			assert.Equal(t, uint64(2), goals[0].Version)
+
+			device[0].Description = "Swim"
+			err := repos.Goals.UpdateGoals(ctx, device, later)
+			assertConflict(t, err, goals[0].ID)
+
+			got, err := repos.Goals.GetGoalByID(ctx, goals[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Run daily", got.Description)
+			assert.Equal(t, uint64(2), got.Version)
+		},
+	)
+
+	t.Run(
+		"InvalidTargets", func(t *testing.T) {
+			repos := factory(t)
+
+			invalid := habit_tracker.Goals{{Description: "Run", TargetType: "foo", TargetValue: 10}}
+			assert.ErrorIs(t, repos.Goals.InsertGoals(ctx, invalid, now), habit_tracker.ErrValidation)
+			invalid = habit_tracker.Goals{{Description: "Run"}}
+			assert.ErrorIs(t, repos.Goals.InsertGoals(ctx, invalid, now), habit_tracker.ErrValidation)
+
+			goals := habit_tracker.Goals{{Description: "Run", TargetValue: 10}}
+			require.NoError(t, repos.Goals.InsertGoals(ctx, goals, now))
+
+			goals[0].TargetValue = -1
+			assert.ErrorIs(t, repos.Goals.UpdateGoals(ctx, goals, later), habit_tracker.ErrValidation)
+
+			got, err := repos.Goals.GetGoalByID(ctx, goals[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, float64(10), got.TargetValue)
+			assert.Equal(t, uint64(1), got.Version)
+		},
+	)
+
+	t.Run(
+		"UnknownIDs", func(t *testing.T) {
+			repos := factory(t)
+
+			_, err := repos.Goals.GetGoalByID(ctx, unknownID)
+			assertNotFound(t, err)
+			assertNotFound(t, repos.Goals.DeleteGoals(ctx, []uint64{unknownID}, later))
+			assertNotFound(t, repos.Goals.PurgeGoals(ctx, []uint64{unknownID}))
+
+			goals := habit_tracker.Goals{{Description: "Run", TargetValue: 10}}
+			require.NoError(t, repos.Goals.InsertGoals(ctx, goals, now))
+			unknown := habit_tracker.Goal{ID: unknownID, TargetValue: 10}
+			assertNotFound(t, repos.Goals.UpdateGoals(ctx, append(goals, unknown), later))
+
+			require.NoError(t, repos.Goals.DeleteGoals(ctx, []uint64{goals[0].ID}, now))
+			assertNotFound(t, repos.Goals.UpdateGoals(ctx, goals, later))
+		},
+	)
+
+	t.Run(
+		"HabitGoals", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			goals := habit_tracker.Goals{{Description: "Run", TargetValue: 10}}
+			require.NoError(t, repos.Goals.InsertGoals(ctx, goals, now))
+
+			habitGoals := habit_tracker.HabitGoals{{HabitID: habit.ID, GoalID: goals[0].ID}}
+			require.NoError(t, repos.Goals.LinkHabits(ctx, habitGoals))
+			require.NoError(t, repos.Goals.LinkHabits(ctx, habitGoals))
+
+			byGoal, err := repos.Goals.ListHabitIDsByGoalIDs(ctx, []uint64{goals[0].ID})
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{habit.ID}, byGoal[goals[0].ID])
+
+			require.NoError(t, repos.Goals.UnlinkHabits(ctx, habitGoals))
+
+			byGoal, err = repos.Goals.ListHabitIDsByGoalIDs(ctx, []uint64{goals[0].ID})
+			require.NoError(t, err)
+			assert.Empty(t, byGoal[goals[0].ID])
+		},
+	)
+
+	t.Run(
+		"ForeignKeys", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			goals := habit_tracker.Goals{{Description: "Run", TargetValue: 10}}
+			require.NoError(t, repos.Goals.InsertGoals(ctx, goals, now))
+
+			assertForeignKey(t, repos.Goals.LinkHabits(ctx, habit_tracker.HabitGoals{{HabitID: habit.ID, GoalID: unknownID}}))
+			assertForeignKey(t, repos.Goals.LinkHabits(ctx, habit_tracker.HabitGoals{{HabitID: unknownID, GoalID: goals[0].ID}}))
+		},
+	)
+}
@@ -0,0 +1,527 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"habit-tracker"
+)
+
+func TestHabitRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run(
+		"InsertThenRead", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			habits := habit_tracker.Habits{
+				{CategoryID: habit.CategoryID, Name: "Read", Description: "Pages"},
+				{CategoryID: habit.CategoryID, Name: "Run", Description: "Km"},
+			}
+			require.NoError(t, repos.Habits.InsertHabits(ctx, habits, now))
+			assert.Less(t, habit.ID, habits[0].ID)
+			assert.Less(t, habits[0].ID, habits[1].ID)
+			for _, inserted := range habits {
+				assertGenerated(t, inserted.ID, inserted.CreatedAt, inserted.UpdatedAt)
+			}
+
+			got, err := repos.Habits.GetHabitByID(ctx, habits[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, habits[0].ID, got.ID)
+			assert.Equal(t, habits[0].CategoryID, got.CategoryID)
+			assert.Equal(t, "Read", got.Name)
+			assert.Equal(t, "Pages", got.Description)
+			assertGenerated(t, got.ID, got.CreatedAt, got.UpdatedAt)
+			assert.Nil(t, got.DeletedAt)
+
+			byIDs, err := repos.Habits.GetHabitsByIDs(ctx, []uint64{habits[1].ID, unknownID, habits[0].ID})
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{habits[0].ID, habits[1].ID}, habitIDs(byIDs))
+
+			listed, err := repos.Habits.ListHabits(ctx, habit_tracker.HabitFilter{NamePrefix: "R"})
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{habits[0].ID, habits[1].ID}, habitIDs(listed))
+
+			listed, err = repos.Habits.ListHabits(
+				ctx, habit_tracker.HabitFilter{
+					CategoryID: habit.CategoryID,
+					Page:       habit_tracker.Page{After: habit.ID, Limit: 1},
+				},
+			)
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{habits[0].ID}, habitIDs(listed))
+
+			records := habit_tracker.HabitRecords{{HabitID: habit.ID, RecordDate: now, Result: "done", Description: "5km"}}
+			require.NoError(t, repos.Habits.InsertHabitRecords(ctx, records, now))
+			assertGenerated(t, records[0].ID, records[0].CreatedAt, records[0].UpdatedAt)
+
+			record, err := repos.Habits.GetHabitRecordByID(ctx, records[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, habit.ID, record.HabitID)
+			assert.True(t, now.Equal(record.RecordDate))
+			assert.Equal(t, habit_tracker.ResultDone, record.Result)
+			assert.Equal(t, "5km", record.Description)
+		},
+	)
+
+	t.Run(
+		"Schedule", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			schedule := habit_tracker.Schedule{
+				Kind:      habit_tracker.ScheduleWeekdays,
+				Weekdays:  []time.Weekday{time.Monday, time.Thursday},
+				StartDate: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC),
+			}
+			habits := habit_tracker.Habits{{CategoryID: habit.CategoryID, Name: "Swim", Schedule: schedule}}
+			require.NoError(t, repos.Habits.InsertHabits(ctx, habits, now))
+
+			got, err := repos.Habits.GetHabitByID(ctx, habits[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, schedule, got.Schedule)
+
+			got.Schedule = habit_tracker.Schedule{Kind: habit_tracker.ScheduleEveryNDays}
+			_, err = repos.Habits.UpdateHabits(ctx, habit_tracker.Habits{got}, later)
+			assert.ErrorIs(t, err, habit_tracker.ErrValidation)
+
+			err = repos.Habits.InsertHabits(
+				ctx, habit_tracker.Habits{{CategoryID: habit.CategoryID, Schedule: habit_tracker.Schedule{Kind: "hourly"}}}, now,
+			)
+			assert.ErrorIs(t, err, habit_tracker.ErrValidation)
+		},
+	)
+
+	t.Run(
+		"Target", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			habits := habit_tracker.Habits{
+				{
+					CategoryID:       habit.CategoryID,
+					Name:             "Drink water",
+					TargetValue:      2.5,
+					TargetUnit:       "l",
+					TargetComparison: habit_tracker.TargetAtLeast,
+					TargetPeriod:     habit_tracker.TargetPerDay,
+				},
+			}
+			require.NoError(t, repos.Habits.InsertHabits(ctx, habits, now))
+
+			got, err := repos.Habits.GetHabitByID(ctx, habits[0].ID)
+			require.NoError(t, err)
+			assert.True(t, got.Measurable())
+			assert.Equal(t, 2.5, got.TargetValue)
+			assert.Equal(t, "l", got.TargetUnit)
+			assert.Equal(t, habit_tracker.TargetAtLeast, got.TargetComparison)
+			assert.Equal(t, habit_tracker.TargetPerDay, got.TargetPeriod)
+
+			got.TargetPeriod = "month"
+			_, err = repos.Habits.UpdateHabits(ctx, habit_tracker.Habits{got}, later)
+			assert.ErrorIs(t, err, habit_tracker.ErrValidation)
+		},
+	)
+
+	t.Run(
+		"RecordValue", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			value := 5.2
+			records := habit_tracker.HabitRecords{
+				{HabitID: habit.ID, RecordDate: now, Result: habit_tracker.ResultPartial, Value: &value, Unit: "km"},
+			}
+			require.NoError(t, repos.Habits.InsertHabitRecords(ctx, records, now))
+
+			got, err := repos.Habits.GetHabitRecordByID(ctx, records[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, habit_tracker.ResultPartial, got.Result)
+			assert.Equal(t, &value, got.Value)
+			assert.Equal(t, "km", got.Unit)
+
+			got.Value = nil
+			_, err = repos.Habits.UpdateHabitRecords(ctx, habit_tracker.HabitRecords{got}, later)
+			assert.ErrorIs(t, err, habit_tracker.ErrValidation)
+
+			err = repos.Habits.InsertHabitRecords(
+				ctx, habit_tracker.HabitRecords{{HabitID: habit.ID, RecordDate: later, Result: "yes"}}, now,
+			)
+			assert.ErrorIs(t, err, habit_tracker.ErrValidation)
+		},
+	)
+
+	t.Run(
+		"Lifecycle", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+			resumed := later.Add(time.Hour)
+			assert.Equal(t, habit_tracker.HabitActive, habit.Status)
+
+			// Inserts ignore the Status of the habits.
+			habits := habit_tracker.Habits{
+				{CategoryID: habit.CategoryID, Name: "Read", Status: habit_tracker.HabitPaused},
+			}
+			require.NoError(t, repos.Habits.InsertHabits(ctx, habits, now))
+			assert.Equal(t, habit_tracker.HabitActive, habits[0].Status)
+
+			got, err := repos.Habits.GetHabitByID(ctx, habits[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, habit_tracker.HabitActive, got.Status)
+
+			require.NoError(t, repos.Habits.PauseHabits(ctx, []uint64{habit.ID}, now))
+			assertConflict(t, repos.Habits.PauseHabits(ctx, []uint64{habit.ID}, now), habit.ID)
+			assertNotFound(t, repos.Habits.PauseHabits(ctx, []uint64{unknownID}, now))
+
+			got, err = repos.Habits.GetHabitByID(ctx, habit.ID)
+			require.NoError(t, err)
+			assert.Equal(t, habit_tracker.HabitPaused, got.Status)
+			assert.Equal(t, habit.Version+1, got.Version)
+
+			got.Name = "Swim"
+			_, err = repos.Habits.UpdateHabits(ctx, habit_tracker.Habits{got}, later)
+			require.NoError(t, err)
+
+			require.NoError(t, repos.Habits.ArchiveHabits(ctx, []uint64{habit.ID}, later))
+			got, err = repos.Habits.GetHabitByID(ctx, habit.ID)
+			require.NoError(t, err)
+			assert.Equal(t, habit_tracker.HabitArchived, got.Status)
+			assert.Equal(t, "Swim", got.Name)
+
+			require.NoError(t, repos.Habits.ResumeHabits(ctx, []uint64{habit.ID}, resumed))
+			assertConflict(t, repos.Habits.ResumeHabits(ctx, []uint64{habit.ID}, resumed), habit.ID)
+			require.NoError(t, repos.Habits.ArchiveHabits(ctx, []uint64{habit.ID}, resumed))
+
+			pauses, err := repos.Habits.ListHabitPauses(ctx, []uint64{habit.ID, unknownID})
+			require.NoError(t, err)
+			require.Len(t, pauses, 1)
+			require.Len(t, pauses[habit.ID], 2)
+			assert.True(t, now.Equal(pauses[habit.ID][0].PausedAt))
+			if assert.NotNil(t, pauses[habit.ID][0].ResumedAt) {
+				assert.True(t, resumed.Equal(*pauses[habit.ID][0].ResumedAt))
+			}
+			assert.True(t, resumed.Equal(pauses[habit.ID][1].PausedAt))
+			assert.Nil(t, pauses[habit.ID][1].ResumedAt)
+
+			due, err := habit_tracker.DueHabits(ctx, repos.Habits, resumed.AddDate(0, 0, 1))
+			require.NoError(t, err)
+			require.Len(t, due, 1)
+			assert.Equal(t, habits[0].ID, due[0].ID)
+		},
+	)
+
+	t.Run(
+		"UpdateBumpsUpdatedAt", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			habit.Name = "Swim"
+			matched, err := repos.Habits.UpdateHabits(ctx, habit_tracker.Habits{habit}, later)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), matched)
+
+			got, err := repos.Habits.GetHabitByID(ctx, habit.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Swim", got.Name)
+			assertUpdated(t, got.CreatedAt, got.UpdatedAt)
+
+			assert.Equal(t, habit.Version+1, got.Version)
+
+			category, err := repos.Habits.GetHabitCategoryByID(ctx, habit.CategoryID)
+			require.NoError(t, err)
+
+			category.CategoryName = "Sport"
+			matched, err = repos.Habits.UpdateHabitCategories(ctx, habit_tracker.HabitCategories{category}, later)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), matched)
+
+			gotCategory, err := repos.Habits.GetHabitCategoryByID(ctx, habit.CategoryID)
+			require.NoError(t, err)
+			assert.Equal(t, "Sport", gotCategory.CategoryName)
+			assertUpdated(t, gotCategory.CreatedAt, gotCategory.UpdatedAt)
+		},
+	)
+
+	t.Run(
+		"StaleVersion", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			renamed := habit
+			renamed.Name = "Swim"
+			_, err := repos.Habits.UpdateHabits(ctx, habit_tracker.Habits{renamed}, later)
+			require.NoError(t, err)
+
+			habit.Name = "Walk"
+			_, err = repos.Habits.UpdateHabits(ctx, habit_tracker.Habits{habit}, later)
+			assertConflict(t, err, habit.ID)
+
+			got, err := repos.Habits.GetHabitByID(ctx, habit.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Swim", got.Name)
+		},
+	)
+
+	t.Run(
+		"RecordTimeZones", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			records := habit_tracker.HabitRecords{
+				{HabitID: habit.ID, RecordDate: now.In(elsewhere), Result: habit_tracker.ResultDone},
+			}
+			require.NoError(t, repos.Habits.InsertHabitRecords(ctx, records, now))
+
+			listed, err := repos.Habits.ListHabitRecords(
+				ctx, habit_tracker.HabitRecordFilter{
+					HabitID: habit.ID,
+					From:    now.Add(-time.Minute).In(elsewhere),
+					To:      now.Add(time.Minute).In(elsewhere),
+				},
+			)
+			require.NoError(t, err)
+			require.Len(t, listed, 1)
+			assert.True(t, now.Equal(listed[0].RecordDate))
+
+			listed, err = repos.Habits.ListHabitRecords(
+				ctx, habit_tracker.HabitRecordFilter{HabitID: habit.ID, From: now.Add(time.Minute).In(elsewhere)},
+			)
+			require.NoError(t, err)
+			assert.Empty(t, listed)
+		},
+	)
+
+	t.Run(
+		"UnknownIDs", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			_, err := repos.Habits.GetHabitByID(ctx, unknownID)
+			assertNotFound(t, err)
+
+			_, err = repos.Habits.GetHabitCategoryByID(ctx, unknownID)
+			assertNotFound(t, err)
+
+			_, err = repos.Habits.GetHabitRecordByID(ctx, unknownID)
+			assertNotFound(t, err)
+
+			renamed := habit
+			renamed.Name = "Swim"
+			_, err = repos.Habits.UpdateHabits(
+				ctx, habit_tracker.Habits{renamed, {ID: unknownID, CategoryID: habit.CategoryID}}, later,
+			)
+			assertNotFound(t, err)
+
+			got, err := repos.Habits.GetHabitByID(ctx, habit.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Exercise", got.Name, "a failed batch must not update any row")
+
+			assertNotFound(t, repos.Habits.DeleteHabits(ctx, []uint64{habit.ID, unknownID}, later))
+			assertNotFound(t, repos.Habits.RestoreHabits(ctx, []uint64{habit.ID}, later))
+			assertNotFound(t, repos.Habits.PurgeHabits(ctx, []uint64{unknownID}))
+
+			_, err = repos.Habits.GetHabitByID(ctx, habit.ID)
+			require.NoError(t, err)
+		},
+	)
+
+	t.Run(
+		"ForeignKeys", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			assertForeignKey(
+				t, repos.Habits.InsertHabits(ctx, habit_tracker.Habits{{CategoryID: unknownID, Name: "Orphan"}}, now),
+			)
+			assertForeignKey(
+				t, repos.Habits.InsertHabitRecords(
+					ctx, habit_tracker.HabitRecords{{HabitID: unknownID, RecordDate: now, Result: "done"}}, now,
+				),
+			)
+
+			habit.CategoryID = unknownID
+			_, err := repos.Habits.UpdateHabits(ctx, habit_tracker.Habits{habit}, later)
+			assertForeignKey(t, err)
+		},
+	)
+
+	t.Run(
+		"SoftDelete", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			require.NoError(t, repos.Habits.DeleteHabits(ctx, []uint64{habit.ID}, later))
+
+			_, err := repos.Habits.GetHabitByID(ctx, habit.ID)
+			assertNotFound(t, err)
+
+			listed, err := repos.Habits.ListHabits(ctx, habit_tracker.HabitFilter{})
+			require.NoError(t, err)
+			assert.Empty(t, listed)
+
+			listed, err = repos.Habits.ListHabits(ctx, habit_tracker.HabitFilter{IncludeDeleted: true})
+			require.NoError(t, err)
+			require.Len(t, listed, 1)
+			require.NotNil(t, listed[0].DeletedAt)
+			assert.True(t, later.Equal(*listed[0].DeletedAt))
+
+			_, err = repos.Habits.UpdateHabits(ctx, habit_tracker.Habits{habit}, later)
+			assertNotFound(t, err)
+
+			require.NoError(t, repos.Habits.RestoreHabits(ctx, []uint64{habit.ID}, later))
+
+			got, err := repos.Habits.GetHabitByID(ctx, habit.ID)
+			require.NoError(t, err)
+			assert.Nil(t, got.DeletedAt)
+		},
+	)
+
+	t.Run(
+		"PurgeCascades", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			records := habit_tracker.HabitRecords{{HabitID: habit.ID, RecordDate: now, Result: "done"}}
+			require.NoError(t, repos.Habits.InsertHabitRecords(ctx, records, now))
+
+			events := habit_tracker.Events{{HabitID: habit.ID, Subject: "Run", StartAt: now, EndAt: later}}
+			require.NoError(t, repos.Events.InsertEvents(ctx, events, now))
+
+			tags := habit_tracker.Tags{{Name: "morning"}}
+			require.NoError(t, repos.Tags.InsertTags(ctx, tags, now))
+			require.NoError(t, repos.Tags.AttachTags(ctx, habit_tracker.HabitTags{{HabitID: habit.ID, TagID: tags[0].ID}}))
+
+			require.NoError(t, repos.Habits.PurgeHabitCategories(ctx, []uint64{habit.CategoryID}))
+
+			listed, err := repos.Habits.ListHabits(ctx, habit_tracker.HabitFilter{IncludeDeleted: true})
+			require.NoError(t, err)
+			assert.Empty(t, listed)
+
+			listedRecords, err := repos.Habits.ListHabitRecords(ctx, habit_tracker.HabitRecordFilter{IncludeDeleted: true})
+			require.NoError(t, err)
+			assert.Empty(t, listedRecords)
+
+			listedEvents, err := repos.Events.ListEvents(ctx, habit_tracker.EventFilter{IncludeDeleted: true})
+			require.NoError(t, err)
+			assert.Empty(t, listedEvents)
+
+			_, err = repos.Tags.GetTagByID(ctx, tags[0].ID)
+			require.NoError(t, err, "purging a habit keeps its tags")
+		},
+	)
+
+	t.Run(
+		"BulkLoadRecords", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			loaded, err := repos.Habits.LoadHabitRecords(
+				ctx, feed(
+					habit_tracker.HabitRecord{HabitID: habit.ID, RecordDate: now, Result: "done"},
+					habit_tracker.HabitRecord{HabitID: habit.ID, RecordDate: now.AddDate(0, 0, 1), Result: "missed"},
+				), now,
+			)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), loaded)
+
+			_, err = repos.Habits.LoadHabitRecords(
+				ctx, feed(
+					habit_tracker.HabitRecord{HabitID: habit.ID, RecordDate: now.AddDate(0, 0, 2), Result: "done"},
+					habit_tracker.HabitRecord{HabitID: unknownID, RecordDate: now, Result: "done"},
+				), now,
+			)
+			assertForeignKey(t, err)
+
+			listed, err := repos.Habits.ListHabitRecords(ctx, habit_tracker.HabitRecordFilter{HabitID: habit.ID})
+			require.NoError(t, err)
+			require.Len(t, listed, 2, "a failed load must not leave any row")
+			assertGenerated(t, listed[0].ID, listed[0].CreatedAt, listed[0].UpdatedAt)
+		},
+	)
+
+	t.Run(
+		"OneRecordPerDay", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			records := habit_tracker.HabitRecords{{HabitID: habit.ID, RecordDate: now, Result: "done"}}
+			require.NoError(t, repos.Habits.InsertHabitRecords(ctx, records, now))
+
+			err := repos.Habits.InsertHabitRecords(
+				ctx, habit_tracker.HabitRecords{{HabitID: habit.ID, RecordDate: later, Result: "missed"}}, now,
+			)
+			assert.ErrorIs(t, err, habit_tracker.ErrConflict)
+
+			require.NoError(t, repos.Habits.DeleteHabitRecords(ctx, []uint64{records[0].ID}, later))
+			require.NoError(
+				t, repos.Habits.InsertHabitRecords(
+					ctx, habit_tracker.HabitRecords{{HabitID: habit.ID, RecordDate: later, Result: "missed"}}, now,
+				),
+				"deleted records don't take their day",
+			)
+
+			err = repos.Habits.RestoreHabitRecords(ctx, []uint64{records[0].ID}, later)
+			assert.ErrorIs(t, err, habit_tracker.ErrConflict)
+		},
+	)
+
+	t.Run(
+		"UpsertRecords", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			records := habit_tracker.HabitRecords{{HabitID: habit.ID, RecordDate: now, Result: "done", Description: "5km"}}
+			require.NoError(t, repos.Habits.InsertHabitRecords(ctx, records, now))
+
+			merged := habit_tracker.HabitRecords{
+				{HabitID: habit.ID, RecordDate: later, Result: "partial"},
+				{HabitID: habit.ID, RecordDate: now.AddDate(0, 0, 1), Result: "done"},
+			}
+			created, err := repos.Habits.UpsertHabitRecords(ctx, merged, habit_tracker.UpsertMerge, later)
+			require.NoError(t, err)
+			assert.Equal(t, []bool{false, true}, created)
+			assert.Equal(t, records[0].ID, merged[0].ID)
+			assertUpdated(t, merged[0].CreatedAt, merged[0].UpdatedAt)
+			assert.NotZero(t, merged[1].ID)
+
+			got, err := repos.Habits.GetHabitRecordByID(ctx, records[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, habit_tracker.ResultPartial, got.Result)
+			assert.Equal(t, "5km", got.Description, "merging keeps the fields left empty")
+			assert.True(t, now.Equal(got.RecordDate), "merging keeps the record date")
+
+			replaced := habit_tracker.HabitRecords{{HabitID: habit.ID, RecordDate: later, Result: "done"}}
+			created, err = repos.Habits.UpsertHabitRecords(ctx, replaced, habit_tracker.UpsertReplace, later)
+			require.NoError(t, err)
+			assert.Equal(t, []bool{false}, created)
+
+			got, err = repos.Habits.GetHabitRecordByID(ctx, records[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, habit_tracker.ResultDone, got.Result)
+			assert.Empty(t, got.Description)
+			assert.True(t, later.Equal(got.RecordDate))
+
+			_, err = repos.Habits.UpsertHabitRecords(
+				ctx, habit_tracker.HabitRecords{
+					{HabitID: habit.ID, RecordDate: now, Result: "done"},
+					{HabitID: habit.ID, RecordDate: later, Result: "missed"},
+				}, habit_tracker.UpsertReplace, later,
+			)
+			assert.ErrorIs(t, err, habit_tracker.ErrValidation)
+		},
+	)
+}
+
+func habitIDs(habits habit_tracker.Habits) []uint64 {
+	ids := make([]uint64, len(habits))
+	for i, habit := range habits {
+		ids[i] = habit.ID
+	}
+
+	return ids
+}
@@ -0,0 +1,96 @@
+// Package repotest holds the behavioral contracts every backend of the
+// habit_tracker repositories must honor, as tests any backend can run:
+//
+//	func TestConformance(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repotest.Repositories { ... })
+//	}
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"habit-tracker"
+)
+
+type Repositories struct {
+	Habits habit_tracker.HabitRepository
+	Tags   habit_tracker.TagRepository
+	Goals  habit_tracker.GoalRepository
+	Events habit_tracker.EventRepository
+}
+
+// Factory returns repositories over an empty backend. The repositories of a
+// single call share their data, the ones of different calls don't have to.
+type Factory func(t *testing.T) Repositories
+
+// Times are in UTC and whole seconds so that every backend stores them as is.
+// elsewhere is a zone away from UTC, where instants must still compare alike.
+var (
+	now       = time.Date(2023, 7, 30, 12, 0, 0, 0, time.UTC)
+	later     = now.Add(time.Hour)
+	elsewhere = time.FixedZone("UTC-5", -5*60*60)
+)
+
+const unknownID = 999999
+
+func Run(t *testing.T, factory Factory) {
+	t.Run("HabitRepository", func(t *testing.T) { TestHabitRepository(t, factory) })
+	t.Run("TagRepository", func(t *testing.T) { TestTagRepository(t, factory) })
+	t.Run("GoalRepository", func(t *testing.T) { TestGoalRepository(t, factory) })
+	t.Run("EventRepository", func(t *testing.T) { TestEventRepository(t, factory) })
+}
+
+// seedHabit inserts a category and a habit in it, returning the habit.
+func seedHabit(t *testing.T, repos Repositories) habit_tracker.Habit {
+	ctx := context.Background()
+
+	categories := habit_tracker.HabitCategories{{CategoryName: "Health"}}
+	require.NoError(t, repos.Habits.InsertHabitCategories(ctx, categories, now))
+
+	habits := habit_tracker.Habits{{CategoryID: categories[0].ID, Name: "Exercise", Description: "Daily"}}
+	require.NoError(t, repos.Habits.InsertHabits(ctx, habits, now))
+
+	return habits[0]
+}
+
+func assertGenerated(t *testing.T, id uint64, createdAt, updatedAt time.Time) {
+	assert.NotZero(t, id)
+	assert.True(t, now.Equal(createdAt), "created_at %s", createdAt)
+	assert.True(t, now.Equal(updatedAt), "updated_at %s", updatedAt)
+}
+
+func assertUpdated(t *testing.T, createdAt, updatedAt time.Time) {
+	assert.True(t, now.Equal(createdAt), "created_at %s", createdAt)
+	assert.True(t, later.Equal(updatedAt), "updated_at %s", updatedAt)
+}
+
+func assertNotFound(t *testing.T, err error) {
+	assert.ErrorIs(t, err, habit_tracker.ErrNotFound)
+}
+
+func assertForeignKey(t *testing.T, err error) {
+	assert.ErrorIs(t, err, habit_tracker.ErrForeignKeyViolation)
+}
+
+func assertConflict(t *testing.T, err error, ids ...uint64) {
+	var classified *habit_tracker.Error
+	if assert.ErrorIs(t, err, habit_tracker.ErrConflict) && assert.ErrorAs(t, err, &classified) {
+		assert.Equal(t, ids, classified.IDs)
+	}
+}
+
+// feed returns a closed channel yielding values.
+func feed[T any](values ...T) <-chan T {
+	ch := make(chan T, len(values))
+	for _, value := range values {
+		ch <- value
+	}
+	close(ch)
+
+	return ch
+}
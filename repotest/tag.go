@@ -0,0 +1,137 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"habit-tracker"
+)
+
+func TestTagRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run(
+		"InsertThenRead", func(t *testing.T) {
+			repos := factory(t)
+
+			tags := habit_tracker.Tags{{Name: "morning", Description: "Before 9"}, {Name: "evening"}}
+			require.NoError(t, repos.Tags.InsertTags(ctx, tags, now))
+			assert.Less(t, tags[0].ID, tags[1].ID)
+			for _, inserted := range tags {
+				assertGenerated(t, inserted.ID, inserted.CreatedAt, inserted.UpdatedAt)
+			}
+
+			got, err := repos.Tags.GetTagByID(ctx, tags[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, "morning", got.Name)
+			assert.Equal(t, "Before 9", got.Description)
+
+			listed, err := repos.Tags.ListTags(ctx, habit_tracker.TagFilter{NamePrefix: "eve"})
+			require.NoError(t, err)
+			require.Len(t, listed, 1)
+			assert.Equal(t, tags[1].ID, listed[0].ID)
+		},
+	)
+
+	t.Run(
+		"UpdateBumpsUpdatedAt", func(t *testing.T) {
+			repos := factory(t)
+
+			tags := habit_tracker.Tags{{Name: "morning"}}
+			require.NoError(t, repos.Tags.InsertTags(ctx, tags, now))
+
+			tags[0].Name = "dawn"
+			require.NoError(t, repos.Tags.UpdateTags(ctx, tags, later))
+
+			got, err := repos.Tags.GetTagByID(ctx, tags[0].ID)
+			require.NoError(t, err)
+			assert.Equal(t, "dawn", got.Name)
+			assertUpdated(t, got.CreatedAt, got.UpdatedAt)
+		},
+	)
+
+	t.Run(
+		"UnknownIDs", func(t *testing.T) {
+			repos := factory(t)
+
+			_, err := repos.Tags.GetTagByID(ctx, unknownID)
+			assertNotFound(t, err)
+			assertNotFound(t, repos.Tags.DeleteTags(ctx, []uint64{unknownID}, later))
+			assertNotFound(t, repos.Tags.PurgeTags(ctx, []uint64{unknownID}))
+
+			tags := habit_tracker.Tags{{Name: "morning"}}
+			require.NoError(t, repos.Tags.InsertTags(ctx, tags, now))
+			assertNotFound(t, repos.Tags.UpdateTags(ctx, append(tags, habit_tracker.Tag{ID: unknownID, Name: "x"}), later))
+
+			require.NoError(t, repos.Tags.DeleteTags(ctx, []uint64{tags[0].ID}, now))
+			assertNotFound(t, repos.Tags.UpdateTags(ctx, tags, later))
+		},
+	)
+
+	t.Run(
+		"HabitTags", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			tags := habit_tracker.Tags{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+			require.NoError(t, repos.Tags.InsertTags(ctx, tags, now))
+
+			require.NoError(
+				t, repos.Tags.AttachTags(
+					ctx, habit_tracker.HabitTags{
+						{HabitID: habit.ID, TagID: tags[1].ID},
+						{HabitID: habit.ID, TagID: tags[0].ID},
+						{HabitID: habit.ID, TagID: tags[0].ID},
+					},
+				),
+			)
+
+			byHabit, err := repos.Tags.ListTagsByHabitIDs(ctx, []uint64{habit.ID})
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{tags[0].ID, tags[1].ID}, tagIDs(byHabit[habit.ID]))
+
+			listed, err := repos.Habits.ListHabits(ctx, habit_tracker.HabitFilter{TagID: tags[1].ID})
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{habit.ID}, habitIDs(listed))
+
+			require.NoError(t, repos.Tags.ReplaceHabitTags(ctx, habit.ID, []uint64{tags[2].ID}))
+			require.NoError(t, repos.Tags.DeleteTags(ctx, []uint64{tags[0].ID}, later))
+
+			byHabit, err = repos.Tags.ListTagsByHabitIDs(ctx, []uint64{habit.ID})
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{tags[2].ID}, tagIDs(byHabit[habit.ID]))
+
+			require.NoError(t, repos.Tags.DetachTags(ctx, habit_tracker.HabitTags{{HabitID: habit.ID, TagID: tags[2].ID}}))
+
+			byHabit, err = repos.Tags.ListTagsByHabitIDs(ctx, []uint64{habit.ID})
+			require.NoError(t, err)
+			assert.Empty(t, byHabit[habit.ID])
+		},
+	)
+
+	t.Run(
+		"ForeignKeys", func(t *testing.T) {
+			repos := factory(t)
+			habit := seedHabit(t, repos)
+
+			tags := habit_tracker.Tags{{Name: "a"}}
+			require.NoError(t, repos.Tags.InsertTags(ctx, tags, now))
+
+			assertForeignKey(t, repos.Tags.AttachTags(ctx, habit_tracker.HabitTags{{HabitID: habit.ID, TagID: unknownID}}))
+			assertForeignKey(t, repos.Tags.AttachTags(ctx, habit_tracker.HabitTags{{HabitID: unknownID, TagID: tags[0].ID}}))
+			assertForeignKey(t, repos.Tags.ReplaceHabitTags(ctx, habit.ID, []uint64{tags[0].ID, unknownID}))
+		},
+	)
+}
+
+func tagIDs(tags habit_tracker.Tags) []uint64 {
+	ids := make([]uint64, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+
+	return ids
+}
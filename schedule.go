@@ -0,0 +1,278 @@
+package habit_tracker
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type ScheduleKind string
+
+const (
+	ScheduleDaily         ScheduleKind = "daily"
+	ScheduleWeekdays      ScheduleKind = "weekdays"
+	ScheduleTimesPerWeek  ScheduleKind = "times_per_week"
+	ScheduleTimesPerMonth ScheduleKind = "times_per_month"
+	ScheduleEveryNDays    ScheduleKind = "every_n_days"
+)
+
+// Schedule tells on which days a habit is due. Weekdays is used by
+// ScheduleWeekdays, Times by ScheduleTimesPerWeek and ScheduleTimesPerMonth,
+// and Interval by ScheduleEveryNDays, which counts days from StartDate.
+// StartDate is a calendar date, no day before it is due; zero means no start.
+// The zero Schedule is due every day.
+type Schedule struct {
+	Kind      ScheduleKind
+	Weekdays  []time.Weekday
+	Times     int
+	Interval  int
+	StartDate time.Time
+}
+
+type scheduleJSON struct {
+	Kind      ScheduleKind   `json:"kind,omitempty"`
+	Weekdays  []time.Weekday `json:"weekdays,omitempty"`
+	Times     int            `json:"times,omitempty"`
+	Interval  int            `json:"interval,omitempty"`
+	StartDate string         `json:"start_date,omitempty"`
+}
+
+func (s Schedule) Validate() error {
+	switch s.Kind {
+	case "", ScheduleDaily:
+	case ScheduleWeekdays:
+		if len(s.Weekdays) == 0 {
+			return errors.New("weekdays schedule without weekdays")
+		}
+
+		for _, weekday := range s.Weekdays {
+			if weekday < time.Sunday || weekday > time.Saturday {
+				return fmt.Errorf("unknown weekday %d", weekday)
+			}
+		}
+	case ScheduleTimesPerWeek:
+		if s.Times < 1 || s.Times > 7 {
+			return fmt.Errorf("%d times per week out of 1-7", s.Times)
+		}
+	case ScheduleTimesPerMonth:
+		if s.Times < 1 || s.Times > 31 {
+			return fmt.Errorf("%d times per month out of 1-31", s.Times)
+		}
+	case ScheduleEveryNDays:
+		if s.Interval < 1 {
+			return fmt.Errorf("every %d days is less than every day", s.Interval)
+		}
+
+		if s.StartDate.IsZero() {
+			return errors.New("every n days schedule without start date")
+		}
+	default:
+		return fmt.Errorf("unknown schedule kind %q", s.Kind)
+	}
+
+	return nil
+}
+
+// IsDue reports whether the habit is due on the calendar day of date, in the
+// location of date. records, which may hold any records of the habit, are
+// only read by the schedules counting times per period: a day is due until
+// Times successful records were kept on the previous days of its period.
+func (s Schedule) IsDue(date time.Time, records HabitRecords) bool {
+	day := civilDate(date)
+	if !s.StartDate.IsZero() && day.Before(civilDate(s.StartDate)) {
+		return false
+	}
+
+	switch s.Kind {
+	case ScheduleWeekdays:
+		for _, weekday := range s.Weekdays {
+			if weekday == day.Weekday() {
+				return true
+			}
+		}
+
+		return false
+	case ScheduleTimesPerWeek, ScheduleTimesPerMonth:
+		from, _ := s.Period(day)
+
+		successful := 0
+		for _, record := range records {
+			recordDay := civilDate(record.RecordDate.In(date.Location()))
+			if record.Succeeded() && !recordDay.Before(from) && recordDay.Before(day) {
+				successful++
+			}
+		}
+
+		return successful < s.Times
+	case ScheduleEveryNDays:
+		if s.Interval < 1 {
+			return true
+		}
+
+		return daysBetween(civilDate(s.StartDate), day)%s.Interval == 0
+	default:
+		return true
+	}
+}
+
+// Period returns the start and the end, excluded, of the period date belongs
+// to, in the location of date: the week starting on Monday for
+// ScheduleTimesPerWeek, the month for ScheduleTimesPerMonth and the day for
+// the others.
+func (s Schedule) Period(date time.Time) (time.Time, time.Time) {
+	year, month, day := date.Date()
+
+	switch s.Kind {
+	case ScheduleTimesPerWeek:
+		day -= (int(date.Weekday()) + 6) % 7
+		from := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+
+		return from, from.AddDate(0, 0, 7)
+	case ScheduleTimesPerMonth:
+		from := time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+
+		return from, from.AddDate(0, 1, 0)
+	default:
+		from := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+
+		return from, from.AddDate(0, 0, 1)
+	}
+}
+
+// countsTimes reports whether the schedule is due a number of times per period
+// rather than on given days.
+func (s Schedule) countsTimes() bool {
+	return s.Kind == ScheduleTimesPerWeek || s.Kind == ScheduleTimesPerMonth
+}
+
+// IsDue reports whether the habit is due on the calendar day of date, see
+// Schedule.IsDue. It never is on a day its Pauses cover.
+func (h Habit) IsDue(date time.Time, records HabitRecords) bool {
+	if h.Pauses.PausedOn(date) {
+		return false
+	}
+
+	return h.Schedule.IsDue(date, records)
+}
+
+// DueHabits returns the habits due on the calendar day of date, in the location
+// of date, reading their pauses. Records are only listed for the habits due a
+// number of times per period, from the start of the period to date.
+func DueHabits(ctx context.Context, habitRepository HabitRepository, date time.Time) (Habits, error) {
+	habits, err := habitRepository.ListHabits(ctx, HabitFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, len(habits))
+	for i, habit := range habits {
+		ids[i] = habit.ID
+	}
+
+	pauses, err := habitRepository.ListHabitPauses(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	habits = habits.WithPauses(pauses)
+
+	due := make(Habits, 0, len(habits))
+	for _, habit := range habits {
+		var records HabitRecords
+		if habit.Schedule.countsTimes() {
+			from, _ := habit.Schedule.Period(date)
+			to, _ := Schedule{}.Period(date)
+
+			records, err = habitRepository.ListHabitRecords(
+				ctx, HabitRecordFilter{HabitID: habit.ID, From: from, To: to},
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if habit.IsDue(date, records) {
+			due = append(due, habit)
+		}
+	}
+
+	return due, nil
+}
+
+func (s Schedule) MarshalJSON() ([]byte, error) {
+	value := scheduleJSON{
+		Kind:     s.Kind,
+		Weekdays: s.Weekdays,
+		Times:    s.Times,
+		Interval: s.Interval,
+	}
+	if !s.StartDate.IsZero() {
+		value.StartDate = s.StartDate.Format(time.DateOnly)
+	}
+
+	return json.Marshal(value)
+}
+
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	var value scheduleJSON
+
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	*s = Schedule{
+		Kind:     value.Kind,
+		Weekdays: value.Weekdays,
+		Times:    value.Times,
+		Interval: value.Interval,
+	}
+	if value.StartDate != "" {
+		s.StartDate, err = time.Parse(time.DateOnly, value.StartDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Value stores the schedule as JSON.
+func (s Schedule) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (s *Schedule) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*s = Schedule{}
+
+		return nil
+	case []byte:
+		return json.Unmarshal(src, s)
+	case string:
+		return json.Unmarshal([]byte(src), s)
+	default:
+		return fmt.Errorf("[schedule][err:can't scan %T]", src)
+	}
+}
+
+// civilDate returns the calendar day of t as midnight UTC, which has no DST
+// transition, so that days can be compared and counted.
+func civilDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours()) / 24
+}
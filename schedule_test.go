@@ -0,0 +1,361 @@
+package habit_tracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+	"habit-tracker/mocks"
+)
+
+func TestSchedule_IsDue(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	// 2023-07-03 is a Monday.
+	start := time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC)
+	records := habit_tracker.HabitRecords{
+		{RecordDate: time.Date(2023, 7, 3, 8, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone},
+		{RecordDate: time.Date(2023, 7, 4, 8, 0, 0, 0, time.UTC), Result: habit_tracker.ResultMissed},
+		{RecordDate: time.Date(2023, 7, 5, 8, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone},
+	}
+
+	type args struct {
+		schedule habit_tracker.Schedule
+		date     time.Time
+		records  habit_tracker.HabitRecords
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "ZeroEveryDay",
+			args: args{
+				date: time.Date(2023, 7, 9, 23, 59, 0, 0, time.UTC),
+			},
+			want: true,
+		},
+		{
+			name: "DailyBeforeStart",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleDaily, StartDate: start},
+				date:     time.Date(2023, 7, 2, 23, 59, 0, 0, time.UTC),
+			},
+			want: false,
+		},
+		{
+			name: "DailyOnStart",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleDaily, StartDate: start},
+				date:     start,
+			},
+			want: true,
+		},
+		{
+			name: "WeekdaysMatching",
+			args: args{
+				schedule: habit_tracker.Schedule{
+					Kind:     habit_tracker.ScheduleWeekdays,
+					Weekdays: []time.Weekday{time.Monday, time.Friday},
+				},
+				date: time.Date(2023, 7, 7, 12, 0, 0, 0, time.UTC),
+			},
+			want: true,
+		},
+		{
+			name: "WeekdaysOther",
+			args: args{
+				schedule: habit_tracker.Schedule{
+					Kind:     habit_tracker.ScheduleWeekdays,
+					Weekdays: []time.Weekday{time.Monday, time.Friday},
+				},
+				date: time.Date(2023, 7, 8, 12, 0, 0, 0, time.UTC),
+			},
+			want: false,
+		},
+		{
+			name: "WeekdaysInDateLocation",
+			args: args{
+				schedule: habit_tracker.Schedule{
+					Kind:     habit_tracker.ScheduleWeekdays,
+					Weekdays: []time.Weekday{time.Friday},
+				},
+				// Saturday 02:00 UTC is still Friday in New York.
+				date: time.Date(2023, 7, 8, 2, 0, 0, 0, time.UTC).In(newYork),
+			},
+			want: true,
+		},
+		{
+			name: "EveryNDaysOnInterval",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleEveryNDays, Interval: 3, StartDate: start},
+				date:     time.Date(2023, 7, 9, 7, 0, 0, 0, time.UTC),
+			},
+			want: true,
+		},
+		{
+			name: "EveryNDaysBetween",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleEveryNDays, Interval: 3, StartDate: start},
+				date:     time.Date(2023, 7, 10, 7, 0, 0, 0, time.UTC),
+			},
+			want: false,
+		},
+		{
+			name: "EveryNDaysAcrossDST",
+			args: args{
+				schedule: habit_tracker.Schedule{
+					Kind:      habit_tracker.ScheduleEveryNDays,
+					Interval:  2,
+					StartDate: time.Date(2023, 3, 11, 0, 0, 0, 0, time.UTC),
+				},
+				// 2023-03-12 lasts 23 hours in New York.
+				date: time.Date(2023, 3, 13, 0, 30, 0, 0, newYork),
+			},
+			want: true,
+		},
+		{
+			name: "TimesPerWeekBelowTarget",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 3},
+				date:     time.Date(2023, 7, 6, 12, 0, 0, 0, time.UTC),
+				records:  records,
+			},
+			want: true,
+		},
+		{
+			name: "TimesPerWeekReached",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 2},
+				date:     time.Date(2023, 7, 6, 12, 0, 0, 0, time.UTC),
+				records:  records,
+			},
+			want: false,
+		},
+		{
+			name: "TimesPerWeekIgnoresSameDay",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 2},
+				date:     time.Date(2023, 7, 5, 12, 0, 0, 0, time.UTC),
+				records:  records,
+			},
+			want: true,
+		},
+		{
+			name: "TimesPerWeekNewWeek",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 1},
+				date:     time.Date(2023, 7, 10, 12, 0, 0, 0, time.UTC),
+				records:  records,
+			},
+			want: true,
+		},
+		{
+			name: "TimesPerMonthReached",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerMonth, Times: 2},
+				date:     time.Date(2023, 7, 31, 12, 0, 0, 0, time.UTC),
+				records:  records,
+			},
+			want: false,
+		},
+		{
+			name: "TimesPerMonthNewMonth",
+			args: args{
+				schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerMonth, Times: 2},
+				date:     time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC),
+				records:  records,
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				assert.Equal(t, tt.want, tt.args.schedule.IsDue(tt.args.date, tt.args.records))
+			},
+		)
+	}
+}
+
+func TestSchedule_Period(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	// Sunday, the last day of the week starting on Monday 2023-07-03.
+	date := time.Date(2023, 7, 9, 23, 0, 0, 0, newYork)
+
+	from, to := habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek}.Period(date)
+	assert.Equal(t, time.Date(2023, 7, 3, 0, 0, 0, 0, newYork), from)
+	assert.Equal(t, time.Date(2023, 7, 10, 0, 0, 0, 0, newYork), to)
+
+	from, to = habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerMonth}.Period(date)
+	assert.Equal(t, time.Date(2023, 7, 1, 0, 0, 0, 0, newYork), from)
+	assert.Equal(t, time.Date(2023, 8, 1, 0, 0, 0, 0, newYork), to)
+
+	from, to = habit_tracker.Schedule{}.Period(date)
+	assert.Equal(t, time.Date(2023, 7, 9, 0, 0, 0, 0, newYork), from)
+	assert.Equal(t, time.Date(2023, 7, 10, 0, 0, 0, 0, newYork), to)
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	start := time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule habit_tracker.Schedule
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name:     "Zero",
+			schedule: habit_tracker.Schedule{},
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "Weekdays",
+			schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleWeekdays, Weekdays: []time.Weekday{time.Sunday}},
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "ErrorNoWeekdays",
+			schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleWeekdays},
+			wantErr:  assert.Error,
+		},
+		{
+			name:     "ErrorWeekday",
+			schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleWeekdays, Weekdays: []time.Weekday{7}},
+			wantErr:  assert.Error,
+		},
+		{
+			name:     "ErrorTimesPerWeek",
+			schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 8},
+			wantErr:  assert.Error,
+		},
+		{
+			name:     "ErrorTimesPerMonth",
+			schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerMonth},
+			wantErr:  assert.Error,
+		},
+		{
+			name:     "EveryNDays",
+			schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleEveryNDays, Interval: 2, StartDate: start},
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "ErrorEveryNDaysWithoutStart",
+			schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleEveryNDays, Interval: 2},
+			wantErr:  assert.Error,
+		},
+		{
+			name:     "ErrorKind",
+			schedule: habit_tracker.Schedule{Kind: "hourly"},
+			wantErr:  assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				tt.wantErr(t, tt.schedule.Validate())
+			},
+		)
+	}
+}
+
+func TestSchedule_ValueScan(t *testing.T) {
+	schedule := habit_tracker.Schedule{
+		Kind:      habit_tracker.ScheduleEveryNDays,
+		Interval:  2,
+		StartDate: time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC),
+	}
+
+	value, err := schedule.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"kind":"every_n_days","interval":2,"start_date":"2023-07-03"}`, value)
+
+	var got habit_tracker.Schedule
+	assert.NoError(t, got.Scan([]byte(value.(string))))
+	assert.Equal(t, schedule, got)
+
+	value, err = habit_tracker.Schedule{}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{}`, value)
+
+	assert.NoError(t, got.Scan(nil))
+	assert.Equal(t, habit_tracker.Schedule{}, got)
+	assert.Error(t, got.Scan(1))
+}
+
+func TestHabits_Validate(t *testing.T) {
+	habits := habit_tracker.Habits{
+		{ID: 1, TargetValue: 30, TargetUnit: "pages", TargetComparison: habit_tracker.TargetAtLeast},
+		{ID: 2, Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek}},
+		{ID: 3, TargetValue: 2},
+		{ID: 4, TargetComparison: habit_tracker.TargetExactly, TargetPeriod: "month"},
+		{ID: 5, TargetValue: -1, TargetComparison: habit_tracker.TargetAtMost},
+	}
+
+	err := habits.Validate()
+
+	var classified *habit_tracker.Error
+	assert.ErrorIs(t, err, habit_tracker.ErrValidation)
+	assert.ErrorAs(t, err, &classified)
+	assert.Equal(t, []uint64{2, 3, 4, 5}, classified.IDs)
+	assert.NoError(t, habits[:1].Validate())
+}
+
+func TestDueHabits(t *testing.T) {
+	ctx := context.Background()
+	// A Thursday.
+	date := time.Date(2023, 7, 6, 12, 0, 0, 0, time.UTC)
+
+	habitRepository := mocks.NewHabitRepository(t)
+	habitRepository.On("ListHabits", ctx, habit_tracker.HabitFilter{}).Return(
+		habit_tracker.Habits{
+			{ID: 1},
+			{ID: 2, Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleWeekdays, Weekdays: []time.Weekday{time.Monday}}},
+			{ID: 3, Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 1}},
+			{ID: 4, Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 2}},
+			{ID: 5, Status: habit_tracker.HabitPaused},
+		}, nil,
+	)
+	habitRepository.On("ListHabitPauses", ctx, []uint64{1, 2, 3, 4, 5}).Return(
+		map[uint64]habit_tracker.HabitPauses{
+			5: {{HabitID: 5, PausedAt: time.Date(2023, 7, 5, 9, 0, 0, 0, time.UTC)}},
+		}, nil,
+	)
+	habitRepository.On(
+		"ListHabitRecords", ctx, habit_tracker.HabitRecordFilter{
+			HabitID: 3,
+			From:    time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC),
+			To:      time.Date(2023, 7, 6, 0, 0, 0, 0, time.UTC),
+		},
+	).Return(
+		habit_tracker.HabitRecords{{HabitID: 3, RecordDate: time.Date(2023, 7, 4, 0, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone}}, nil,
+	)
+	habitRepository.On(
+		"ListHabitRecords", ctx, habit_tracker.HabitRecordFilter{
+			HabitID: 4,
+			From:    time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC),
+			To:      time.Date(2023, 7, 6, 0, 0, 0, 0, time.UTC),
+		},
+	).Return(
+		habit_tracker.HabitRecords{{HabitID: 4, RecordDate: time.Date(2023, 7, 4, 0, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone}}, nil,
+	)
+
+	got, err := habit_tracker.DueHabits(ctx, habitRepository, date)
+
+	assert.NoError(t, err)
+
+	ids := make([]uint64, len(got))
+	for i, habit := range got {
+		ids[i] = habit.ID
+	}
+	assert.Equal(t, []uint64{1, 4}, ids)
+}
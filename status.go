@@ -0,0 +1,55 @@
+package habit_tracker
+
+import (
+	"time"
+)
+
+// HabitStatus is changed by HabitRepository.PauseHabits, ResumeHabits and
+// ArchiveHabits only. The empty status is the one of a habit not stored yet.
+type HabitStatus string
+
+const (
+	HabitActive   HabitStatus = "active"
+	HabitPaused   HabitStatus = "paused"
+	HabitArchived HabitStatus = "archived"
+)
+
+// HabitPause is an interval a habit was paused, or archived, for. ResumedAt is
+// nil while it still is.
+type HabitPause struct {
+	HabitID   uint64     `sql:"habit_id"`
+	PausedAt  time.Time  `sql:"paused_at"`
+	ResumedAt *time.Time `sql:"resumed_at"`
+}
+
+type HabitPauses []HabitPause
+
+// PausedOn reports whether a pause covers the calendar day of date, in the
+// location of date: a pause covers the day it starts on, up to the day before
+// it ends, so that the day of a resume is due again.
+func (p HabitPauses) PausedOn(date time.Time) bool {
+	day := civilDate(date)
+	for _, pause := range p {
+		if day.Before(civilDate(pause.PausedAt.In(date.Location()))) {
+			continue
+		}
+
+		if pause.ResumedAt == nil || day.Before(civilDate(pause.ResumedAt.In(date.Location()))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithPauses sets the Pauses of every habit from pauses, keyed by habit ID, as
+// returned by HabitRepository.ListHabitPauses.
+func (h Habits) WithPauses(pauses map[uint64]HabitPauses) Habits {
+	habits := make(Habits, len(h))
+	for i, habit := range h {
+		habit.Pauses = pauses[habit.ID]
+		habits[i] = habit
+	}
+
+	return habits
+}
@@ -0,0 +1,55 @@
+package habit_tracker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func TestHabitPauses_PausedOn(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	resumedAt := time.Date(2023, 7, 10, 18, 0, 0, 0, time.UTC)
+	pauses := habit_tracker.HabitPauses{
+		{PausedAt: time.Date(2023, 7, 5, 18, 0, 0, 0, time.UTC), ResumedAt: &resumedAt},
+		{PausedAt: time.Date(2023, 7, 20, 2, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want bool
+	}{
+		{name: "BeforePauses", date: time.Date(2023, 7, 4, 12, 0, 0, 0, time.UTC)},
+		{name: "PauseDay", date: time.Date(2023, 7, 5, 8, 0, 0, 0, time.UTC), want: true},
+		{name: "DuringPause", date: time.Date(2023, 7, 8, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "ResumeDay", date: time.Date(2023, 7, 10, 8, 0, 0, 0, time.UTC)},
+		{name: "BetweenPauses", date: time.Date(2023, 7, 15, 12, 0, 0, 0, time.UTC)},
+		{name: "OpenPause", date: time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC), want: true},
+		{name: "DayBeforeOpenPause", date: time.Date(2023, 7, 19, 12, 0, 0, 0, time.UTC)},
+		// The open pause starts on 2023-07-19 in New York.
+		{name: "DayInLocation", date: time.Date(2023, 7, 19, 12, 0, 0, 0, newYork), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				assert.Equal(t, tt.want, pauses.PausedOn(tt.date))
+			},
+		)
+	}
+}
+
+func TestHabit_IsDuePaused(t *testing.T) {
+	habit := habit_tracker.Habit{
+		Status: habit_tracker.HabitArchived,
+		Pauses: habit_tracker.HabitPauses{{PausedAt: time.Date(2023, 7, 5, 18, 0, 0, 0, time.UTC)}},
+	}
+
+	assert.True(t, habit.IsDue(time.Date(2023, 7, 4, 12, 0, 0, 0, time.UTC), nil))
+	assert.False(t, habit.IsDue(time.Date(2023, 7, 6, 12, 0, 0, 0, time.UTC), nil))
+}
@@ -0,0 +1,236 @@
+// Package streaks computes the streaks of a habit from its records: the runs
+// of due days, or of periods for the schedules counting times per period, it
+// was kept without a miss.
+package streaks
+
+import (
+	"time"
+
+	"habit-tracker"
+)
+
+// Config sets how the time of a record maps to a day.
+type Config struct {
+	// Location is the time zone days are counted in, UTC if nil.
+	Location *time.Location
+	// DayBoundary is the time of day a day starts at, e.g. with 4h a record
+	// kept at 2am counts for the day before. Zero means midnight.
+	DayBoundary time.Duration
+}
+
+// Streak is a run of due days, or periods, with a successful record. Start
+// and End are the days, at midnight in the Config location, of its first and
+// last successful records. Length counts the days, or periods, of the run.
+type Streak struct {
+	Start  time.Time
+	End    time.Time
+	Length int
+}
+
+// Summary holds every streak, from the oldest. Current is the one still
+// running, if any, and Longest the oldest of the longest ones.
+type Summary struct {
+	Current Streak
+	Longest Streak
+	Streaks []Streak
+}
+
+// outcome is whether a due day, or a period, was kept. It is pending when it
+// isn't kept yet but may still be, being today or including it, and paused
+// when a pause of the habit covers some of its days or some were skipped.
+type outcome struct {
+	kept    bool
+	pending bool
+	paused  bool
+	days    []time.Time
+}
+
+// Compute returns the streaks of the habit, given its records, as of now.
+// Days the habit isn't due, is paused, per its Pauses, or was skipped without
+// succeeding are neutral: they neither break nor extend a streak, and so are
+// the periods partly paused or skipped but not kept. Records which didn't
+// succeed nor were skipped, are after now or are on a paused day are ignored.
+func Compute(habit habit_tracker.Habit, records habit_tracker.HabitRecords, now time.Time, cfg Config) Summary {
+	today := cfg.day(now)
+
+	succeeded := make(map[time.Time]bool)
+	skipped := make(map[time.Time]bool)
+	var first time.Time
+	for _, record := range records {
+		day := cfg.day(record.RecordDate)
+		if day.After(today) {
+			continue
+		}
+
+		if record.Result == habit_tracker.ResultSkipped {
+			skipped[day] = true
+		}
+
+		if !record.Succeeded() {
+			continue
+		}
+
+		succeeded[day] = true
+		if first.IsZero() || day.Before(first) {
+			first = day
+		}
+	}
+
+	summary := Summary{
+		Streaks: make([]Streak, 0),
+	}
+	if len(succeeded) == 0 {
+		return summary
+	}
+
+	var outcomes []outcome
+	switch habit.Schedule.Kind {
+	case habit_tracker.ScheduleTimesPerWeek, habit_tracker.ScheduleTimesPerMonth:
+		outcomes = periodOutcomes(habit, succeeded, skipped, first, today, cfg)
+	default:
+		outcomes = dayOutcomes(habit, succeeded, skipped, first, today, cfg)
+	}
+
+	var run *Streak
+	for _, o := range outcomes {
+		switch {
+		case o.kept:
+			if run == nil {
+				run = &Streak{Start: o.days[0]}
+			}
+
+			run.End = o.days[len(o.days)-1]
+			run.Length++
+		case o.pending, o.paused:
+			// Today may still be kept, or the habit was paused or skipped: the
+			// run goes on.
+		case run != nil:
+			summary.Streaks = append(summary.Streaks, *run)
+			run = nil
+		}
+	}
+
+	if run != nil {
+		summary.Streaks = append(summary.Streaks, *run)
+	}
+
+	for i := range summary.Streaks {
+		summary.Streaks[i].Start = cfg.local(summary.Streaks[i].Start)
+		summary.Streaks[i].End = cfg.local(summary.Streaks[i].End)
+
+		if summary.Streaks[i].Length > summary.Longest.Length {
+			summary.Longest = summary.Streaks[i]
+		}
+	}
+
+	if run != nil {
+		summary.Current = summary.Streaks[len(summary.Streaks)-1]
+	}
+
+	return summary
+}
+
+// dayOutcomes returns the outcome of every due day, not paused nor skipped
+// without succeeding, from first to today.
+func dayOutcomes(habit habit_tracker.Habit, succeeded, skipped map[time.Time]bool, first, today time.Time,
+	cfg Config) []outcome {
+	outcomes := make([]outcome, 0)
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if !habit.Schedule.IsDue(day, nil) || cfg.paused(habit.Pauses, day) || (skipped[day] && !succeeded[day]) {
+			continue
+		}
+
+		outcomes = append(
+			outcomes, outcome{
+				kept:    succeeded[day],
+				pending: day.Equal(today),
+				days:    []time.Time{day},
+			},
+		)
+	}
+
+	return outcomes
+}
+
+// periodOutcomes returns the outcome of every period from the one of first to
+// the one of today. A period is kept once it has Times successful days.
+func periodOutcomes(habit habit_tracker.Habit, succeeded, skipped map[time.Time]bool, first, today time.Time,
+	cfg Config) []outcome {
+	schedule := habit.Schedule
+
+	outcomes := make([]outcome, 0)
+	for from, to := schedule.Period(first); !from.After(today); from, to = schedule.Period(to) {
+		var (
+			days   []time.Time
+			paused bool
+		)
+		for day := from; day.Before(to) && !day.After(today); day = day.AddDate(0, 0, 1) {
+			switch {
+			case cfg.paused(habit.Pauses, day):
+				paused = true
+			case succeeded[day] && schedule.IsDue(day, nil):
+				days = append(days, day)
+			case skipped[day]:
+				paused = true
+			}
+		}
+
+		outcomes = append(
+			outcomes, outcome{
+				kept:    len(days) > 0 && len(days) >= schedule.Times,
+				pending: today.Before(to),
+				paused:  paused,
+				days:    days,
+			},
+		)
+	}
+
+	return outcomes
+}
+
+func (c Config) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+
+	return c.Location
+}
+
+// day returns the day t counts for as midnight UTC, which has no DST
+// transition, so that days can be compared and stepped through.
+func (c Config) day(t time.Time) time.Time {
+	local := t.In(c.location())
+
+	year, month, day := local.Date()
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+	if clock < c.DayBoundary {
+		day--
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// paused reports whether one of pauses covers day, as returned by Config.day:
+// from the day it starts on to the one before it ends.
+func (c Config) paused(pauses habit_tracker.HabitPauses, day time.Time) bool {
+	for _, pause := range pauses {
+		if day.Before(c.day(pause.PausedAt)) {
+			continue
+		}
+
+		if pause.ResumedAt == nil || day.Before(c.day(*pause.ResumedAt)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// local returns day, as returned by Config.day, at midnight in the Config
+// location.
+func (c Config) local(day time.Time) time.Time {
+	year, month, date := day.Date()
+
+	return time.Date(year, month, date, 0, 0, 0, 0, c.location())
+}
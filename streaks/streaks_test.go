@@ -0,0 +1,511 @@
+package streaks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+)
+
+func done(t time.Time) habit_tracker.HabitRecord {
+	return habit_tracker.HabitRecord{RecordDate: t, Result: habit_tracker.ResultDone}
+}
+
+func date(year int, month time.Month, day int, location *time.Location) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, location)
+}
+
+func TestCompute(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	daily := habit_tracker.Habit{Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleDaily}}
+	// 2023-07-03 is a Monday.
+	july := func(day, hour int) time.Time {
+		return time.Date(2023, 7, day, hour, 0, 0, 0, time.UTC)
+	}
+	resumedAt := func(day, hour int) *time.Time {
+		t := july(day, hour)
+
+		return &t
+	}
+	streak := func(start, end, length int) Streak {
+		return Streak{Start: date(2023, 7, start, time.UTC), End: date(2023, 7, end, time.UTC), Length: length}
+	}
+
+	type args struct {
+		habit   habit_tracker.Habit
+		records habit_tracker.HabitRecords
+		now     time.Time
+		cfg     Config
+	}
+	tests := []struct {
+		name string
+		args args
+		want Summary
+	}{
+		{
+			name: "NoRecords",
+			args: args{
+				habit: daily,
+				now:   july(5, 12),
+			},
+			want: Summary{Streaks: []Streak{}},
+		},
+		{
+			name: "DailyCurrent",
+			args: args{
+				habit:   daily,
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(4, 8)), done(july(5, 8))},
+				now:     july(5, 18),
+			},
+			want: Summary{
+				Current: streak(3, 5, 3),
+				Longest: streak(3, 5, 3),
+				Streaks: []Streak{streak(3, 5, 3)},
+			},
+		},
+		{
+			name: "DailyTodayPending",
+			args: args{
+				habit:   daily,
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(4, 8))},
+				now:     july(5, 10),
+			},
+			want: Summary{
+				Current: streak(3, 4, 2),
+				Longest: streak(3, 4, 2),
+				Streaks: []Streak{streak(3, 4, 2)},
+			},
+		},
+		{
+			name: "DailyMissedYesterday",
+			args: args{
+				habit:   daily,
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(4, 8))},
+				now:     july(6, 10),
+			},
+			want: Summary{
+				Longest: streak(3, 4, 2),
+				Streaks: []Streak{streak(3, 4, 2)},
+			},
+		},
+		{
+			name: "DailyLongestBeforeCurrent",
+			args: args{
+				habit: daily,
+				records: habit_tracker.HabitRecords{
+					done(july(8, 8)), done(july(3, 8)), done(july(4, 8)), done(july(5, 8)), done(july(7, 8)),
+				},
+				now: july(8, 20),
+			},
+			want: Summary{
+				Current: streak(7, 8, 2),
+				Longest: streak(3, 5, 3),
+				Streaks: []Streak{streak(3, 5, 3), streak(7, 8, 2)},
+			},
+		},
+		{
+			name: "LongestTieKeepsOldest",
+			args: args{
+				habit: daily,
+				records: habit_tracker.HabitRecords{
+					done(july(3, 8)),
+					{RecordDate: july(4, 8), Result: habit_tracker.ResultMissed},
+					done(july(5, 8)),
+				},
+				now: july(5, 20),
+			},
+			want: Summary{
+				Current: streak(5, 5, 1),
+				Longest: streak(3, 3, 1),
+				Streaks: []Streak{streak(3, 3, 1), streak(5, 5, 1)},
+			},
+		},
+		{
+			name: "DailySkippedNeutral",
+			args: args{
+				habit: daily,
+				records: habit_tracker.HabitRecords{
+					done(july(3, 8)),
+					{RecordDate: july(4, 8), Result: habit_tracker.ResultSkipped},
+					done(july(5, 8)),
+				},
+				now: july(5, 20),
+			},
+			want: Summary{
+				Current: streak(3, 5, 2),
+				Longest: streak(3, 5, 2),
+				Streaks: []Streak{streak(3, 5, 2)},
+			},
+		},
+		{
+			name: "SeveralRecordsADay",
+			args: args{
+				habit:   daily,
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(3, 20))},
+				now:     july(3, 21),
+			},
+			want: Summary{
+				Current: streak(3, 3, 1),
+				Longest: streak(3, 3, 1),
+				Streaks: []Streak{streak(3, 3, 1)},
+			},
+		},
+		{
+			name: "FutureRecordsIgnored",
+			args: args{
+				habit:   daily,
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(10, 8))},
+				now:     july(4, 10),
+			},
+			want: Summary{
+				Current: streak(3, 3, 1),
+				Longest: streak(3, 3, 1),
+				Streaks: []Streak{streak(3, 3, 1)},
+			},
+		},
+		{
+			name: "StartDateNeutral",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleDaily, StartDate: july(5, 0)},
+				},
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(5, 8)), done(july(6, 8))},
+				now:     july(6, 10),
+			},
+			want: Summary{
+				Current: streak(5, 6, 2),
+				Longest: streak(5, 6, 2),
+				Streaks: []Streak{streak(5, 6, 2)},
+			},
+		},
+		{
+			name: "WeekdaysNonDueDaysNeutral",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{
+						Kind:     habit_tracker.ScheduleWeekdays,
+						Weekdays: []time.Weekday{time.Monday, time.Wednesday, time.Friday},
+					},
+				},
+				records: habit_tracker.HabitRecords{
+					done(july(3, 8)), done(july(5, 8)), done(july(7, 8)), done(july(8, 8)), done(july(10, 8)),
+				},
+				now: july(11, 20),
+			},
+			want: Summary{
+				Current: streak(3, 10, 4),
+				Longest: streak(3, 10, 4),
+				Streaks: []Streak{streak(3, 10, 4)},
+			},
+		},
+		{
+			name: "WeekdaysMissAcrossWeekBoundary",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{
+						Kind:     habit_tracker.ScheduleWeekdays,
+						Weekdays: []time.Weekday{time.Monday, time.Friday},
+					},
+				},
+				records: habit_tracker.HabitRecords{done(july(7, 8)), done(july(9, 8)), done(july(14, 8))},
+				now:     july(14, 20),
+			},
+			want: Summary{
+				Current: streak(14, 14, 1),
+				Longest: streak(7, 7, 1),
+				Streaks: []Streak{streak(7, 7, 1), streak(14, 14, 1)},
+			},
+		},
+		{
+			name: "EveryNDaysBetweenDueDays",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleEveryNDays, Interval: 2, StartDate: july(3, 0)},
+				},
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(5, 8)), done(july(7, 8))},
+				now:     july(8, 20),
+			},
+			want: Summary{
+				Current: streak(3, 7, 3),
+				Longest: streak(3, 7, 3),
+				Streaks: []Streak{streak(3, 7, 3)},
+			},
+		},
+		{
+			name: "EveryNDaysMissed",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleEveryNDays, Interval: 2, StartDate: july(3, 0)},
+				},
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(5, 8)), done(july(7, 8))},
+				now:     july(10, 8),
+			},
+			want: Summary{
+				Longest: streak(3, 7, 3),
+				Streaks: []Streak{streak(3, 7, 3)},
+			},
+		},
+		{
+			name: "TimesPerWeekAcrossWeekBoundary",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 2},
+				},
+				// Saturday and Sunday, then Monday and Sunday, then Tuesday
+				// of a week still running.
+				records: habit_tracker.HabitRecords{
+					done(july(1, 8)), done(july(2, 8)), done(july(3, 8)), done(july(9, 8)), done(july(11, 8)),
+				},
+				now: july(12, 8),
+			},
+			want: Summary{
+				Current: streak(1, 9, 2),
+				Longest: streak(1, 9, 2),
+				Streaks: []Streak{streak(1, 9, 2)},
+			},
+		},
+		{
+			name: "TimesPerWeekMissedWeek",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 1},
+				},
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(17, 8))},
+				now:     july(18, 8),
+			},
+			want: Summary{
+				Current: streak(17, 17, 1),
+				Longest: streak(3, 3, 1),
+				Streaks: []Streak{streak(3, 3, 1), streak(17, 17, 1)},
+			},
+		},
+		{
+			name: "TimesPerWeekShortWeek",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 2},
+				},
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(4, 8)), done(july(10, 8))},
+				now:     july(17, 8),
+			},
+			want: Summary{
+				Longest: streak(3, 4, 1),
+				Streaks: []Streak{streak(3, 4, 1)},
+			},
+		},
+		{
+			name: "DailyPausedNeutral",
+			args: args{
+				habit: habit_tracker.Habit{
+					Pauses: habit_tracker.HabitPauses{
+						{PausedAt: july(5, 9), ResumedAt: resumedAt(8, 9)},
+					},
+				},
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(4, 8)), done(july(8, 20))},
+				now:     july(8, 21),
+			},
+			want: Summary{
+				Current: streak(3, 8, 3),
+				Longest: streak(3, 8, 3),
+				Streaks: []Streak{streak(3, 8, 3)},
+			},
+		},
+		{
+			name: "DailyStillPaused",
+			args: args{
+				habit: habit_tracker.Habit{
+					Pauses: habit_tracker.HabitPauses{{PausedAt: july(5, 9)}},
+				},
+				records: habit_tracker.HabitRecords{done(july(3, 8)), done(july(4, 8))},
+				now:     july(10, 12),
+			},
+			want: Summary{
+				Current: streak(3, 4, 2),
+				Longest: streak(3, 4, 2),
+				Streaks: []Streak{streak(3, 4, 2)},
+			},
+		},
+		{
+			name: "TimesPerWeekPausedWeek",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 2},
+					Pauses: habit_tracker.HabitPauses{
+						{PausedAt: july(11, 9), ResumedAt: resumedAt(20, 9)},
+					},
+				},
+				records: habit_tracker.HabitRecords{
+					done(july(3, 8)), done(july(4, 8)), done(july(10, 8)), done(july(20, 8)), done(july(21, 8)),
+					done(july(22, 8)),
+				},
+				now: july(22, 12),
+			},
+			want: Summary{
+				Current: streak(3, 22, 2),
+				Longest: streak(3, 22, 2),
+				Streaks: []Streak{streak(3, 22, 2)},
+			},
+		},
+		{
+			name: "TimesPerWeekSkippedWeek",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 2},
+				},
+				records: habit_tracker.HabitRecords{
+					done(july(3, 8)), done(july(4, 8)), done(july(10, 8)),
+					{RecordDate: july(12, 8), Result: habit_tracker.ResultSkipped},
+					done(july(17, 8)), done(july(18, 8)),
+				},
+				now: july(18, 12),
+			},
+			want: Summary{
+				Current: streak(3, 18, 2),
+				Longest: streak(3, 18, 2),
+				Streaks: []Streak{streak(3, 18, 2)},
+			},
+		},
+		{
+			name: "TimesPerMonth",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerMonth, Times: 2},
+				},
+				records: habit_tracker.HabitRecords{
+					done(time.Date(2023, 6, 1, 8, 0, 0, 0, time.UTC)),
+					done(time.Date(2023, 6, 30, 8, 0, 0, 0, time.UTC)),
+					done(july(31, 8)),
+					done(time.Date(2023, 8, 1, 8, 0, 0, 0, time.UTC)),
+				},
+				now: time.Date(2023, 8, 2, 8, 0, 0, 0, time.UTC),
+			},
+			want: Summary{
+				Longest: Streak{Start: date(2023, 6, 1, time.UTC), End: date(2023, 6, 30, time.UTC), Length: 1},
+				Streaks: []Streak{{Start: date(2023, 6, 1, time.UTC), End: date(2023, 6, 30, time.UTC), Length: 1}},
+			},
+		},
+		{
+			name: "DayBoundary",
+			args: args{
+				habit: daily,
+				// 2am counts for the day before, as does now.
+				records: habit_tracker.HabitRecords{done(july(3, 23)), done(july(5, 2)), done(july(5, 22))},
+				now:     july(6, 3),
+				cfg:     Config{DayBoundary: 4 * time.Hour},
+			},
+			want: Summary{
+				Current: streak(3, 5, 3),
+				Longest: streak(3, 5, 3),
+				Streaks: []Streak{streak(3, 5, 3)},
+			},
+		},
+		{
+			name: "TimeZone",
+			args: args{
+				habit: daily,
+				// 16:00 UTC is 01:00 the next day in Tokyo.
+				records: habit_tracker.HabitRecords{done(july(3, 16)), done(july(4, 16))},
+				now:     july(5, 0),
+				cfg:     Config{Location: tokyo},
+			},
+			want: Summary{
+				Current: Streak{Start: date(2023, 7, 4, tokyo), End: date(2023, 7, 5, tokyo), Length: 2},
+				Longest: Streak{Start: date(2023, 7, 4, tokyo), End: date(2023, 7, 5, tokyo), Length: 2},
+				Streaks: []Streak{{Start: date(2023, 7, 4, tokyo), End: date(2023, 7, 5, tokyo), Length: 2}},
+			},
+		},
+		{
+			name: "DSTSpringForward",
+			args: args{
+				habit: daily,
+				// 2023-03-12 lasts 23 hours in New York.
+				records: habit_tracker.HabitRecords{
+					done(time.Date(2023, 3, 11, 23, 30, 0, 0, newYork)),
+					done(time.Date(2023, 3, 12, 23, 30, 0, 0, newYork)),
+					done(time.Date(2023, 3, 13, 23, 30, 0, 0, newYork)),
+				},
+				now: time.Date(2023, 3, 13, 23, 45, 0, 0, newYork),
+				cfg: Config{Location: newYork},
+			},
+			want: Summary{
+				Current: Streak{Start: date(2023, 3, 11, newYork), End: date(2023, 3, 13, newYork), Length: 3},
+				Longest: Streak{Start: date(2023, 3, 11, newYork), End: date(2023, 3, 13, newYork), Length: 3},
+				Streaks: []Streak{{Start: date(2023, 3, 11, newYork), End: date(2023, 3, 13, newYork), Length: 3}},
+			},
+		},
+		{
+			name: "DSTFallBack",
+			args: args{
+				habit: daily,
+				// 2023-11-05 lasts 25 hours in New York: both records are on
+				// that day.
+				records: habit_tracker.HabitRecords{
+					done(time.Date(2023, 11, 5, 0, 30, 0, 0, newYork)),
+					done(time.Date(2023, 11, 5, 23, 30, 0, 0, newYork)),
+					done(time.Date(2023, 11, 6, 0, 15, 0, 0, newYork)),
+				},
+				now: time.Date(2023, 11, 6, 12, 0, 0, 0, newYork),
+				cfg: Config{Location: newYork},
+			},
+			want: Summary{
+				Current: Streak{Start: date(2023, 11, 5, newYork), End: date(2023, 11, 6, newYork), Length: 2},
+				Longest: Streak{Start: date(2023, 11, 5, newYork), End: date(2023, 11, 6, newYork), Length: 2},
+				Streaks: []Streak{{Start: date(2023, 11, 5, newYork), End: date(2023, 11, 6, newYork), Length: 2}},
+			},
+		},
+		{
+			name: "DSTDayBoundary",
+			args: args{
+				habit: daily,
+				// The boundary is a wall clock time: 03:30 on 2023-03-12 is
+				// before 4am though only 2.5 hours after midnight.
+				records: habit_tracker.HabitRecords{
+					done(time.Date(2023, 3, 11, 3, 0, 0, 0, newYork)),
+					done(time.Date(2023, 3, 12, 3, 30, 0, 0, newYork)),
+					done(time.Date(2023, 3, 12, 20, 0, 0, 0, newYork)),
+				},
+				now: time.Date(2023, 3, 12, 21, 0, 0, 0, newYork),
+				cfg: Config{Location: newYork, DayBoundary: 4 * time.Hour},
+			},
+			want: Summary{
+				Current: Streak{Start: date(2023, 3, 10, newYork), End: date(2023, 3, 12, newYork), Length: 3},
+				Longest: Streak{Start: date(2023, 3, 10, newYork), End: date(2023, 3, 12, newYork), Length: 3},
+				Streaks: []Streak{{Start: date(2023, 3, 10, newYork), End: date(2023, 3, 12, newYork), Length: 3}},
+			},
+		},
+		{
+			name: "WeekBoundaryInTimeZone",
+			args: args{
+				habit: habit_tracker.Habit{
+					Schedule: habit_tracker.Schedule{Kind: habit_tracker.ScheduleTimesPerWeek, Times: 1},
+				},
+				// Sunday 23:30 in New York is already Monday in UTC.
+				records: habit_tracker.HabitRecords{
+					done(time.Date(2023, 7, 9, 23, 30, 0, 0, newYork)),
+					done(time.Date(2023, 7, 10, 12, 0, 0, 0, newYork)),
+				},
+				now: time.Date(2023, 7, 11, 12, 0, 0, 0, newYork),
+				cfg: Config{Location: newYork},
+			},
+			want: Summary{
+				Current: Streak{Start: date(2023, 7, 9, newYork), End: date(2023, 7, 10, newYork), Length: 2},
+				Longest: Streak{Start: date(2023, 7, 9, newYork), End: date(2023, 7, 10, newYork), Length: 2},
+				Streaks: []Streak{{Start: date(2023, 7, 9, newYork), End: date(2023, 7, 10, newYork), Length: 2}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				assert.Equal(t, tt.want, Compute(tt.args.habit, tt.args.records, tt.args.now, tt.args.cfg))
+			},
+		)
+	}
+}
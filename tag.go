@@ -6,15 +6,47 @@ import (
 )
 
 type Tag struct {
-	ID          uint64
-	Name        string
-	Description string
+	ID          uint64     `sql:"id"`
+	Name        string     `sql:"name"`
+	Description string     `sql:"description"`
+	CreatedAt   time.Time  `sql:"created_at"`
+	UpdatedAt   time.Time  `sql:"updated_at"`
+	DeletedAt   *time.Time `sql:"deleted_at"`
+	Version     uint64     `sql:"version"`
+}
+
+type HabitTag struct {
+	HabitID uint64 `sql:"habit_id"`
+	TagID   uint64 `sql:"tag_id"`
+}
+
+type TagFilter struct {
+	NamePrefix     string
+	IncludeDeleted bool
+	Page
 }
 
 type Tags []Tag
+type HabitTags []HabitTag
 
 //go:generate mockery --name TagRepository --filename tag_repository.go --outpkg mocks --structname TagRepository --disable-version-string
 type TagRepository interface {
+	// InsertTags fills the ID, CreatedAt, UpdatedAt and Version generated for each element.
 	InsertTags(ctx context.Context, tags Tags, now time.Time) error
+	// UpdateTags checks and bumps the Version of the tags like UpdateGoals.
 	UpdateTags(ctx context.Context, tags Tags, now time.Time) error
+	GetTagByID(ctx context.Context, id uint64) (Tag, error)
+	GetTagsByIDs(ctx context.Context, ids []uint64) (Tags, error)
+	ListTags(ctx context.Context, filter TagFilter) (Tags, error)
+	DeleteTags(ctx context.Context, ids []uint64, now time.Time) error
+	RestoreTags(ctx context.Context, ids []uint64, now time.Time) error
+	// PurgeTags removes the tags for good, detaching them from their habits.
+	PurgeTags(ctx context.Context, ids []uint64) error
+	// AttachTags links tags to habits, ignoring links that already exist.
+	AttachTags(ctx context.Context, habitTags HabitTags) error
+	DetachTags(ctx context.Context, habitTags HabitTags) error
+	// ReplaceHabitTags atomically makes tagIDs the full tag set of the habit.
+	ReplaceHabitTags(ctx context.Context, habitID uint64, tagIDs []uint64) error
+	// ListTagsByHabitIDs returns the tags of each habit, keyed by habit ID.
+	ListTagsByHabitIDs(ctx context.Context, habitIDs []uint64) (map[uint64]Tags, error)
 }
@@ -0,0 +1,152 @@
+package habit_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+type TargetComparison string
+
+const (
+	TargetAtLeast TargetComparison = "at_least"
+	TargetAtMost  TargetComparison = "at_most"
+	TargetExactly TargetComparison = "exactly"
+)
+
+type TargetPeriod string
+
+const (
+	TargetPerDay  TargetPeriod = "day"
+	TargetPerWeek TargetPeriod = "week"
+)
+
+// TargetResult is the total of the record Values in the period from From to
+// To, excluded, and whether it met the target of the habit.
+type TargetResult struct {
+	From  time.Time
+	To    time.Time
+	Total float64
+	Met   bool
+}
+
+// exactlyTolerance absorbs the rounding of summed float Values.
+const exactlyTolerance = 1e-9
+
+// Measurable reports whether the habit has a target, i.e. a TargetComparison.
+func (h Habit) Measurable() bool {
+	return h.TargetComparison != ""
+}
+
+func (h Habit) validateTarget() error {
+	if !h.Measurable() {
+		if h.TargetValue != 0 || h.TargetUnit != "" || h.TargetPeriod != "" {
+			return errors.New("target without comparison")
+		}
+
+		return nil
+	}
+
+	switch h.TargetComparison {
+	case TargetAtLeast, TargetAtMost, TargetExactly:
+	default:
+		return fmt.Errorf("unknown target comparison %q", h.TargetComparison)
+	}
+
+	switch h.TargetPeriod {
+	case "", TargetPerDay, TargetPerWeek:
+	default:
+		return fmt.Errorf("unknown target period %q", h.TargetPeriod)
+	}
+
+	if h.TargetValue < 0 || math.IsNaN(h.TargetValue) || math.IsInf(h.TargetValue, 0) {
+		return fmt.Errorf("target value %v isn't a non-negative number", h.TargetValue)
+	}
+
+	return nil
+}
+
+// TargetMet reports whether total meets the target of the habit.
+func (h Habit) TargetMet(total float64) bool {
+	switch h.TargetComparison {
+	case TargetAtLeast:
+		return total >= h.TargetValue
+	case TargetAtMost:
+		return total <= h.TargetValue
+	case TargetExactly:
+		return math.Abs(total-h.TargetValue) < exactlyTolerance
+	default:
+		return false
+	}
+}
+
+// TargetPeriodOf returns the start and the end, excluded, of the target period
+// date belongs to, in the location of date: the day, or the week starting on
+// Monday.
+func (h Habit) TargetPeriodOf(date time.Time) (time.Time, time.Time) {
+	if h.TargetPeriod == TargetPerWeek {
+		return Schedule{Kind: ScheduleTimesPerWeek}.Period(date)
+	}
+
+	return Schedule{}.Period(date)
+}
+
+// TargetResults returns the result of every target period from the one of
+// from to the one of to, both included, in the location of from. records may
+// hold any records of the habit: the Values in TargetUnit, or without a Unit,
+// are summed and the others ignored. The period including now may not be
+// over yet, its result can still change.
+func (h Habit) TargetResults(records HabitRecords, from, to time.Time) []TargetResult {
+	results := make([]TargetResult, 0)
+	for start, end := h.TargetPeriodOf(from); !start.After(to); start, end = h.TargetPeriodOf(end) {
+		result := TargetResult{From: start, To: end}
+		for _, record := range records {
+			if record.Value == nil || (record.Unit != "" && record.Unit != h.TargetUnit) {
+				continue
+			}
+
+			if !record.RecordDate.Before(start) && record.RecordDate.Before(end) {
+				result.Total += *record.Value
+			}
+		}
+
+		result.Met = h.TargetMet(result.Total)
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// HabitTargetResults lists the records of the habit to return its target
+// results from the period of from to the one of to, see Habit.TargetResults.
+// It fails with a validation error if the habit isn't measurable.
+func HabitTargetResults(ctx context.Context, habitRepository HabitRepository, habitID uint64,
+	from, to time.Time) ([]TargetResult, error) {
+	habit, err := habitRepository.GetHabitByID(ctx, habitID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !habit.Measurable() {
+		return nil, &Error{
+			Kind:   ErrValidation,
+			Entity: "habits",
+			IDs:    []uint64{habitID},
+			Err:    errors.New("habit without target"),
+		}
+	}
+
+	start, _ := habit.TargetPeriodOf(from)
+	_, end := habit.TargetPeriodOf(to.In(from.Location()))
+
+	records, err := habitRepository.ListHabitRecords(
+		ctx, HabitRecordFilter{HabitID: habitID, From: start, To: end},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return habit.TargetResults(records, from, to), nil
+}
@@ -0,0 +1,190 @@
+package habit_tracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"habit-tracker"
+	"habit-tracker/mocks"
+)
+
+func value(v float64) *float64 {
+	return &v
+}
+
+func TestHabit_TargetMet(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparison habit_tracker.TargetComparison
+		total      float64
+		want       bool
+	}{
+		{name: "AtLeastMet", comparison: habit_tracker.TargetAtLeast, total: 2, want: true},
+		{name: "AtLeastMissed", comparison: habit_tracker.TargetAtLeast, total: 1.9},
+		{name: "AtMostMet", comparison: habit_tracker.TargetAtMost, total: 0, want: true},
+		{name: "AtMostMissed", comparison: habit_tracker.TargetAtMost, total: 2.1},
+		{name: "ExactlyMet", comparison: habit_tracker.TargetExactly, total: 0.1 + 0.2 + 1.7, want: true},
+		{name: "ExactlyMissed", comparison: habit_tracker.TargetExactly, total: 3},
+		{name: "NotMeasurable", total: 2},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				habit := habit_tracker.Habit{TargetValue: 2, TargetComparison: tt.comparison}
+
+				assert.Equal(t, tt.want, habit.TargetMet(tt.total))
+			},
+		)
+	}
+}
+
+func TestHabit_TargetResults(t *testing.T) {
+	// 2023-07-03 is a Monday.
+	records := habit_tracker.HabitRecords{
+		{RecordDate: time.Date(2023, 7, 3, 8, 0, 0, 0, time.UTC), Value: value(20), Unit: "pages"},
+		{RecordDate: time.Date(2023, 7, 3, 20, 0, 0, 0, time.UTC), Value: value(15)},
+		{RecordDate: time.Date(2023, 7, 4, 8, 0, 0, 0, time.UTC), Value: value(10), Unit: "pages"},
+		{RecordDate: time.Date(2023, 7, 4, 9, 0, 0, 0, time.UTC), Value: value(50), Unit: "minutes"},
+		{RecordDate: time.Date(2023, 7, 5, 8, 0, 0, 0, time.UTC), Result: habit_tracker.ResultDone},
+		{RecordDate: time.Date(2023, 7, 10, 8, 0, 0, 0, time.UTC), Value: value(40), Unit: "pages"},
+	}
+
+	tests := []struct {
+		name  string
+		habit habit_tracker.Habit
+		from  time.Time
+		to    time.Time
+		want  []habit_tracker.TargetResult
+	}{
+		{
+			name: "PerDay",
+			habit: habit_tracker.Habit{
+				TargetValue:      30,
+				TargetUnit:       "pages",
+				TargetComparison: habit_tracker.TargetAtLeast,
+			},
+			from: time.Date(2023, 7, 3, 12, 0, 0, 0, time.UTC),
+			to:   time.Date(2023, 7, 5, 12, 0, 0, 0, time.UTC),
+			want: []habit_tracker.TargetResult{
+				{
+					From:  time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC),
+					To:    time.Date(2023, 7, 4, 0, 0, 0, 0, time.UTC),
+					Total: 35,
+					Met:   true,
+				},
+				{
+					From:  time.Date(2023, 7, 4, 0, 0, 0, 0, time.UTC),
+					To:    time.Date(2023, 7, 5, 0, 0, 0, 0, time.UTC),
+					Total: 10,
+				},
+				{
+					From: time.Date(2023, 7, 5, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2023, 7, 6, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			name: "PerWeek",
+			habit: habit_tracker.Habit{
+				TargetValue:      50,
+				TargetUnit:       "pages",
+				TargetComparison: habit_tracker.TargetAtMost,
+				TargetPeriod:     habit_tracker.TargetPerWeek,
+			},
+			from: time.Date(2023, 7, 5, 12, 0, 0, 0, time.UTC),
+			to:   time.Date(2023, 7, 10, 12, 0, 0, 0, time.UTC),
+			want: []habit_tracker.TargetResult{
+				{
+					From:  time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC),
+					To:    time.Date(2023, 7, 10, 0, 0, 0, 0, time.UTC),
+					Total: 45,
+					Met:   true,
+				},
+				{
+					From:  time.Date(2023, 7, 10, 0, 0, 0, 0, time.UTC),
+					To:    time.Date(2023, 7, 17, 0, 0, 0, 0, time.UTC),
+					Total: 40,
+					Met:   true,
+				},
+			},
+		},
+		{
+			name: "Exactly",
+			habit: habit_tracker.Habit{
+				TargetValue:      50,
+				TargetUnit:       "minutes",
+				TargetComparison: habit_tracker.TargetExactly,
+			},
+			from: time.Date(2023, 7, 4, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2023, 7, 4, 23, 0, 0, 0, time.UTC),
+			want: []habit_tracker.TargetResult{
+				{
+					From:  time.Date(2023, 7, 4, 0, 0, 0, 0, time.UTC),
+					To:    time.Date(2023, 7, 5, 0, 0, 0, 0, time.UTC),
+					Total: 50,
+					Met:   true,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name,
+			func(t *testing.T) {
+				assert.Equal(t, tt.want, tt.habit.TargetResults(records, tt.from, tt.to))
+			},
+		)
+	}
+}
+
+func TestHabitTargetResults(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2023, 7, 3, 12, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 7, 4, 12, 0, 0, 0, time.UTC)
+
+	habitRepository := mocks.NewHabitRepository(t)
+	habitRepository.On("GetHabitByID", ctx, uint64(1)).Return(
+		habit_tracker.Habit{ID: 1, TargetValue: 2, TargetUnit: "l", TargetComparison: habit_tracker.TargetAtLeast}, nil,
+	)
+	habitRepository.On("GetHabitByID", ctx, uint64(2)).Return(habit_tracker.Habit{ID: 2}, nil)
+	habitRepository.On(
+		"ListHabitRecords", ctx, habit_tracker.HabitRecordFilter{
+			HabitID: 1,
+			From:    time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC),
+			To:      time.Date(2023, 7, 5, 0, 0, 0, 0, time.UTC),
+		},
+	).Return(
+		habit_tracker.HabitRecords{
+			{HabitID: 1, RecordDate: time.Date(2023, 7, 3, 9, 0, 0, 0, time.UTC), Value: value(1.5), Unit: "l"},
+			{HabitID: 1, RecordDate: time.Date(2023, 7, 3, 18, 0, 0, 0, time.UTC), Value: value(0.5), Unit: "l"},
+		}, nil,
+	)
+
+	got, err := habit_tracker.HabitTargetResults(ctx, habitRepository, 1, from, to)
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]habit_tracker.TargetResult{
+			{
+				From:  time.Date(2023, 7, 3, 0, 0, 0, 0, time.UTC),
+				To:    time.Date(2023, 7, 4, 0, 0, 0, 0, time.UTC),
+				Total: 2,
+				Met:   true,
+			},
+			{
+				From: time.Date(2023, 7, 4, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2023, 7, 5, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		got,
+	)
+
+	_, err = habit_tracker.HabitTargetResults(ctx, habitRepository, 2, from, to)
+
+	assert.ErrorIs(t, err, habit_tracker.ErrValidation)
+}
@@ -0,0 +1,12 @@
+package habit_tracker
+
+import "context"
+
+// Transactor runs fn as a single unit of work. Repository calls made with the
+// context passed to fn take part in it, so they are all committed or all
+// rolled back. Nested calls roll back only their own work on failure.
+//
+//go:generate mockery --name Transactor --filename transactor.go --outpkg mocks --structname Transactor --disable-version-string
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}